@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// aggregates are the continuous aggregates materialized from raw events.
+// Migrations create them WITH NO DATA and only refresh recent buckets, so
+// older history is missing from them until backfilled.
+var aggregates = []string{
+	"events_hourly",
+	"events_daily",
+	"events_daily_jakarta",
+	"daily_active_users",
+}
+
+// backfill materializes the continuous aggregates over the whole event
+// history, one chunk at a time so that no single refresh holds locks or
+// memory for the full range. It is safe to rerun; refreshing a chunk that is
+// already materialized is cheap.
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+
+	views := flag.String("views", strings.Join(aggregates, ","), "comma-separated continuous aggregates to refresh")
+	chunk := flag.Duration("chunk", 7*24*time.Hour, "width of each refresh window")
+	fromStr := flag.String("from", "", "start of the backfill (RFC3339); defaults to the oldest event")
+	flag.Parse()
+
+	// Daily aggregates cannot refresh a window narrower than a day
+	if *chunk < 24*time.Hour {
+		log.Fatalf("Invalid chunk %s: must be at least 24h", *chunk)
+	}
+
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		dbUser := getEnvOrDefault("POSTGRES_USER", "postgres")
+		dbPassword := getEnvOrDefault("POSTGRES_PASSWORD", "postgres")
+		dbHost := getEnvOrDefault("POSTGRES_HOST", "localhost")
+		dbPort := getEnvOrDefault("POSTGRES_PORT", "5432")
+		dbName := getEnvOrDefault("POSTGRES_DB", "telemetry")
+		dbSSLMode := getEnvOrDefault("POSTGRES_SSLMODE", "disable")
+		databaseURL = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
+			dbUser, dbPassword, dbHost, dbPort, dbName, dbSSLMode)
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, databaseURL)
+	if err != nil {
+		log.Fatalf("Unable to connect to database: %v", err)
+	}
+	defer pool.Close()
+
+	var from time.Time
+	if *fromStr != "" {
+		if from, err = time.Parse(time.RFC3339, *fromStr); err != nil {
+			log.Fatalf("Invalid from %q: %v", *fromStr, err)
+		}
+	} else {
+		var oldest *time.Time
+		if err := pool.QueryRow(ctx, "SELECT MIN(timestamp) FROM events").Scan(&oldest); err != nil {
+			log.Fatalf("Unable to find the oldest event: %v", err)
+		}
+		if oldest == nil {
+			log.Println("No events, nothing to backfill")
+			return
+		}
+		from = *oldest
+	}
+	from = from.UTC().Truncate(24 * time.Hour)
+	to := time.Now().UTC()
+
+	for _, view := range strings.Split(*views, ",") {
+		view = strings.TrimSpace(view)
+		if view == "" {
+			continue
+		}
+		for start := from; start.Before(to); start = start.Add(*chunk) {
+			// The last window may end in the future; the refresh stops at
+			// the latest complete bucket
+			end := start.Add(*chunk)
+			// refresh_continuous_aggregate refuses to run in a transaction
+			// block, which the extended protocol may open implicitly
+			if _, err := pool.Exec(ctx, "CALL refresh_continuous_aggregate($1::regclass, $2::timestamptz, $3::timestamptz)",
+				pgx.QueryExecModeSimpleProtocol, view, start, end); err != nil {
+				log.Fatalf("Unable to refresh %s from %s to %s: %v", view, start.Format(time.RFC3339), end.Format(time.RFC3339), err)
+			}
+			log.Printf("Refreshed %s from %s to %s", view, start.Format(time.RFC3339), end.Format(time.RFC3339))
+		}
+	}
+
+	log.Println("Backfill done")
+}
+
+func getEnvOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -4,17 +4,22 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	delivery "github.com/herpiko/blankon-telemetry-backend/internal/delivery/http"
+	"github.com/herpiko/blankon-telemetry-backend/internal/ingest"
 	"github.com/herpiko/blankon-telemetry-backend/internal/repo"
+	"github.com/herpiko/blankon-telemetry-backend/internal/spool"
 	"github.com/herpiko/blankon-telemetry-backend/internal/usecase"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
 )
 
 func main() {
@@ -58,12 +63,83 @@ func main() {
 	// Initialize layers
 	eventRepo := repo.NewEventRepository(pool)
 	analyticsRepo := repo.NewAnalyticsRepository(pool)
+	schemaRepo := repo.NewSchemaRepository(pool)
+	quarantineRepo := repo.NewQuarantineRepository(pool)
+	apiKeyRepo := repo.NewAPIKeyRepository(pool)
+	signingRepo := repo.NewSigningSecretRepository(pool)
 	
-	eventUC := usecase.NewEventUsecase(eventRepo)
+	idempotencyWindow := getEnvDuration("IDEMPOTENCY_WINDOW", usecase.DefaultIdempotencyWindow)
+	if idempotencyWindow > usecase.MaxIdempotencyWindow {
+		log.Fatalf("Invalid IDEMPOTENCY_WINDOW %s: keys are purged after %s", idempotencyWindow, usecase.MaxIdempotencyWindow)
+	}
+	schemaUC := usecase.NewSchemaUsecase(schemaRepo)
+	eventOpts := []usecase.EventOption{
+		usecase.WithIdempotencyWindow(idempotencyWindow),
+		usecase.WithSchemaValidator(schemaUC),
+		usecase.WithQuarantine(quarantineRepo),
+		usecase.WithMaxPayloadSize(getEnvInt("MAX_PAYLOAD_SIZE", 64<<10)),
+	}
+
+	// Background writes deduplicate keyed events just like the request path
+	eventWriter := usecase.NewEventWriter(eventRepo, idempotencyWindow)
+
+	// Optional on-disk spool: events are kept locally while the database is down
+	var eventSpool *spool.Spool
+	if spoolDir := os.Getenv("SPOOL_DIR"); spoolDir != "" {
+		eventSpool, err = spool.Open(spool.Config{
+			Dir:            spoolDir,
+			SegmentSize:    int64(getEnvInt("SPOOL_SEGMENT_SIZE", 16<<20)),
+			ReplayInterval: getEnvDuration("SPOOL_REPLAY_INTERVAL", 5*time.Second),
+		}, eventWriter, pool)
+		if err != nil {
+			log.Fatalf("Unable to open spool: %v", err)
+		}
+		eventSpool.Start()
+		eventOpts = append(eventOpts, usecase.WithSpool(eventSpool))
+		log.Printf("Spooling to %s when the database is unavailable", spoolDir)
+	}
+
+	// Optional asynchronous write path: events are buffered and COPYed in batches
+	var queue *ingest.Queue
+	if getEnvBool("INGEST_ASYNC", false) {
+		queueCfg := ingest.Config{
+			Capacity:      getEnvInt("INGEST_QUEUE_CAPACITY", 10000),
+			BatchSize:     getEnvInt("INGEST_BATCH_SIZE", 1000),
+			FlushInterval: getEnvDuration("INGEST_FLUSH_INTERVAL", time.Second),
+		}
+		if eventSpool != nil {
+			queueCfg.Fallback = func(events []*models.Event) error {
+				return eventSpool.Append(events...)
+			}
+		}
+		queue = ingest.NewQueue(eventWriter, queueCfg)
+		queue.Start()
+		eventOpts = append(eventOpts, usecase.WithQueue(queue))
+		log.Printf("Asynchronous ingest enabled: %+v", queue.Stats())
+	}
+
+	eventUC := usecase.NewEventUsecase(eventRepo, eventOpts...)
 	analyticsUC := usecase.NewAnalyticsUsecase(analyticsRepo)
+	quarantineUC := usecase.NewQuarantineUsecase(quarantineRepo, eventUC)
+	authUC := usecase.NewAuthUsecase(apiKeyRepo, usecase.AuthConfig{
+		BootstrapAdminKey: os.Getenv("ADMIN_API_KEY"),
+	})
+
+	signingMode := getEnvOrDefault("SIGNING_MODE", usecase.SigningOff)
+	if !usecase.ValidSigningMode(signingMode) {
+		log.Fatalf("Invalid SIGNING_MODE %q", signingMode)
+	}
+	signingUC := usecase.NewSigningUsecase(signingRepo, usecase.SigningConfig{
+		Mode:      signingMode,
+		Tolerance: getEnvDuration("SIGNATURE_TOLERANCE", usecase.DefaultSignatureTolerance),
+	})
 	
-	handler := delivery.NewHandler(eventUC, analyticsUC)
-	router := delivery.NewRouter(handler)
+	handler := delivery.NewHandler(eventUC, analyticsUC, schemaUC, quarantineUC, authUC, signingUC)
+	router := delivery.NewRouter(handler, delivery.RouterConfig{
+		AuthRateLimit:      getEnvRateLimit("AUTH", delivery.RateLimitByIP),
+		EventsRateLimit:    getEnvRateLimit("EVENTS", delivery.RateLimitByAPIKey),
+		AnalyticsRateLimit: getEnvRateLimit("ANALYTICS", delivery.RateLimitByAPIKey),
+	})
 
 	// Create server
 	server := &http.Server{
@@ -92,8 +168,28 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// Keep going on failure: events already acknowledged still have to be
+	// drained from the queue and spool
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+
+	// Flush whatever is still buffered
+	if queue != nil {
+		log.Printf("Draining ingest queue (%d events)...", queue.Depth())
+		// The shutdown deadline may already be spent, so the drain gets
+		// its own
+		drainCtx, drainCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer drainCancel()
+		if err := queue.Close(drainCtx); err != nil {
+			log.Printf("Ingest queue not fully drained: %v", err)
+		}
+	}
+
+	if eventSpool != nil {
+		if err := eventSpool.Close(); err != nil {
+			log.Printf("Failed to close spool: %v", err)
+		}
 	}
 
 	log.Println("Server stopped")
@@ -105,3 +201,70 @@ func getEnvOrDefault(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Fatalf("Invalid %s %q: %v", key, v, err)
+	}
+	return n
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Fatalf("Invalid %s %q: %v", key, v, err)
+	}
+	return b
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("Invalid %s %q: %v", key, v, err)
+	}
+	return d
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s %q: %v", key, v, err)
+	}
+	return f
+}
+
+// getEnvRateLimit reads <group>_RATE_LIMIT (requests per second, 0 to
+// disable), <group>_RATE_BURST and <group>_RATE_LIMIT_KEY.
+func getEnvRateLimit(group string, defaultKey string) delivery.RateLimit {
+	limit := delivery.RateLimit{
+		Rate:  getEnvFloat(group+"_RATE_LIMIT", 0),
+		Burst: getEnvInt(group+"_RATE_BURST", 0),
+		Key:   getEnvOrDefault(group+"_RATE_LIMIT_KEY", defaultKey),
+	}
+	switch limit.Key {
+	case delivery.RateLimitByAPIKey, delivery.RateLimitByIP, delivery.RateLimitByInstall:
+	default:
+		log.Fatalf("Invalid %s_RATE_LIMIT_KEY %q", group, limit.Key)
+	}
+	if limit.Burst <= 0 {
+		limit.Burst = int(math.Ceil(limit.Rate))
+	}
+	return limit
+}
@@ -3,9 +3,13 @@ package http
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -14,20 +18,29 @@ import (
 )
 
 type Handler struct {
-	eventUC     usecase.EventUsecase
-	analyticsUC usecase.AnalyticsUsecase
+	eventUC      usecase.EventUsecase
+	analyticsUC  usecase.AnalyticsUsecase
+	schemaUC     usecase.SchemaUsecase
+	quarantineUC usecase.QuarantineUsecase
+	authUC       usecase.AuthUsecase
+	signingUC    usecase.SigningUsecase
 }
 
-func NewHandler(eventUC usecase.EventUsecase, analyticsUC usecase.AnalyticsUsecase) *Handler {
+func NewHandler(eventUC usecase.EventUsecase, analyticsUC usecase.AnalyticsUsecase, schemaUC usecase.SchemaUsecase, quarantineUC usecase.QuarantineUsecase, authUC usecase.AuthUsecase, signingUC usecase.SigningUsecase) *Handler {
 	return &Handler{
-		eventUC:     eventUC,
-		analyticsUC: analyticsUC,
+		eventUC:      eventUC,
+		analyticsUC:  analyticsUC,
+		schemaUC:     schemaUC,
+		quarantineUC: quarantineUC,
+		authUC:       authUC,
+		signingUC:    signingUC,
 	}
 }
 
 type response struct {
-	Data  interface{} `json:"data,omitempty"`
-	Error string      `json:"error,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Details interface{} `json:"details,omitempty"`
 }
 
 func (h *Handler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -42,33 +55,138 @@ func (h *Handler) respondError(w http.ResponseWriter, status int, message string
 	json.NewEncoder(w).Encode(response{Error: message})
 }
 
+// pageResponse is the envelope of event listings: data stays the list of
+// events, as it was before pagination, with the next page's cursor beside it.
+type pageResponse struct {
+	Data       []models.Event `json:"data"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// respondPage writes one page of listed events.
+func (h *Handler) respondPage(w http.ResponseWriter, page *models.EventPage) {
+	events := page.Events
+	if events == nil {
+		events = []models.Event{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(pageResponse{Data: events, NextCursor: page.NextCursor})
+}
+
+// respondInvalidPayload reports a schema violation together with the
+// failing fields.
+func (h *Handler) respondInvalidPayload(w http.ResponseWriter, verr *usecase.ValidationError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(response{Error: verr.Error(), Details: verr.Fields})
+}
+
+// respondBusy asks the client to retry shortly when the ingest queue is
+// saturated.
+func (h *Handler) respondBusy(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", "1")
+	h.respondError(w, http.StatusServiceUnavailable, "ingest queue is full, retry later")
+}
+
 func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 	h.respondJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
 func (h *Handler) CreateEvent(w http.ResponseWriter, r *http.Request) {
+	if isNDJSON(r) {
+		h.createEventStream(w, r)
+		return
+	}
+
 	var req models.CreateEventRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	capture := &rawCapture{}
+	if err := json.NewDecoder(io.TeeReader(r.Body, capture)).Decode(&req); err != nil {
+		if bodyTooLarge(err) {
+			h.respondError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
 		log.Printf("CreateEvent: invalid request body: %v", err)
+		h.quarantineBody(r, capture, err)
 		h.respondError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	event, err := h.eventUC.CreateEvent(r.Context(), req)
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = r.Header.Get("Idempotency-Key")
+	}
+
+	event, status, err := h.eventUC.CreateEvent(r.Context(), req)
 	if err != nil {
+		var verr *usecase.ValidationError
+		if errors.As(err, &verr) {
+			log.Printf("CreateEvent: payload rejected: %v", err)
+			h.respondInvalidPayload(w, verr)
+			return
+		}
+		if errors.Is(err, usecase.ErrPayloadTooLarge) {
+			h.respondError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
 		if errors.Is(err, usecase.ErrInvalidEvent) {
 			log.Printf("CreateEvent: invalid event: %v", err)
 			h.respondError(w, http.StatusBadRequest, err.Error())
 			return
 		}
+		if errors.Is(err, usecase.ErrQueueFull) {
+			h.respondBusy(w)
+			return
+		}
 		log.Printf("CreateEvent: failed to create event: %v", err)
 		h.respondError(w, http.StatusInternalServerError, "failed to create event")
 		return
 	}
 
+	// Events handed to the ingest queue or spool are stored later
+	if status == models.BatchStatusQueued || status == models.BatchStatusSpooled {
+		h.respondJSON(w, http.StatusAccepted, event)
+		return
+	}
 	h.respondJSON(w, http.StatusCreated, event)
 }
 
+func (h *Handler) CreateEvents(w http.ResponseWriter, r *http.Request) {
+	if isNDJSON(r) {
+		h.createEventStream(w, r)
+		return
+	}
+
+	var reqs []models.CreateEventRequest
+	capture := &rawCapture{}
+	if err := json.NewDecoder(io.TeeReader(r.Body, capture)).Decode(&reqs); err != nil {
+		if bodyTooLarge(err) {
+			h.respondError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		log.Printf("CreateEvents: invalid request body: %v", err)
+		h.quarantineBody(r, capture, err)
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	resp, err := h.eventUC.CreateEvents(r.Context(), reqs)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrEmptyBatch):
+			h.respondError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, usecase.ErrBatchTooLarge):
+			h.respondError(w, http.StatusRequestEntityTooLarge, err.Error())
+		case errors.Is(err, usecase.ErrQueueFull):
+			h.respondBusy(w)
+		default:
+			log.Printf("CreateEvents: failed to create events: %v", err)
+			h.respondError(w, http.StatusInternalServerError, "failed to create events")
+		}
+		return
+	}
+
+	h.respondJSON(w, batchStatus(resp), resp)
+}
+
 func (h *Handler) GetEvent(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -127,14 +245,71 @@ func (h *Handler) ListEvents(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	events, err := h.eventUC.ListEvents(r.Context(), filter)
+	payload, err := payloadFilters(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.Payload = payload
+
+	if cursorStr := r.URL.Query().Get("cursor"); cursorStr != "" {
+		if filter.Offset > 0 {
+			h.respondError(w, http.StatusBadRequest, "cursor and offset cannot be combined")
+			return
+		}
+		// Events are only listed newest first, so ascending cursors of
+		// user timelines are rejected like malformed ones
+		cursor, err := models.ParseEventCursor(cursorStr)
+		if err != nil || cursor.Ascending {
+			h.respondError(w, http.StatusBadRequest, models.ErrInvalidCursor.Error())
+			return
+		}
+		filter.Cursor = cursor
+	}
+
+	page, err := h.eventUC.ListEvents(r.Context(), filter)
 	if err != nil {
 		log.Printf("ListEvents: failed to list events: %v", err)
 		h.respondError(w, http.StatusInternalServerError, "failed to list events")
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, events)
+	h.respondPage(w, page)
+}
+
+// payloadFilters parses payload.<path>=<value> and payload.<path>[<op>]=<value>
+// query parameters, e.g. payload.device.arch=amd64 or payload.version[gte]=23.
+func payloadFilters(r *http.Request) ([]models.PayloadFilter, error) {
+	keys := make([]string, 0)
+	for key := range r.URL.Query() {
+		if strings.HasPrefix(key, "payload.") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var filters []models.PayloadFilter
+	for _, key := range keys {
+		field, op := strings.TrimPrefix(key, "payload."), ""
+		if strings.HasSuffix(field, "]") {
+			if i := strings.LastIndex(field, "["); i >= 0 {
+				field, op = field[:i], field[i+1:len(field)-1]
+			}
+		}
+
+		for _, value := range r.URL.Query()[key] {
+			filter, err := models.ParsePayloadFilter(field, op, value)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, filter)
+		}
+	}
+
+	if len(filters) > models.MaxPayloadFilters {
+		return nil, fmt.Errorf("%w: at most %d payload filters are allowed", models.ErrInvalidPayloadFilter, models.MaxPayloadFilters)
+	}
+	return filters, nil
 }
 
 func (h *Handler) GetHourlyStats(w http.ResponseWriter, r *http.Request) {
@@ -152,7 +327,13 @@ func (h *Handler) GetHourlyStats(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	stats, err := h.analyticsUC.GetHourlyStats(r.Context(), eventName, from, to)
+	compare, err := models.ParseCompare(r.URL.Query().Get("compare"))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stats, err := h.analyticsUC.GetHourlyStats(r.Context(), eventName, from, to, compare)
 	if err != nil {
 		log.Printf("GetHourlyStats: failed: %v", err)
 		h.respondError(w, http.StatusInternalServerError, "failed to get hourly stats")
@@ -177,7 +358,19 @@ func (h *Handler) GetDailyStats(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	stats, err := h.analyticsUC.GetDailyStats(r.Context(), eventName, from, to)
+	loc, err := queryTimezone(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	compare, err := models.ParseCompare(r.URL.Query().Get("compare"))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stats, err := h.analyticsUC.GetDailyStats(r.Context(), eventName, from, to, loc, compare)
 	if err != nil {
 		log.Printf("GetDailyStats: failed: %v", err)
 		h.respondError(w, http.StatusInternalServerError, "failed to get daily stats")
@@ -186,3 +379,7 @@ func (h *Handler) GetDailyStats(w http.ResponseWriter, r *http.Request) {
 
 	h.respondJSON(w, http.StatusOK, stats)
 }
+
+func (h *Handler) GetIngestStats(w http.ResponseWriter, r *http.Request) {
+	h.respondJSON(w, http.StatusOK, h.eventUC.IngestStats(r.Context()))
+}
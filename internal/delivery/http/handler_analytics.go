@@ -0,0 +1,381 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/usecase"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+// GetBreakdown groups an event's counts by a payload field, e.g.
+// ?event_name=app_launch&by=payload.version&interval=1d&top=10.
+func (h *Handler) GetBreakdown(w http.ResponseWriter, r *http.Request) {
+	q := models.BreakdownQuery{EventName: r.URL.Query().Get("event_name")}
+
+	var err error
+	if q.From, q.To, err = timeRange(r); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	by := r.URL.Query().Get("by")
+	if !strings.HasPrefix(by, "payload.") {
+		h.respondError(w, http.StatusBadRequest, "by must name a payload field, e.g. payload.version")
+		return
+	}
+	if q.Path, err = models.ParsePayloadPath(strings.TrimPrefix(by, "payload.")); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if q.Interval, err = queryInterval(r); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if q.Location, err = queryTimezone(r); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if topStr := r.URL.Query().Get("top"); topStr != "" {
+		if q.Top, err = strconv.Atoi(topStr); err != nil || q.Top <= 0 {
+			h.respondError(w, http.StatusBadRequest, "invalid top")
+			return
+		}
+	}
+
+	breakdown, err := h.analyticsUC.GetBreakdown(r.Context(), q)
+	if err != nil {
+		h.respondAnalyticsError(w, "GetBreakdown", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, breakdown)
+}
+
+// GetTimeseries returns a zero-filled series of event counts, e.g.
+// ?event_name=app_launch&interval=15m&compare=previous_period.
+func (h *Handler) GetTimeseries(w http.ResponseWriter, r *http.Request) {
+	q := models.TimeseriesQuery{EventName: r.URL.Query().Get("event_name")}
+
+	var err error
+	if q.From, q.To, err = timeRange(r); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if q.Interval, err = queryInterval(r); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if q.Location, err = queryTimezone(r); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if q.Compare, err = models.ParseCompare(r.URL.Query().Get("compare")); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	series, err := h.analyticsUC.GetTimeseries(r.Context(), q)
+	if err != nil {
+		h.respondAnalyticsError(w, "GetTimeseries", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, series)
+}
+
+// GetActiveUsers returns rolling DAU, WAU, MAU and stickiness per day of
+// ?from=&to=.
+func (h *Handler) GetActiveUsers(w http.ResponseWriter, r *http.Request) {
+	from, to, err := timeRange(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	active, err := h.analyticsUC.GetActiveUsers(r.Context(), from, to)
+	if err != nil {
+		h.respondAnalyticsError(w, "GetActiveUsers", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, active)
+}
+
+// GetUniqueUsers estimates the distinct users over ?from=&to=, optionally
+// of one ?event_name=.
+func (h *Handler) GetUniqueUsers(w http.ResponseWriter, r *http.Request) {
+	from, to, err := timeRange(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	users, err := h.analyticsUC.GetUniqueUsers(r.Context(), r.URL.Query().Get("event_name"), from, to)
+	if err != nil {
+		h.respondAnalyticsError(w, "GetUniqueUsers", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, users)
+}
+
+// GetRetention returns a cohort retention matrix, e.g.
+// ?start_event=install,first_boot&return_event=app_launch&granularity=1w&periods=8.
+func (h *Handler) GetRetention(w http.ResponseWriter, r *http.Request) {
+	q := models.RetentionQuery{ReturnEvent: r.URL.Query().Get("return_event")}
+
+	var err error
+	if q.From, q.To, err = timeRange(r); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if start := r.URL.Query().Get("start_event"); start != "" {
+		for _, name := range strings.Split(start, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				q.StartEvents = append(q.StartEvents, name)
+			}
+		}
+	}
+
+	if granularity := r.URL.Query().Get("granularity"); granularity != "" {
+		if q.Granularity, err = models.ParseInterval(granularity); err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid granularity")
+			return
+		}
+	}
+
+	if periodsStr := r.URL.Query().Get("periods"); periodsStr != "" {
+		if q.Periods, err = strconv.Atoi(periodsStr); err != nil || q.Periods <= 0 {
+			h.respondError(w, http.StatusBadRequest, "invalid periods")
+			return
+		}
+	}
+
+	retention, err := h.analyticsUC.GetRetention(r.Context(), q)
+	if err != nil {
+		h.respondAnalyticsError(w, "GetRetention", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, retention)
+}
+
+// GetFunnel measures how installs progress through ?steps=a,b,c within
+// ?window=, optionally split ?by=payload.field.
+func (h *Handler) GetFunnel(w http.ResponseWriter, r *http.Request) {
+	var q models.FunnelQuery
+
+	var err error
+	if q.From, q.To, err = timeRange(r); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if steps := r.URL.Query().Get("steps"); steps != "" {
+		for _, name := range strings.Split(steps, ",") {
+			q.Steps = append(q.Steps, strings.TrimSpace(name))
+		}
+	}
+
+	if window := r.URL.Query().Get("window"); window != "" {
+		if q.Window, err = models.ParseInterval(window); err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid window")
+			return
+		}
+	}
+
+	if by := r.URL.Query().Get("by"); by != "" {
+		if !strings.HasPrefix(by, "payload.") {
+			h.respondError(w, http.StatusBadRequest, "by must name a payload field, e.g. payload.edition")
+			return
+		}
+		if q.By, err = models.ParsePayloadPath(strings.TrimPrefix(by, "payload.")); err != nil {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if topStr := r.URL.Query().Get("top"); topStr != "" {
+		if q.Top, err = strconv.Atoi(topStr); err != nil || q.Top <= 0 {
+			h.respondError(w, http.StatusBadRequest, "invalid top")
+			return
+		}
+	}
+
+	funnel, err := h.analyticsUC.GetFunnel(r.Context(), q)
+	if err != nil {
+		h.respondAnalyticsError(w, "GetFunnel", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, funnel)
+}
+
+// GetMetric aggregates a numeric payload field per bucket, e.g.
+// ?event_name=boot_time&field=payload.duration_ms&agg=p50,p90,avg, or with
+// ?mode=histogram&bins=20 distributes it over bins.
+func (h *Handler) GetMetric(w http.ResponseWriter, r *http.Request) {
+	eventName := r.URL.Query().Get("event_name")
+
+	from, to, err := timeRange(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	field := r.URL.Query().Get("field")
+	if !strings.HasPrefix(field, "payload.") {
+		h.respondError(w, http.StatusBadRequest, "field must name a payload field, e.g. payload.duration_ms")
+		return
+	}
+	path, err := models.ParsePayloadPath(strings.TrimPrefix(field, "payload."))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	switch r.URL.Query().Get("mode") {
+	case "", "series":
+	case "histogram":
+		h.getHistogram(w, r, models.HistogramQuery{EventName: eventName, Path: path, From: from, To: to})
+		return
+	default:
+		h.respondError(w, http.StatusBadRequest, "mode must be series or histogram")
+		return
+	}
+
+	q := models.MetricQuery{EventName: eventName, Path: path, From: from, To: to}
+	if aggs := r.URL.Query().Get("agg"); aggs != "" {
+		if q.Aggs, err = models.ParseMetricAggs(aggs); err != nil {
+			h.respondError(w, http.StatusBadRequest, "agg must list p1 to p99, count, avg, min, max or sum")
+			return
+		}
+	}
+	if q.Interval, err = queryInterval(r); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if q.Location, err = queryTimezone(r); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	metric, err := h.analyticsUC.GetMetric(r.Context(), q)
+	if err != nil {
+		h.respondAnalyticsError(w, "GetMetric", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, metric)
+}
+
+func (h *Handler) getHistogram(w http.ResponseWriter, r *http.Request, q models.HistogramQuery) {
+	var err error
+	if binsStr := r.URL.Query().Get("bins"); binsStr != "" {
+		if q.Bins, err = strconv.Atoi(binsStr); err != nil || q.Bins <= 0 {
+			h.respondError(w, http.StatusBadRequest, "invalid bins")
+			return
+		}
+	}
+	if minStr := r.URL.Query().Get("min"); minStr != "" {
+		lo, err := strconv.ParseFloat(minStr, 64)
+		if err != nil || math.IsNaN(lo) || math.IsInf(lo, 0) {
+			h.respondError(w, http.StatusBadRequest, "invalid min")
+			return
+		}
+		q.Min = &lo
+	}
+	if maxStr := r.URL.Query().Get("max"); maxStr != "" {
+		hi, err := strconv.ParseFloat(maxStr, 64)
+		if err != nil || math.IsNaN(hi) || math.IsInf(hi, 0) {
+			h.respondError(w, http.StatusBadRequest, "invalid max")
+			return
+		}
+		q.Max = &hi
+	}
+
+	hist, err := h.analyticsUC.GetHistogram(r.Context(), q)
+	if err != nil {
+		h.respondAnalyticsError(w, "GetHistogram", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, hist)
+}
+
+// GetSessionStats summarises usage sessions per day, e.g. ?gap=30m.
+func (h *Handler) GetSessionStats(w http.ResponseWriter, r *http.Request) {
+	q, err := sessionQuery(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if q.Location, err = queryTimezone(r); err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stats, err := h.analyticsUC.GetSessionStats(r.Context(), q)
+	if err != nil {
+		h.respondAnalyticsError(w, "GetSessionStats", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, stats)
+}
+
+// sessionQuery parses the range and the optional inactivity gap.
+func sessionQuery(r *http.Request) (models.SessionQuery, error) {
+	var q models.SessionQuery
+
+	var err error
+	if q.From, q.To, err = timeRange(r); err != nil {
+		return q, err
+	}
+	if gap := r.URL.Query().Get("gap"); gap != "" {
+		if q.Gap, err = models.ParseInterval(gap); err != nil {
+			return q, fmt.Errorf("invalid gap: %q", gap)
+		}
+	}
+	return q, nil
+}
+
+// queryInterval parses the optional interval parameter; the zero Interval
+// leaves the choice to the usecase.
+func queryInterval(r *http.Request) (models.Interval, error) {
+	intervalStr := r.URL.Query().Get("interval")
+	if intervalStr == "" {
+		return models.Interval{}, nil
+	}
+	return models.ParseInterval(intervalStr)
+}
+
+// queryTimezone parses the optional IANA tz parameter; nil means UTC.
+func queryTimezone(r *http.Request) (*time.Location, error) {
+	tz := r.URL.Query().Get("tz")
+	loc, err := models.ParseTimezone(tz)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", err, tz)
+	}
+	return loc, nil
+}
+
+func (h *Handler) respondAnalyticsError(w http.ResponseWriter, op string, err error) {
+	if errors.Is(err, usecase.ErrInvalidAnalyticsQuery) {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	log.Printf("%s: failed: %v", op, err)
+	h.respondError(w, http.StatusInternalServerError, "failed to run analytics query")
+}
@@ -0,0 +1,385 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/repo"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAnalyticsUsecase is a mock implementation of AnalyticsUsecase
+type MockAnalyticsUsecase struct {
+	mock.Mock
+}
+
+func (m *MockAnalyticsUsecase) GetHourlyStats(ctx context.Context, eventName string, from, to time.Time, compare string) ([]repo.EventStats, error) {
+	args := m.Called(ctx, eventName, from, to, compare)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repo.EventStats), args.Error(1)
+}
+
+func (m *MockAnalyticsUsecase) GetDailyStats(ctx context.Context, eventName string, from, to time.Time, loc *time.Location, compare string) ([]repo.EventStats, error) {
+	args := m.Called(ctx, eventName, from, to, loc, compare)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repo.EventStats), args.Error(1)
+}
+
+func (m *MockAnalyticsUsecase) ListEventNames(ctx context.Context, from, to time.Time) ([]models.EventNameSummary, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.EventNameSummary), args.Error(1)
+}
+
+func (m *MockAnalyticsUsecase) DescribePayload(ctx context.Context, eventName string, from, to time.Time, sample int) (*models.PayloadDescription, error) {
+	args := m.Called(ctx, eventName, from, to, sample)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PayloadDescription), args.Error(1)
+}
+
+func (m *MockAnalyticsUsecase) GetBreakdown(ctx context.Context, q models.BreakdownQuery) (*models.BreakdownResponse, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.BreakdownResponse), args.Error(1)
+}
+
+func (m *MockAnalyticsUsecase) GetTimeseries(ctx context.Context, q models.TimeseriesQuery) (*models.TimeseriesResponse, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TimeseriesResponse), args.Error(1)
+}
+
+func (m *MockAnalyticsUsecase) GetActiveUsers(ctx context.Context, from, to time.Time) (*models.ActiveUsersResponse, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ActiveUsersResponse), args.Error(1)
+}
+
+func (m *MockAnalyticsUsecase) GetUniqueUsers(ctx context.Context, eventName string, from, to time.Time) (*models.UniqueUsersResponse, error) {
+	args := m.Called(ctx, eventName, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UniqueUsersResponse), args.Error(1)
+}
+
+func (m *MockAnalyticsUsecase) GetRetention(ctx context.Context, q models.RetentionQuery) (*models.RetentionResponse, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RetentionResponse), args.Error(1)
+}
+
+func (m *MockAnalyticsUsecase) GetFunnel(ctx context.Context, q models.FunnelQuery) (*models.FunnelResponse, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.FunnelResponse), args.Error(1)
+}
+
+func (m *MockAnalyticsUsecase) GetMetric(ctx context.Context, q models.MetricQuery) (*models.MetricResponse, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MetricResponse), args.Error(1)
+}
+
+func (m *MockAnalyticsUsecase) GetHistogram(ctx context.Context, q models.HistogramQuery) (*models.HistogramResponse, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.HistogramResponse), args.Error(1)
+}
+
+func (m *MockAnalyticsUsecase) GetSessionStats(ctx context.Context, q models.SessionQuery) (*models.SessionStatsResponse, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SessionStatsResponse), args.Error(1)
+}
+
+func TestGetHourlyStats(t *testing.T) {
+	analyticsUC := new(MockAnalyticsUsecase)
+	h := NewHandler(nil, analyticsUC, nil, nil, nil, nil)
+
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	analyticsUC.On("GetHourlyStats", mock.Anything, "app_launch", from, time.Time{}, "").
+		Return([]repo.EventStats{{Bucket: from, EventName: "app_launch", EventCount: 3}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/analytics/hourly?event_name=app_launch&from=2026-03-01T00:00:00Z", nil)
+	rec := httptest.NewRecorder()
+
+	h.GetHourlyStats(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"event_count":3`)
+	analyticsUC.AssertExpectations(t)
+}
+
+func TestGetHourlyStats_Compare(t *testing.T) {
+	analyticsUC := new(MockAnalyticsUsecase)
+	h := NewHandler(nil, analyticsUC, nil, nil, nil, nil)
+
+	bucket := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	analyticsUC.On("GetHourlyStats", mock.Anything, "", time.Time{}, time.Time{}, models.ComparePreviousPeriod).
+		Return([]repo.EventStats{{Bucket: bucket, EventCount: 3, Compare: models.CompareCounts(bucket.Add(-24*time.Hour), 3, 1, 2, 1)}}, nil)
+
+	rec := httptest.NewRecorder()
+	h.GetHourlyStats(rec, httptest.NewRequest(http.MethodGet, "/analytics/hourly?compare=previous_period", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"event_count_delta_pct":50`)
+	analyticsUC.AssertExpectations(t)
+
+	rec = httptest.NewRecorder()
+	h.GetHourlyStats(rec, httptest.NewRequest(http.MethodGet, "/analytics/hourly?compare=last_week", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.GetTimeseries(rec, httptest.NewRequest(http.MethodGet, "/analytics/timeseries?compare=yesterday", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetDailyStats_Timezone(t *testing.T) {
+	analyticsUC := new(MockAnalyticsUsecase)
+	h := NewHandler(nil, analyticsUC, nil, nil, nil, nil)
+
+	inJakarta := mock.MatchedBy(func(loc *time.Location) bool { return loc.String() == models.JakartaTimezone })
+	analyticsUC.On("GetDailyStats", mock.Anything, "", time.Time{}, time.Time{}, inJakarta, "").Return([]repo.EventStats{}, nil)
+
+	rec := httptest.NewRecorder()
+	h.GetDailyStats(rec, httptest.NewRequest(http.MethodGet, "/analytics/daily?tz=Asia/Jakarta", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	analyticsUC.AssertExpectations(t)
+
+	rec = httptest.NewRecorder()
+	h.GetDailyStats(rec, httptest.NewRequest(http.MethodGet, "/analytics/daily?tz=WIB", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetBreakdown(t *testing.T) {
+	analyticsUC := new(MockAnalyticsUsecase)
+	h := NewHandler(nil, analyticsUC, nil, nil, nil, nil)
+
+	analyticsUC.On("GetBreakdown", mock.Anything, models.BreakdownQuery{
+		EventName: "app_launch",
+		Path:      []string{"desktop", "name"},
+		Interval:  models.Interval{Count: 6, Unit: "h"},
+		Top:       5,
+	}).Return(&models.BreakdownResponse{Rows: []models.BreakdownRow{{Other: true, EventCount: 2}}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/analytics/breakdown?event_name=app_launch&by=payload.desktop.name&interval=6h&top=5", nil)
+	rec := httptest.NewRecorder()
+
+	h.GetBreakdown(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"other":true`)
+	analyticsUC.AssertExpectations(t)
+}
+
+func TestGetBreakdown_InvalidParams(t *testing.T) {
+	h := NewHandler(nil, new(MockAnalyticsUsecase), nil, nil, nil, nil)
+
+	for _, query := range []string{
+		"event_name=a&by=version",
+		"event_name=a&by=payload.a..b",
+		"event_name=a&by=payload.version&interval=1y",
+		"event_name=a&by=payload.version&top=0",
+	} {
+		rec := httptest.NewRecorder()
+		h.GetBreakdown(rec, httptest.NewRequest(http.MethodGet, "/analytics/breakdown?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestGetTimeseries(t *testing.T) {
+	analyticsUC := new(MockAnalyticsUsecase)
+	h := NewHandler(nil, analyticsUC, nil, nil, nil, nil)
+
+	analyticsUC.On("GetTimeseries", mock.Anything, models.TimeseriesQuery{
+		EventName: "app_launch",
+		Interval:  models.Interval{Count: 1, Unit: "w"},
+	}).Return(&models.TimeseriesResponse{Source: models.SourceDaily, Points: []models.TimeseriesPoint{{EventCount: 0}}}, nil)
+
+	rec := httptest.NewRecorder()
+	h.GetTimeseries(rec, httptest.NewRequest(http.MethodGet, "/analytics/timeseries?event_name=app_launch&interval=1w", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"source":"events_daily"`)
+	analyticsUC.AssertExpectations(t)
+}
+
+func TestGetActiveUsers(t *testing.T) {
+	analyticsUC := new(MockAnalyticsUsecase)
+	h := NewHandler(nil, analyticsUC, nil, nil, nil, nil)
+
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	analyticsUC.On("GetActiveUsers", mock.Anything, from, time.Time{}).
+		Return(&models.ActiveUsersResponse{Days: []models.ActiveUsers{{Day: from, DAU: 2, MAU: 4, DAUOverMAU: 0.5}}}, nil)
+
+	rec := httptest.NewRecorder()
+	h.GetActiveUsers(rec, httptest.NewRequest(http.MethodGet, "/analytics/active-users?from=2026-03-01T00:00:00Z", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"dau_mau":0.5`)
+	analyticsUC.AssertExpectations(t)
+}
+
+func TestGetUniqueUsers(t *testing.T) {
+	analyticsUC := new(MockAnalyticsUsecase)
+	h := NewHandler(nil, analyticsUC, nil, nil, nil, nil)
+
+	analyticsUC.On("GetUniqueUsers", mock.Anything, "app_launch", time.Time{}, time.Time{}).
+		Return(&models.UniqueUsersResponse{UniqueUsers: 1200, StdError: models.HLLStdError}, nil)
+
+	rec := httptest.NewRecorder()
+	h.GetUniqueUsers(rec, httptest.NewRequest(http.MethodGet, "/analytics/unique-users?event_name=app_launch", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"unique_users":1200`)
+	analyticsUC.AssertExpectations(t)
+}
+
+func TestGetRetention(t *testing.T) {
+	analyticsUC := new(MockAnalyticsUsecase)
+	h := NewHandler(nil, analyticsUC, nil, nil, nil, nil)
+
+	analyticsUC.On("GetRetention", mock.Anything, models.RetentionQuery{
+		StartEvents: []string{"install", "first_boot"},
+		ReturnEvent: "app_launch",
+		Granularity: models.Interval{Count: 1, Unit: "mo"},
+		Periods:     6,
+	}).Return(&models.RetentionResponse{Cohorts: []models.RetentionCohort{}}, nil)
+
+	rec := httptest.NewRecorder()
+	h.GetRetention(rec, httptest.NewRequest(http.MethodGet, "/analytics/retention?start_event=install,first_boot&return_event=app_launch&granularity=1mo&periods=6", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	analyticsUC.AssertExpectations(t)
+
+	for _, query := range []string{"granularity=week", "periods=0"} {
+		rec := httptest.NewRecorder()
+		h.GetRetention(rec, httptest.NewRequest(http.MethodGet, "/analytics/retention?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestGetFunnel(t *testing.T) {
+	analyticsUC := new(MockAnalyticsUsecase)
+	h := NewHandler(nil, analyticsUC, nil, nil, nil, nil)
+
+	analyticsUC.On("GetFunnel", mock.Anything, models.FunnelQuery{
+		Steps:  []string{"installer_start", "partition_done", "install_complete", "first_boot"},
+		Window: models.Interval{Count: 2, Unit: "h"},
+		By:     []string{"edition"},
+	}).Return(&models.FunnelResponse{Groups: []models.FunnelGroup{}}, nil)
+
+	rec := httptest.NewRecorder()
+	h.GetFunnel(rec, httptest.NewRequest(http.MethodGet, "/analytics/funnel?steps=installer_start,partition_done,install_complete,first_boot&window=2h&by=payload.edition", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	analyticsUC.AssertExpectations(t)
+
+	for _, query := range []string{"steps=a,b&window=2y", "steps=a,b&by=edition", "steps=a,b&top=-1"} {
+		rec := httptest.NewRecorder()
+		h.GetFunnel(rec, httptest.NewRequest(http.MethodGet, "/analytics/funnel?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestGetMetric(t *testing.T) {
+	analyticsUC := new(MockAnalyticsUsecase)
+	h := NewHandler(nil, analyticsUC, nil, nil, nil, nil)
+
+	p90 := 2400.0
+	analyticsUC.On("GetMetric", mock.Anything, models.MetricQuery{
+		EventName: "boot_time",
+		Path:      []string{"duration_ms"},
+		Aggs:      []string{"p90", "max"},
+		Interval:  models.Interval{Count: 1, Unit: "d"},
+	}).Return(&models.MetricResponse{Points: []models.MetricPoint{{Count: 3, Values: map[string]*float64{"p90": &p90, "max": nil}}}}, nil)
+
+	rec := httptest.NewRecorder()
+	h.GetMetric(rec, httptest.NewRequest(http.MethodGet, "/analytics/metric?event_name=boot_time&field=payload.duration_ms&agg=p90,max&interval=1d", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"p90":2400`)
+	analyticsUC.AssertExpectations(t)
+}
+
+func TestGetMetric_Histogram(t *testing.T) {
+	analyticsUC := new(MockAnalyticsUsecase)
+	h := NewHandler(nil, analyticsUC, nil, nil, nil, nil)
+
+	lo := 0.0
+	analyticsUC.On("GetHistogram", mock.Anything, models.HistogramQuery{
+		EventName: "boot_time",
+		Path:      []string{"duration_ms"},
+		Bins:      20,
+		Min:       &lo,
+	}).Return(&models.HistogramResponse{Bins: []models.HistogramBin{{Lower: 0, Upper: 500, Count: 7}}}, nil)
+
+	rec := httptest.NewRecorder()
+	h.GetMetric(rec, httptest.NewRequest(http.MethodGet, "/analytics/metric?event_name=boot_time&field=payload.duration_ms&mode=histogram&bins=20&min=0", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"count":7`)
+	analyticsUC.AssertExpectations(t)
+
+	for _, query := range []string{
+		"field=duration_ms",
+		"field=payload.duration_ms&agg=median",
+		"field=payload.duration_ms&mode=pie",
+		"field=payload.duration_ms&mode=histogram&bins=x",
+		"field=payload.duration_ms&mode=histogram&max=y",
+		"field=payload.duration_ms&mode=histogram&min=NaN",
+		"field=payload.duration_ms&mode=histogram&max=Inf",
+	} {
+		rec := httptest.NewRecorder()
+		h.GetMetric(rec, httptest.NewRequest(http.MethodGet, "/analytics/metric?event_name=boot_time&"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestGetSessionStats(t *testing.T) {
+	analyticsUC := new(MockAnalyticsUsecase)
+	h := NewHandler(nil, analyticsUC, nil, nil, nil, nil)
+
+	analyticsUC.On("GetSessionStats", mock.Anything, models.SessionQuery{Gap: models.Interval{Count: 1, Unit: "h"}}).
+		Return(&models.SessionStatsResponse{Days: []models.SessionStats{{Sessions: 12, AvgEvents: 4.5}}}, nil)
+
+	rec := httptest.NewRecorder()
+	h.GetSessionStats(rec, httptest.NewRequest(http.MethodGet, "/analytics/sessions?gap=1h", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"avg_events":4.5`)
+	analyticsUC.AssertExpectations(t)
+
+	rec = httptest.NewRecorder()
+	h.GetSessionStats(rec, httptest.NewRequest(http.MethodGet, "/analytics/sessions?gap=soon", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/herpiko/blankon-telemetry-backend/internal/usecase"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("CreateAPIKey: invalid request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	key, err := h.authUC.CreateAPIKey(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidAPIKey) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("CreateAPIKey: failed to create key: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "failed to create API key")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, key)
+}
+
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.authUC.ListAPIKeys(r.Context())
+	if err != nil {
+		log.Printf("ListAPIKeys: failed to list keys: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "failed to list API keys")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, keys)
+}
+
+// RotateAPIKey replaces a key. With ?grace=<duration> the old key keeps
+// working for that long.
+func (h *Handler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.apiKeyID(w, r)
+	if !ok {
+		return
+	}
+
+	var grace time.Duration
+	if graceStr := r.URL.Query().Get("grace"); graceStr != "" {
+		parsed, err := time.ParseDuration(graceStr)
+		if err != nil || parsed < 0 {
+			h.respondError(w, http.StatusBadRequest, "invalid grace period")
+			return
+		}
+		grace = parsed
+	}
+
+	key, err := h.authUC.RotateAPIKey(r.Context(), id, grace)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrAPIKeyNotFound):
+			h.respondError(w, http.StatusNotFound, "API key not found")
+		case errors.Is(err, usecase.ErrAPIKeyRetired):
+			h.respondError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, usecase.ErrInvalidAPIKey):
+			h.respondError(w, http.StatusBadRequest, err.Error())
+		default:
+			log.Printf("RotateAPIKey: failed to rotate key %d: %v", id, err)
+			h.respondError(w, http.StatusInternalServerError, "failed to rotate API key")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, key)
+}
+
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.apiKeyID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.authUC.RevokeAPIKey(r.Context(), id); err != nil {
+		if errors.Is(err, usecase.ErrAPIKeyNotFound) {
+			h.respondError(w, http.StatusNotFound, "API key not found")
+			return
+		}
+		log.Printf("RevokeAPIKey: failed to revoke key %d: %v", id, err)
+		h.respondError(w, http.StatusInternalServerError, "failed to revoke API key")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) apiKeyID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		log.Printf("apiKeyID: invalid API key id %q: %v", idStr, err)
+		h.respondError(w, http.StatusBadRequest, "invalid API key id")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,193 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/usecase"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAuthUsecase is a mock implementation of AuthUsecase
+type MockAuthUsecase struct {
+	mock.Mock
+}
+
+func (m *MockAuthUsecase) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAuthUsecase) CreateAPIKey(ctx context.Context, req models.CreateAPIKeyRequest) (*models.CreatedAPIKey, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CreatedAPIKey), args.Error(1)
+}
+
+func (m *MockAuthUsecase) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockAuthUsecase) RotateAPIKey(ctx context.Context, id int64, grace time.Duration) (*models.CreatedAPIKey, error) {
+	args := m.Called(ctx, id, grace)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CreatedAPIKey), args.Error(1)
+}
+
+func (m *MockAuthUsecase) RevokeAPIKey(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestRequireScope(t *testing.T) {
+	authUC := new(MockAuthUsecase)
+	h := NewHandler(nil, nil, nil, nil, authUC, nil)
+
+	ingestKey := &models.APIKey{ID: 1, Scopes: []string{models.ScopeIngest}}
+	authUC.On("Authenticate", mock.Anything, "btk_ingest").Return(ingestKey, nil)
+	authUC.On("Authenticate", mock.Anything, "btk_wrong").Return(nil, usecase.ErrUnauthorized)
+	authUC.On("Authenticate", mock.Anything, "").Return(nil, usecase.ErrUnauthorized)
+
+	var seen *models.APIKey
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = apiKeyFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		scope  string
+		status int
+	}{
+		{"bearer token", "Authorization", "Bearer btk_ingest", models.ScopeIngest, http.StatusNoContent},
+		{"api key header", "X-API-Key", "btk_ingest", models.ScopeIngest, http.StatusNoContent},
+		{"missing scope", "Authorization", "Bearer btk_ingest", models.ScopeRead, http.StatusForbidden},
+		{"unknown key", "Authorization", "Bearer btk_wrong", models.ScopeIngest, http.StatusUnauthorized},
+		{"other auth scheme", "Authorization", "Basic btk_ingest", models.ScopeIngest, http.StatusUnauthorized},
+		{"no key", "", "", models.ScopeIngest, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = nil
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+
+			h.RequireScope(tt.scope)(next).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.status == http.StatusNoContent {
+				assert.Equal(t, ingestKey, seen)
+			}
+			if tt.status == http.StatusUnauthorized {
+				assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestRouter_RequiresAPIKeys(t *testing.T) {
+	authUC := new(MockAuthUsecase)
+	router := NewRouter(NewHandler(new(MockEventUsecase), nil, nil, nil, authUC, nil), RouterConfig{})
+
+	authUC.On("Authenticate", mock.Anything, "btk_ingest").Return(&models.APIKey{Scopes: []string{models.ScopeIngest}}, nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Authorization", "Bearer btk_ingest")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/keys", nil)
+	req.Header.Set("Authorization", "Bearer btk_ingest")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	authUC := new(MockAuthUsecase)
+	h := NewHandler(nil, nil, nil, nil, authUC, nil)
+
+	authUC.On("CreateAPIKey", mock.Anything, models.CreateAPIKeyRequest{Name: "desktop", Scopes: []string{"ingest"}}).
+		Return(&models.CreatedAPIKey{APIKey: models.APIKey{ID: 3, Prefix: "abc"}, Key: "btk_abc_secret"}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/keys", bytes.NewReader([]byte(`{"name":"desktop","scopes":["ingest"]}`)))
+	rec := httptest.NewRecorder()
+
+	h.CreateAPIKey(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"key":"btk_abc_secret"`)
+	authUC.AssertExpectations(t)
+}
+
+func TestRotateAPIKey_GracePeriod(t *testing.T) {
+	authUC := new(MockAuthUsecase)
+	h := NewHandler(nil, nil, nil, nil, authUC, nil)
+
+	authUC.On("RotateAPIKey", mock.Anything, int64(3), 2*time.Hour).
+		Return(&models.CreatedAPIKey{APIKey: models.APIKey{ID: 4}, Key: "btk_new_secret"}, nil)
+
+	req := withURLParams(httptest.NewRequest(http.MethodPost, "/admin/keys/3/rotate?grace=2h", nil), map[string]string{"id": "3"})
+	rec := httptest.NewRecorder()
+
+	h.RotateAPIKey(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	authUC.AssertExpectations(t)
+}
+
+func TestRotateAPIKey_Retired(t *testing.T) {
+	authUC := new(MockAuthUsecase)
+	h := NewHandler(nil, nil, nil, nil, authUC, nil)
+
+	authUC.On("RotateAPIKey", mock.Anything, int64(3), time.Duration(0)).Return(nil, usecase.ErrAPIKeyRetired)
+
+	req := withURLParams(httptest.NewRequest(http.MethodPost, "/admin/keys/3/rotate", nil), map[string]string{"id": "3"})
+	rec := httptest.NewRecorder()
+
+	h.RotateAPIKey(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestRevokeAPIKey_NotFound(t *testing.T) {
+	authUC := new(MockAuthUsecase)
+	h := NewHandler(nil, nil, nil, nil, authUC, nil)
+
+	authUC.On("RevokeAPIKey", mock.Anything, int64(8)).Return(usecase.ErrAPIKeyNotFound)
+
+	req := withURLParams(httptest.NewRequest(http.MethodDelete, "/admin/keys/8", nil), map[string]string{"id": "8"})
+	rec := httptest.NewRecorder()
+
+	h.RevokeAPIKey(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
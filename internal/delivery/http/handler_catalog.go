@@ -0,0 +1,82 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/herpiko/blankon-telemetry-backend/internal/usecase"
+)
+
+func (h *Handler) ListEventNames(w http.ResponseWriter, r *http.Request) {
+	from, to, err := timeRange(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	names, err := h.analyticsUC.ListEventNames(r.Context(), from, to)
+	if err != nil {
+		log.Printf("ListEventNames: failed: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "failed to list event names")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, names)
+}
+
+// DescribePayload reports the payload keys observed in the latest
+// ?sample=N events of an event name.
+func (h *Handler) DescribePayload(w http.ResponseWriter, r *http.Request) {
+	from, to, err := timeRange(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var sample int
+	if sampleStr := r.URL.Query().Get("sample"); sampleStr != "" {
+		sample, err = strconv.Atoi(sampleStr)
+		if err != nil || sample <= 0 {
+			h.respondError(w, http.StatusBadRequest, "invalid sample size")
+			return
+		}
+	}
+
+	desc, err := h.analyticsUC.DescribePayload(r.Context(), chi.URLParam(r, "event_name"), from, to, sample)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidAnalyticsQuery) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("DescribePayload: failed: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "failed to describe payload")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, desc)
+}
+
+// timeRange parses the optional RFC 3339 from and to parameters. Unlike the
+// hourly and daily stats it rejects malformed values instead of ignoring
+// them.
+func timeRange(r *http.Request) (from, to time.Time, err error) {
+	if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+		if from, err = time.Parse(time.RFC3339, fromStr); err != nil {
+			return from, to, fmt.Errorf("invalid from: %q", fromStr)
+		}
+	}
+	if toStr := r.URL.Query().Get("to"); toStr != "" {
+		if to, err = time.Parse(time.RFC3339, toStr); err != nil {
+			return from, to, fmt.Errorf("invalid to: %q", toStr)
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return from, to, fmt.Errorf("to is before from")
+	}
+	return from, to, nil
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/usecase"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListEventNames(t *testing.T) {
+	analyticsUC := new(MockAnalyticsUsecase)
+	h := NewHandler(nil, analyticsUC, nil, nil, nil, nil)
+
+	analyticsUC.On("ListEventNames", mock.Anything, time.Time{}, time.Time{}).
+		Return([]models.EventNameSummary{{EventName: "app_launch", Count: 12}}, nil)
+
+	rec := httptest.NewRecorder()
+	h.ListEventNames(rec, httptest.NewRequest(http.MethodGet, "/catalog/event-names", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"count":12`)
+}
+
+func TestListEventNames_InvalidRange(t *testing.T) {
+	h := NewHandler(nil, new(MockAnalyticsUsecase), nil, nil, nil, nil)
+
+	for _, query := range []string{"from=yesterday", "from=2026-03-02T00:00:00Z&to=2026-03-01T00:00:00Z"} {
+		rec := httptest.NewRecorder()
+		h.ListEventNames(rec, httptest.NewRequest(http.MethodGet, "/catalog/event-names?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestDescribePayload(t *testing.T) {
+	analyticsUC := new(MockAnalyticsUsecase)
+	h := NewHandler(nil, analyticsUC, nil, nil, nil, nil)
+
+	analyticsUC.On("DescribePayload", mock.Anything, "app_launch", time.Time{}, time.Time{}, 200).
+		Return(&models.PayloadDescription{EventName: "app_launch", Sampled: 200, Keys: []models.PayloadKey{{Path: "version", Type: "string"}}}, nil)
+	analyticsUC.On("DescribePayload", mock.Anything, "", time.Time{}, time.Time{}, 0).
+		Return(nil, usecase.ErrInvalidAnalyticsQuery)
+
+	req := withURLParams(httptest.NewRequest(http.MethodGet, "/catalog/event-names/app_launch?sample=200", nil), map[string]string{"event_name": "app_launch"})
+	rec := httptest.NewRecorder()
+	h.DescribePayload(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"path":"version"`)
+
+	req = withURLParams(httptest.NewRequest(http.MethodGet, "/catalog/event-names/", nil), map[string]string{"event_name": ""})
+	rec = httptest.NewRecorder()
+	h.DescribePayload(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
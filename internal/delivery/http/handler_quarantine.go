@@ -0,0 +1,203 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/herpiko/blankon-telemetry-backend/internal/usecase"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+// rawCapture keeps the first MaxQuarantineRawSize bytes written to it and
+// counts the rest, so a body that fails to decode can be quarantined
+// without buffering all of it.
+type rawCapture struct {
+	buf  []byte
+	size int
+}
+
+func (c *rawCapture) Write(p []byte) (int, error) {
+	c.size += len(p)
+	if room := usecase.MaxQuarantineRawSize - len(c.buf); room > 0 {
+		if len(p) > room {
+			c.buf = append(c.buf, p[:room]...)
+		} else {
+			c.buf = append(c.buf, p...)
+		}
+	}
+	return len(p), nil
+}
+
+// quarantineBody records a request body that is not valid JSON. The rest
+// of the body is read so the entry has its full size.
+func (h *Handler) quarantineBody(r *http.Request, capture *rawCapture, decodeErr error) {
+	if h.quarantineUC == nil {
+		return
+	}
+	if _, err := io.Copy(capture, r.Body); err != nil {
+		log.Printf("quarantineBody: read rest of body: %v", err)
+	}
+
+	entry := &models.QuarantinedEvent{
+		Reason: models.QuarantineReasonInvalidJSON,
+		Error:  decodeErr.Error(),
+	}
+	usecase.SetQuarantineRaw(entry, capture.buf, capture.size)
+	h.quarantine(r, entry)
+}
+
+// quarantine stores entries, logging failures: the client is told about the
+// rejection either way.
+func (h *Handler) quarantine(r *http.Request, entries ...*models.QuarantinedEvent) {
+	if h.quarantineUC == nil || len(entries) == 0 {
+		return
+	}
+	if err := h.quarantineUC.Quarantine(r.Context(), entries...); err != nil {
+		log.Printf("quarantine: failed to quarantine %d entries: %v", len(entries), err)
+	}
+}
+
+func (h *Handler) ListQuarantine(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.quarantineFilter(w, r)
+	if !ok {
+		return
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err == nil && offset >= 0 {
+			filter.Offset = offset
+		}
+	}
+
+	entries, err := h.quarantineUC.ListQuarantined(r.Context(), filter)
+	if err != nil {
+		log.Printf("ListQuarantine: failed to list entries: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "failed to list quarantined events")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, entries)
+}
+
+func (h *Handler) GetQuarantined(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.quarantineID(w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := h.quarantineUC.GetQuarantined(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, usecase.ErrQuarantineNotFound) {
+			h.respondError(w, http.StatusNotFound, "quarantine entry not found")
+			return
+		}
+		log.Printf("GetQuarantined: failed to get entry %d: %v", id, err)
+		h.respondError(w, http.StatusInternalServerError, "failed to get quarantined event")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, entry)
+}
+
+func (h *Handler) ReprocessQuarantined(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.quarantineID(w, r)
+	if !ok {
+		return
+	}
+
+	event, err := h.quarantineUC.Reprocess(r.Context(), id)
+	if err != nil {
+		var verr *usecase.ValidationError
+		switch {
+		case errors.As(err, &verr):
+			h.respondInvalidPayload(w, verr)
+		case errors.Is(err, usecase.ErrQuarantineNotFound):
+			h.respondError(w, http.StatusNotFound, "quarantine entry not found")
+		case errors.Is(err, usecase.ErrAlreadyReprocessed):
+			h.respondError(w, http.StatusConflict, err.Error())
+		case errors.Is(err, usecase.ErrNotReprocessable), errors.Is(err, usecase.ErrInvalidEvent):
+			h.respondError(w, http.StatusUnprocessableEntity, err.Error())
+		case errors.Is(err, usecase.ErrQueueFull):
+			h.respondBusy(w)
+		default:
+			log.Printf("ReprocessQuarantined: failed to reprocess entry %d: %v", id, err)
+			h.respondError(w, http.StatusInternalServerError, "failed to reprocess quarantined event")
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, event)
+}
+
+// ReprocessQuarantine resubmits pending entries matching the filter, e.g.
+// all schema violations of one event name after its schema was fixed.
+func (h *Handler) ReprocessQuarantine(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.quarantineFilter(w, r)
+	if !ok {
+		return
+	}
+
+	if afterStr := r.URL.Query().Get("after_id"); afterStr != "" {
+		after, err := strconv.ParseInt(afterStr, 10, 64)
+		if err != nil || after < 0 {
+			h.respondError(w, http.StatusBadRequest, "invalid after_id")
+			return
+		}
+		filter.AfterID = after
+	}
+
+	resp, err := h.quarantineUC.ReprocessPending(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, usecase.ErrQueueFull) {
+			h.respondBusy(w)
+			return
+		}
+		log.Printf("ReprocessQuarantine: failed: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "failed to reprocess quarantined events")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) quarantineFilter(w http.ResponseWriter, r *http.Request) (models.QuarantineFilter, bool) {
+	query := r.URL.Query()
+	filter := models.QuarantineFilter{
+		Reason:    query.Get("reason"),
+		EventName: query.Get("event_name"),
+	}
+
+	if reprocessedStr := query.Get("reprocessed"); reprocessedStr != "" {
+		reprocessed, err := strconv.ParseBool(reprocessedStr)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid reprocessed flag")
+			return filter, false
+		}
+		filter.Reprocessed = &reprocessed
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err == nil && limit > 0 {
+			filter.Limit = limit
+		}
+	}
+
+	return filter, true
+}
+
+func (h *Handler) quarantineID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		log.Printf("quarantineID: invalid quarantine id %q: %v", idStr, err)
+		h.respondError(w, http.StatusBadRequest, "invalid quarantine id")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,191 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/usecase"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockQuarantineUsecase is a mock implementation of QuarantineUsecase
+type MockQuarantineUsecase struct {
+	mock.Mock
+}
+
+func (m *MockQuarantineUsecase) Quarantine(ctx context.Context, entries ...*models.QuarantinedEvent) error {
+	args := m.Called(ctx, entries)
+	return args.Error(0)
+}
+
+func (m *MockQuarantineUsecase) GetQuarantined(ctx context.Context, id int64) (*models.QuarantinedEvent, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.QuarantinedEvent), args.Error(1)
+}
+
+func (m *MockQuarantineUsecase) ListQuarantined(ctx context.Context, filter models.QuarantineFilter) ([]models.QuarantinedEvent, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.QuarantinedEvent), args.Error(1)
+}
+
+func (m *MockQuarantineUsecase) Reprocess(ctx context.Context, id int64) (*models.Event, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Event), args.Error(1)
+}
+
+func (m *MockQuarantineUsecase) ReprocessPending(ctx context.Context, filter models.QuarantineFilter) (*models.ReprocessResponse, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ReprocessResponse), args.Error(1)
+}
+
+func TestCreateEvent_QuarantinesInvalidJSON(t *testing.T) {
+	quarantineUC := new(MockQuarantineUsecase)
+	h := NewHandler(new(MockEventUsecase), nil, nil, quarantineUC, nil, nil)
+
+	body := `{"event_name": "app_launch", "payload": {` + strings.Repeat(" ", 8192) + `oops}`
+	quarantineUC.On("Quarantine", mock.Anything, mock.MatchedBy(func(entries []*models.QuarantinedEvent) bool {
+		return len(entries) == 1 &&
+			entries[0].Reason == models.QuarantineReasonInvalidJSON &&
+			entries[0].Raw == body &&
+			entries[0].RawSize == len(body) &&
+			!entries[0].Truncated
+	})).Return(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	h.CreateEvent(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	quarantineUC.AssertExpectations(t)
+}
+
+func TestCreateEvent_PayloadTooLarge(t *testing.T) {
+	mockUC := new(MockEventUsecase)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
+
+	mockUC.On("CreateEvent", mock.Anything, mock.AnythingOfType("models.CreateEventRequest")).Return(nil, "", usecase.ErrPayloadTooLarge)
+
+	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader([]byte(`{"event_name":"app_launch"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	h.CreateEvent(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestCreateEventStream_QuarantinesInvalidLines(t *testing.T) {
+	mockUC := new(MockEventUsecase)
+	quarantineUC := new(MockQuarantineUsecase)
+	h := NewHandler(mockUC, nil, nil, quarantineUC, nil, nil)
+
+	mockUC.On("CreateEvents", mock.Anything, mock.Anything).Return(&models.BatchResponse{
+		Accepted: 1,
+		Results:  []models.BatchItemResult{{Index: 0, Status: models.BatchStatusCreated}},
+	}, nil)
+	quarantineUC.On("Quarantine", mock.Anything, mock.MatchedBy(func(entries []*models.QuarantinedEvent) bool {
+		return len(entries) == 1 && entries[0].Raw == `{"event_name":` && entries[0].Reason == models.QuarantineReasonInvalidJSON
+	})).Return(nil)
+
+	body := "{\"event_name\":\"a\"}\n{\"event_name\":\n"
+	req := httptest.NewRequest(http.MethodPost, "/events/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rec := httptest.NewRecorder()
+
+	h.CreateEvents(rec, req)
+
+	assert.Equal(t, http.StatusMultiStatus, rec.Code)
+	quarantineUC.AssertExpectations(t)
+}
+
+func TestListQuarantine_Filters(t *testing.T) {
+	quarantineUC := new(MockQuarantineUsecase)
+	h := NewHandler(nil, nil, nil, quarantineUC, nil, nil)
+
+	pending := false
+	quarantineUC.On("ListQuarantined", mock.Anything, models.QuarantineFilter{
+		Reason:      models.QuarantineReasonSchemaViolation,
+		EventName:   "app_launch",
+		Reprocessed: &pending,
+		Limit:       20,
+	}).Return([]models.QuarantinedEvent{{ID: 1}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/quarantine?reason=schema_violation&event_name=app_launch&reprocessed=false&limit=20", nil)
+	rec := httptest.NewRecorder()
+
+	h.ListQuarantine(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	quarantineUC.AssertExpectations(t)
+}
+
+func TestReprocessQuarantined(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"success", nil, http.StatusOK},
+		{"not found", usecase.ErrQuarantineNotFound, http.StatusNotFound},
+		{"already reprocessed", usecase.ErrAlreadyReprocessed, http.StatusConflict},
+		{"not reprocessable", usecase.ErrNotReprocessable, http.StatusUnprocessableEntity},
+		{"still invalid", &usecase.ValidationError{EventName: "app_launch"}, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quarantineUC := new(MockQuarantineUsecase)
+			h := NewHandler(nil, nil, nil, quarantineUC, nil, nil)
+
+			if tt.err == nil {
+				quarantineUC.On("Reprocess", mock.Anything, int64(5)).Return(&models.Event{ID: 9}, nil)
+			} else {
+				quarantineUC.On("Reprocess", mock.Anything, int64(5)).Return(nil, tt.err)
+			}
+
+			req := withURLParams(httptest.NewRequest(http.MethodPost, "/admin/quarantine/5/reprocess", nil), map[string]string{"id": "5"})
+			rec := httptest.NewRecorder()
+
+			h.ReprocessQuarantined(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
+
+func TestReprocessQuarantine_AfterID(t *testing.T) {
+	quarantineUC := new(MockQuarantineUsecase)
+	h := NewHandler(nil, nil, nil, quarantineUC, nil, nil)
+
+	quarantineUC.On("ReprocessPending", mock.Anything, models.QuarantineFilter{EventName: "app_launch", AfterID: 42}).
+		Return(&models.ReprocessResponse{Reprocessed: 3, Results: []models.ReprocessResult{}}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/quarantine/reprocess?event_name=app_launch&after_id=42", nil)
+	rec := httptest.NewRecorder()
+
+	h.ReprocessQuarantine(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"reprocessed":3`)
+	quarantineUC.AssertExpectations(t)
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/herpiko/blankon-telemetry-backend/internal/usecase"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+func (h *Handler) CreateSchema(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateSchemaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("CreateSchema: invalid request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	schema, err := h.schemaUC.CreateSchema(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidSchema) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("CreateSchema: failed to create schema: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "failed to create schema")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, schema)
+}
+
+func (h *Handler) ListSchemas(w http.ResponseWriter, r *http.Request) {
+	eventName := chi.URLParam(r, "event_name")
+	if eventName == "" {
+		eventName = r.URL.Query().Get("event_name")
+	}
+
+	schemas, err := h.schemaUC.ListSchemas(r.Context(), eventName)
+	if err != nil {
+		log.Printf("ListSchemas: failed to list schemas: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "failed to list schemas")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, schemas)
+}
+
+func (h *Handler) GetSchema(w http.ResponseWriter, r *http.Request) {
+	eventName, version, ok := h.schemaParams(w, r)
+	if !ok {
+		return
+	}
+
+	schema, err := h.schemaUC.GetSchema(r.Context(), eventName, version)
+	if err != nil {
+		if errors.Is(err, usecase.ErrSchemaNotFound) {
+			h.respondError(w, http.StatusNotFound, "schema not found")
+			return
+		}
+		log.Printf("GetSchema: failed to get schema %q v%d: %v", eventName, version, err)
+		h.respondError(w, http.StatusInternalServerError, "failed to get schema")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, schema)
+}
+
+// DeactivateSchema stops a schema version from being used for validation.
+// Versions are never deleted so stored events can still be traced back to
+// the schema they were accepted under.
+func (h *Handler) DeactivateSchema(w http.ResponseWriter, r *http.Request) {
+	eventName, version, ok := h.schemaParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.schemaUC.SetSchemaActive(r.Context(), eventName, version, false); err != nil {
+		if errors.Is(err, usecase.ErrSchemaNotFound) {
+			h.respondError(w, http.StatusNotFound, "schema not found")
+			return
+		}
+		log.Printf("DeactivateSchema: failed to deactivate schema %q v%d: %v", eventName, version, err)
+		h.respondError(w, http.StatusInternalServerError, "failed to deactivate schema")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) schemaParams(w http.ResponseWriter, r *http.Request) (string, int, bool) {
+	eventName := chi.URLParam(r, "event_name")
+	versionStr := chi.URLParam(r, "version")
+
+	version, err := strconv.Atoi(versionStr)
+	if err != nil || version <= 0 {
+		log.Printf("schemaParams: invalid schema version %q", versionStr)
+		h.respondError(w, http.StatusBadRequest, "invalid schema version")
+		return "", 0, false
+	}
+
+	return eventName, version, true
+}
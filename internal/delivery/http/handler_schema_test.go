@@ -0,0 +1,181 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/herpiko/blankon-telemetry-backend/internal/usecase"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSchemaUsecase is a mock implementation of SchemaUsecase
+type MockSchemaUsecase struct {
+	mock.Mock
+}
+
+func (m *MockSchemaUsecase) ValidatePayload(ctx context.Context, eventName string, version int, payload map[string]interface{}) error {
+	args := m.Called(ctx, eventName, version, payload)
+	return args.Error(0)
+}
+
+func (m *MockSchemaUsecase) CreateSchema(ctx context.Context, req models.CreateSchemaRequest) (*models.EventSchema, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EventSchema), args.Error(1)
+}
+
+func (m *MockSchemaUsecase) GetSchema(ctx context.Context, eventName string, version int) (*models.EventSchema, error) {
+	args := m.Called(ctx, eventName, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EventSchema), args.Error(1)
+}
+
+func (m *MockSchemaUsecase) ListSchemas(ctx context.Context, eventName string) ([]models.EventSchema, error) {
+	args := m.Called(ctx, eventName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.EventSchema), args.Error(1)
+}
+
+func (m *MockSchemaUsecase) SetSchemaActive(ctx context.Context, eventName string, version int, active bool) error {
+	args := m.Called(ctx, eventName, version, active)
+	return args.Error(0)
+}
+
+func withURLParams(req *http.Request, params map[string]string) *http.Request {
+	rctx := chi.NewRouteContext()
+	for key, value := range params {
+		rctx.URLParams.Add(key, value)
+	}
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestCreateEvent_SchemaViolation(t *testing.T) {
+	mockUC := new(MockEventUsecase)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
+
+	mockUC.On("CreateEvent", mock.Anything, mock.AnythingOfType("models.CreateEventRequest")).Return(nil, "", &usecase.ValidationError{
+		EventName: "app_launch",
+		Version:   1,
+		Fields:    []models.FieldError{{Field: "/version", Keyword: "type", Message: "expected string, but got number"}},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader([]byte(`{"event_name":"app_launch","payload":{"version":3}}`)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	h.CreateEvent(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	var resp struct {
+		Error   string              `json:"error"`
+		Details []models.FieldError `json:"details"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp.Error)
+	require.Len(t, resp.Details, 1)
+	assert.Equal(t, "/version", resp.Details[0].Field)
+	assert.Equal(t, "type", resp.Details[0].Keyword)
+}
+
+func TestCreateSchema_Success(t *testing.T) {
+	schemaUC := new(MockSchemaUsecase)
+	h := NewHandler(nil, nil, schemaUC, nil, nil, nil)
+
+	schemaUC.On("CreateSchema", mock.Anything, mock.AnythingOfType("models.CreateSchemaRequest")).
+		Return(&models.EventSchema{EventName: "app_launch", Version: 2, Active: true}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/schemas", bytes.NewReader([]byte(`{"event_name":"app_launch","schema":{"type":"object"}}`)))
+	rec := httptest.NewRecorder()
+
+	h.CreateSchema(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"version":2`)
+	schemaUC.AssertExpectations(t)
+}
+
+func TestCreateSchema_Invalid(t *testing.T) {
+	schemaUC := new(MockSchemaUsecase)
+	h := NewHandler(nil, nil, schemaUC, nil, nil, nil)
+
+	schemaUC.On("CreateSchema", mock.Anything, mock.AnythingOfType("models.CreateSchemaRequest")).
+		Return(nil, usecase.ErrInvalidSchema)
+
+	req := httptest.NewRequest(http.MethodPost, "/schemas", bytes.NewReader([]byte(`{"event_name":"app_launch","schema":{"type":42}}`)))
+	rec := httptest.NewRecorder()
+
+	h.CreateSchema(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestListSchemas_ByEventName(t *testing.T) {
+	schemaUC := new(MockSchemaUsecase)
+	h := NewHandler(nil, nil, schemaUC, nil, nil, nil)
+
+	schemaUC.On("ListSchemas", mock.Anything, "app_launch").Return([]models.EventSchema{{EventName: "app_launch", Version: 1}}, nil)
+
+	req := withURLParams(httptest.NewRequest(http.MethodGet, "/schemas/app_launch", nil), map[string]string{"event_name": "app_launch"})
+	rec := httptest.NewRecorder()
+
+	h.ListSchemas(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	schemaUC.AssertExpectations(t)
+}
+
+func TestGetSchema_NotFound(t *testing.T) {
+	schemaUC := new(MockSchemaUsecase)
+	h := NewHandler(nil, nil, schemaUC, nil, nil, nil)
+
+	schemaUC.On("GetSchema", mock.Anything, "app_launch", 4).Return(nil, usecase.ErrSchemaNotFound)
+
+	req := withURLParams(httptest.NewRequest(http.MethodGet, "/schemas/app_launch/4", nil), map[string]string{"event_name": "app_launch", "version": "4"})
+	rec := httptest.NewRecorder()
+
+	h.GetSchema(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	schemaUC.AssertExpectations(t)
+}
+
+func TestGetSchema_InvalidVersion(t *testing.T) {
+	h := NewHandler(nil, nil, new(MockSchemaUsecase), nil, nil, nil)
+
+	req := withURLParams(httptest.NewRequest(http.MethodGet, "/schemas/app_launch/latest", nil), map[string]string{"event_name": "app_launch", "version": "latest"})
+	rec := httptest.NewRecorder()
+
+	h.GetSchema(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestDeactivateSchema(t *testing.T) {
+	schemaUC := new(MockSchemaUsecase)
+	h := NewHandler(nil, nil, schemaUC, nil, nil, nil)
+
+	schemaUC.On("SetSchemaActive", mock.Anything, "app_launch", 1, false).Return(nil)
+
+	req := withURLParams(httptest.NewRequest(http.MethodDelete, "/schemas/app_launch/1", nil), map[string]string{"event_name": "app_launch", "version": "1"})
+	rec := httptest.NewRecorder()
+
+	h.DeactivateSchema(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	schemaUC.AssertExpectations(t)
+}
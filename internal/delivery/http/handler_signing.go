@@ -0,0 +1,77 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/usecase"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+func (h *Handler) CreateSigningSecret(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateSigningSecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("CreateSigningSecret: invalid request body: %v", err)
+		h.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	secret, err := h.signingUC.CreateSigningSecret(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidSigningSecret) {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("CreateSigningSecret: failed to create secret: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "failed to create signing secret")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, secret)
+}
+
+func (h *Handler) ListSigningSecrets(w http.ResponseWriter, r *http.Request) {
+	secrets, err := h.signingUC.ListSigningSecrets(r.Context())
+	if err != nil {
+		log.Printf("ListSigningSecrets: failed to list secrets: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "failed to list signing secrets")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, secrets)
+}
+
+func (h *Handler) RevokeSigningSecret(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.signingSecretID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.signingUC.RevokeSigningSecret(r.Context(), id); err != nil {
+		if errors.Is(err, usecase.ErrSigningSecretNotFound) {
+			h.respondError(w, http.StatusNotFound, "signing secret not found")
+			return
+		}
+		log.Printf("RevokeSigningSecret: failed to revoke secret %d: %v", id, err)
+		h.respondError(w, http.StatusInternalServerError, "failed to revoke signing secret")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) signingSecretID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		log.Printf("signingSecretID: invalid signing secret id %q: %v", idStr, err)
+		h.respondError(w, http.StatusBadRequest, "invalid signing secret id")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/usecase"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSigningUsecase is a mock implementation of SigningUsecase
+type MockSigningUsecase struct {
+	mock.Mock
+}
+
+func (m *MockSigningUsecase) Mode() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockSigningUsecase) Verify(ctx context.Context, sig models.RequestSignature, body []byte) error {
+	args := m.Called(ctx, sig, body)
+	return args.Error(0)
+}
+
+func (m *MockSigningUsecase) CreateSigningSecret(ctx context.Context, req models.CreateSigningSecretRequest) (*models.CreatedSigningSecret, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CreatedSigningSecret), args.Error(1)
+}
+
+func (m *MockSigningUsecase) ListSigningSecrets(ctx context.Context) ([]models.SigningSecret, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SigningSecret), args.Error(1)
+}
+
+func (m *MockSigningUsecase) RevokeSigningSecret(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestVerifySignature_CompressedBody(t *testing.T) {
+	signingUC := new(MockSigningUsecase)
+	h := NewHandler(nil, nil, nil, nil, nil, signingUC)
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte(`{"event_name":"app_launch"}`))
+	zw.Close()
+
+	sig := models.RequestSignature{Release: "blankon-12", Timestamp: "1700000000", Signature: "sha256=abcd"}
+	signingUC.On("Mode").Return(usecase.SigningRequired)
+	signingUC.On("Verify", mock.Anything, sig, compressed.Bytes()).Return(nil)
+
+	var got []byte
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(compressed.Bytes()))
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("X-Telemetry-Release", sig.Release)
+	req.Header.Set("X-Telemetry-Timestamp", sig.Timestamp)
+	req.Header.Set("X-Telemetry-Signature", sig.Signature)
+	rec := httptest.NewRecorder()
+
+	h.VerifySignature(h.DecompressRequest(next)).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, `{"event_name":"app_launch"}`, string(got))
+	signingUC.AssertExpectations(t)
+}
+
+func TestVerifySignature_Rejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"unsigned", usecase.ErrSignatureRequired, http.StatusUnauthorized},
+		{"invalid", usecase.ErrInvalidSignature, http.StatusUnauthorized},
+		{"replayed", usecase.ErrStaleSignature, http.StatusUnauthorized},
+		{"database down", context.DeadlineExceeded, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signingUC := new(MockSigningUsecase)
+			h := NewHandler(nil, nil, nil, nil, nil, signingUC)
+
+			signingUC.On("Mode").Return(usecase.SigningRequired)
+			signingUC.On("Verify", mock.Anything, mock.Anything, mock.Anything).Return(tt.err)
+
+			called := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+			req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader([]byte(`{}`)))
+			rec := httptest.NewRecorder()
+
+			h.VerifySignature(next).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.False(t, called)
+		})
+	}
+}
+
+func TestVerifySignature_Off(t *testing.T) {
+	signingUC := new(MockSigningUsecase)
+	h := NewHandler(nil, nil, nil, nil, nil, signingUC)
+
+	signingUC.On("Mode").Return(usecase.SigningOff)
+
+	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader([]byte(`{}`)))
+	req.Header.Set("X-Telemetry-Signature", "sha256=bogus")
+	rec := httptest.NewRecorder()
+
+	h.VerifySignature(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	signingUC.AssertNotCalled(t, "Verify", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRevokeSigningSecret(t *testing.T) {
+	signingUC := new(MockSigningUsecase)
+	h := NewHandler(nil, nil, nil, nil, nil, signingUC)
+
+	signingUC.On("RevokeSigningSecret", mock.Anything, int64(2)).Return(nil)
+	signingUC.On("RevokeSigningSecret", mock.Anything, int64(9)).Return(usecase.ErrSigningSecretNotFound)
+
+	req := withURLParams(httptest.NewRequest(http.MethodDelete, "/admin/signing-secrets/2", nil), map[string]string{"id": "2"})
+	rec := httptest.NewRecorder()
+	h.RevokeSigningSecret(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	req = withURLParams(httptest.NewRequest(http.MethodDelete, "/admin/signing-secrets/9", nil), map[string]string{"id": "9"})
+	rec = httptest.NewRecorder()
+	h.RevokeSigningSecret(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"net/http"
@@ -21,12 +22,20 @@ type MockEventUsecase struct {
 	mock.Mock
 }
 
-func (m *MockEventUsecase) CreateEvent(ctx context.Context, req models.CreateEventRequest) (*models.Event, error) {
+func (m *MockEventUsecase) CreateEvent(ctx context.Context, req models.CreateEventRequest) (*models.Event, string, error) {
 	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.String(1), args.Error(2)
+	}
+	return args.Get(0).(*models.Event), args.String(1), args.Error(2)
+}
+
+func (m *MockEventUsecase) CreateEvents(ctx context.Context, reqs []models.CreateEventRequest) (*models.BatchResponse, error) {
+	args := m.Called(ctx, reqs)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*models.Event), args.Error(1)
+	return args.Get(0).(*models.BatchResponse), args.Error(1)
 }
 
 func (m *MockEventUsecase) GetEvent(ctx context.Context, id int64) (*models.Event, error) {
@@ -37,17 +46,38 @@ func (m *MockEventUsecase) GetEvent(ctx context.Context, id int64) (*models.Even
 	return args.Get(0).(*models.Event), args.Error(1)
 }
 
-func (m *MockEventUsecase) ListEvents(ctx context.Context, filter models.EventFilter) ([]models.Event, error) {
+func (m *MockEventUsecase) ListEvents(ctx context.Context, filter models.EventFilter) (*models.EventPage, error) {
 	args := m.Called(ctx, filter)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]models.Event), args.Error(1)
+	return args.Get(0).(*models.EventPage), args.Error(1)
+}
+
+func (m *MockEventUsecase) ListUserSessions(ctx context.Context, userID string, q models.SessionQuery, limit int) (*models.UserSessions, error) {
+	args := m.Called(ctx, userID, q, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserSessions), args.Error(1)
+}
+
+func (m *MockEventUsecase) ListUserEvents(ctx context.Context, filter models.UserEventFilter) (*models.EventPage, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EventPage), args.Error(1)
+}
+
+func (m *MockEventUsecase) IngestStats(ctx context.Context) models.IngestStats {
+	args := m.Called(ctx)
+	return args.Get(0).(models.IngestStats)
 }
 
 func TestHealth(t *testing.T) {
 	mockUC := new(MockEventUsecase)
-	h := NewHandler(mockUC, nil)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/health", nil)
 	rec := httptest.NewRecorder()
@@ -64,7 +94,7 @@ func TestHealth(t *testing.T) {
 
 func TestCreateEvent_Success(t *testing.T) {
 	mockUC := new(MockEventUsecase)
-	h := NewHandler(mockUC, nil)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
 
 	now := time.Now()
 	reqBody := models.CreateEventRequest{
@@ -81,7 +111,7 @@ func TestCreateEvent_Success(t *testing.T) {
 		CreatedAt: now,
 	}
 
-	mockUC.On("CreateEvent", mock.Anything, mock.AnythingOfType("models.CreateEventRequest")).Return(expectedEvent, nil)
+	mockUC.On("CreateEvent", mock.Anything, mock.AnythingOfType("models.CreateEventRequest")).Return(expectedEvent, models.BatchStatusCreated, nil)
 
 	body, _ := json.Marshal(reqBody)
 	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(body))
@@ -94,9 +124,62 @@ func TestCreateEvent_Success(t *testing.T) {
 	mockUC.AssertExpectations(t)
 }
 
+func TestCreateEvent_IdempotencyKeyHeader(t *testing.T) {
+	mockUC := new(MockEventUsecase)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
+
+	mockUC.On("CreateEvent", mock.Anything, models.CreateEventRequest{EventName: "test_event", IdempotencyKey: "abc-123"}).
+		Return(&models.Event{ID: 7, EventName: "test_event", IdempotencyKey: "abc-123"}, models.BatchStatusCreated, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader([]byte(`{"event_name":"test_event"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "abc-123")
+	rec := httptest.NewRecorder()
+
+	h.CreateEvent(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	mockUC.AssertExpectations(t)
+}
+
+func TestCreateEvent_Queued(t *testing.T) {
+	mockUC := new(MockEventUsecase)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
+
+	mockUC.On("CreateEvent", mock.Anything, mock.AnythingOfType("models.CreateEventRequest")).
+		Return(&models.Event{EventName: "test_event"}, models.BatchStatusQueued, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader([]byte(`{"event_name":"test_event"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	h.CreateEvent(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	mockUC.AssertExpectations(t)
+}
+
+func TestCreateEvent_QueueFull(t *testing.T) {
+	mockUC := new(MockEventUsecase)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
+
+	mockUC.On("CreateEvent", mock.Anything, mock.AnythingOfType("models.CreateEventRequest")).
+		Return(nil, "", usecase.ErrQueueFull)
+
+	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader([]byte(`{"event_name":"test_event"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	h.CreateEvent(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	mockUC.AssertExpectations(t)
+}
+
 func TestCreateEvent_InvalidJSON(t *testing.T) {
 	mockUC := new(MockEventUsecase)
-	h := NewHandler(mockUC, nil)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader([]byte("invalid json")))
 	req.Header.Set("Content-Type", "application/json")
@@ -109,13 +192,13 @@ func TestCreateEvent_InvalidJSON(t *testing.T) {
 
 func TestCreateEvent_InvalidEvent(t *testing.T) {
 	mockUC := new(MockEventUsecase)
-	h := NewHandler(mockUC, nil)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
 
 	reqBody := models.CreateEventRequest{
 		EventName: "", // Invalid - empty name
 	}
 
-	mockUC.On("CreateEvent", mock.Anything, mock.AnythingOfType("models.CreateEventRequest")).Return(nil, usecase.ErrInvalidEvent)
+	mockUC.On("CreateEvent", mock.Anything, mock.AnythingOfType("models.CreateEventRequest")).Return(nil, "", usecase.ErrInvalidEvent)
 
 	body, _ := json.Marshal(reqBody)
 	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(body))
@@ -128,9 +211,238 @@ func TestCreateEvent_InvalidEvent(t *testing.T) {
 	mockUC.AssertExpectations(t)
 }
 
+func TestCreateEvents_AllCreated(t *testing.T) {
+	mockUC := new(MockEventUsecase)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
+
+	reqBody := []models.CreateEventRequest{
+		{EventName: "app_launch"},
+		{EventName: "app_close"},
+	}
+
+	mockUC.On("CreateEvents", mock.Anything, mock.AnythingOfType("[]models.CreateEventRequest")).Return(&models.BatchResponse{
+		Accepted: 2,
+		Results: []models.BatchItemResult{
+			{Index: 0, Status: models.BatchStatusCreated, Event: &models.Event{ID: 1, EventName: "app_launch"}},
+			{Index: 1, Status: models.BatchStatusCreated, Event: &models.Event{ID: 2, EventName: "app_close"}},
+		},
+	}, nil)
+
+	body, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPost, "/events/batch", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	h.CreateEvents(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	mockUC.AssertExpectations(t)
+}
+
+func TestCreateEvents_PartiallyRejected(t *testing.T) {
+	mockUC := new(MockEventUsecase)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
+
+	reqBody := []models.CreateEventRequest{
+		{EventName: "app_launch"},
+		{EventName: ""},
+	}
+
+	mockUC.On("CreateEvents", mock.Anything, mock.AnythingOfType("[]models.CreateEventRequest")).Return(&models.BatchResponse{
+		Accepted: 1,
+		Rejected: 1,
+		Results: []models.BatchItemResult{
+			{Index: 0, Status: models.BatchStatusCreated, Event: &models.Event{ID: 1, EventName: "app_launch"}},
+			{Index: 1, Status: models.BatchStatusRejected, Error: usecase.ErrInvalidEvent.Error()},
+		},
+	}, nil)
+
+	body, _ := json.Marshal(reqBody)
+	req := httptest.NewRequest(http.MethodPost, "/events/batch", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	h.CreateEvents(rec, req)
+
+	assert.Equal(t, http.StatusMultiStatus, rec.Code)
+	mockUC.AssertExpectations(t)
+}
+
+func TestCreateEvents_Queued(t *testing.T) {
+	mockUC := new(MockEventUsecase)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
+
+	mockUC.On("CreateEvents", mock.Anything, mock.AnythingOfType("[]models.CreateEventRequest")).Return(&models.BatchResponse{
+		Accepted: 1,
+		Queued:   1,
+		Results: []models.BatchItemResult{
+			{Index: 0, Status: models.BatchStatusQueued, Event: &models.Event{EventName: "app_launch"}},
+		},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/events/batch", bytes.NewReader([]byte(`[{"event_name":"app_launch"}]`)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	h.CreateEvents(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	mockUC.AssertExpectations(t)
+}
+
+func TestCreateEvents_TooLarge(t *testing.T) {
+	mockUC := new(MockEventUsecase)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
+
+	mockUC.On("CreateEvents", mock.Anything, mock.AnythingOfType("[]models.CreateEventRequest")).Return(nil, usecase.ErrBatchTooLarge)
+
+	req := httptest.NewRequest(http.MethodPost, "/events/batch", bytes.NewReader([]byte(`[{"event_name":"a"}]`)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	h.CreateEvents(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	mockUC.AssertExpectations(t)
+}
+
+func TestCreateEvent_NDJSON(t *testing.T) {
+	mockUC := new(MockEventUsecase)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
+
+	body := "{\"event_name\":\"app_launch\"}\n" +
+		"not json\n" +
+		"\n" +
+		"{\"event_name\":\"app_close\"}\n"
+
+	mockUC.On("CreateEvents", mock.Anything, []models.CreateEventRequest{
+		{EventName: "app_launch"},
+		{EventName: "app_close"},
+	}).Return(&models.BatchResponse{
+		Accepted: 2,
+		Results: []models.BatchItemResult{
+			{Index: 0, Status: models.BatchStatusCreated, Event: &models.Event{ID: 1, EventName: "app_launch"}},
+			{Index: 1, Status: models.BatchStatusCreated, Event: &models.Event{ID: 2, EventName: "app_close"}},
+		},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rec := httptest.NewRecorder()
+
+	h.CreateEvent(rec, req)
+
+	assert.Equal(t, http.StatusMultiStatus, rec.Code)
+
+	var resp struct {
+		Data models.BatchResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 2, resp.Data.Accepted)
+	assert.Equal(t, 1, resp.Data.Rejected)
+	assert.Len(t, resp.Data.Results, 3)
+	assert.Equal(t, models.BatchStatusCreated, resp.Data.Results[0].Status)
+	assert.Equal(t, 1, resp.Data.Results[1].Index)
+	assert.Equal(t, models.BatchStatusRejected, resp.Data.Results[1].Status)
+	assert.Equal(t, 2, resp.Data.Results[2].Index)
+	// Streamed results carry the stored ID, not the event itself
+	assert.Equal(t, int64(2), resp.Data.Results[2].ID)
+	assert.Nil(t, resp.Data.Results[2].Event)
+	mockUC.AssertExpectations(t)
+}
+
+func TestCreateEvent_NDJSONStoreFailure(t *testing.T) {
+	mockUC := new(MockEventUsecase)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
+
+	mockUC.On("CreateEvents", mock.Anything, mock.AnythingOfType("[]models.CreateEventRequest")).Return(nil, assert.AnError)
+
+	req := httptest.NewRequest(http.MethodPost, "/events/batch", bytes.NewReader([]byte("{\"event_name\":\"app_launch\"}")))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rec := httptest.NewRecorder()
+
+	h.CreateEvents(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var resp struct {
+		Data models.BatchResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 1, resp.Data.Failed)
+	assert.Equal(t, models.BatchStatusFailed, resp.Data.Results[0].Status)
+	mockUC.AssertExpectations(t)
+}
+
+func TestDecompressRequest_Gzip(t *testing.T) {
+	mockUC := new(MockEventUsecase)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
+
+	mockUC.On("CreateEvent", mock.Anything, models.CreateEventRequest{EventName: "app_launch"}).
+		Return(&models.Event{ID: 1, EventName: "app_launch"}, models.BatchStatusCreated, nil)
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(`{"event_name":"app_launch"}`))
+	zw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/events", &buf)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	h.DecompressRequest(http.HandlerFunc(h.CreateEvent)).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	mockUC.AssertExpectations(t)
+}
+
+func TestDecompressRequest_InvalidGzip(t *testing.T) {
+	h := NewHandler(new(MockEventUsecase), nil, nil, nil, nil, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader([]byte("plain text")))
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	h.DecompressRequest(http.HandlerFunc(h.CreateEvent)).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestDecompressRequest_UnsupportedEncoding(t *testing.T) {
+	h := NewHandler(new(MockEventUsecase), nil, nil, nil, nil, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader([]byte("{}")))
+	req.Header.Set("Content-Encoding", "br")
+	rec := httptest.NewRecorder()
+
+	h.DecompressRequest(http.HandlerFunc(h.CreateEvent)).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+}
+
+func TestGetIngestStats(t *testing.T) {
+	mockUC := new(MockEventUsecase)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
+
+	mockUC.On("IngestStats", mock.Anything).Return(models.IngestStats{
+		Async: true,
+		Queue: &models.QueueStats{Depth: 12, Capacity: 100},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/ingest", nil)
+	rec := httptest.NewRecorder()
+
+	h.GetIngestStats(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"depth":12`)
+	mockUC.AssertExpectations(t)
+}
+
 func TestGetEvent_Success(t *testing.T) {
 	mockUC := new(MockEventUsecase)
-	h := NewHandler(mockUC, nil)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
 
 	expectedEvent := &models.Event{
 		ID:        1,
@@ -155,7 +467,7 @@ func TestGetEvent_Success(t *testing.T) {
 
 func TestGetEvent_InvalidID(t *testing.T) {
 	mockUC := new(MockEventUsecase)
-	h := NewHandler(mockUC, nil)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/events/abc", nil)
 	rec := httptest.NewRecorder()
@@ -171,7 +483,7 @@ func TestGetEvent_InvalidID(t *testing.T) {
 
 func TestGetEvent_NotFound(t *testing.T) {
 	mockUC := new(MockEventUsecase)
-	h := NewHandler(mockUC, nil)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
 
 	mockUC.On("GetEvent", mock.Anything, int64(999)).Return(nil, usecase.ErrEventNotFound)
 
@@ -190,14 +502,14 @@ func TestGetEvent_NotFound(t *testing.T) {
 
 func TestListEvents_Success(t *testing.T) {
 	mockUC := new(MockEventUsecase)
-	h := NewHandler(mockUC, nil)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
 
 	expectedEvents := []models.Event{
 		{ID: 1, EventName: "event1"},
 		{ID: 2, EventName: "event2"},
 	}
 
-	mockUC.On("ListEvents", mock.Anything, mock.AnythingOfType("models.EventFilter")).Return(expectedEvents, nil)
+	mockUC.On("ListEvents", mock.Anything, mock.AnythingOfType("models.EventFilter")).Return(&models.EventPage{Events: expectedEvents}, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/events", nil)
 	rec := httptest.NewRecorder()
@@ -205,18 +517,26 @@ func TestListEvents_Success(t *testing.T) {
 	h.ListEvents(rec, req)
 
 	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Offset pages keep data as the list of events
+	var resp struct {
+		Data []models.Event `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, expectedEvents, resp.Data)
+	assert.NotContains(t, rec.Body.String(), "next_cursor")
 	mockUC.AssertExpectations(t)
 }
 
 func TestListEvents_WithFilters(t *testing.T) {
 	mockUC := new(MockEventUsecase)
-	h := NewHandler(mockUC, nil)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
 
 	expectedEvents := []models.Event{
 		{ID: 1, EventName: "app_launch"},
 	}
 
-	mockUC.On("ListEvents", mock.Anything, mock.AnythingOfType("models.EventFilter")).Return(expectedEvents, nil)
+	mockUC.On("ListEvents", mock.Anything, mock.AnythingOfType("models.EventFilter")).Return(&models.EventPage{Events: expectedEvents}, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/events?event_name=app_launch&limit=10&offset=0", nil)
 	rec := httptest.NewRecorder()
@@ -229,11 +549,11 @@ func TestListEvents_WithFilters(t *testing.T) {
 
 func TestListEvents_WithTimeFilters(t *testing.T) {
 	mockUC := new(MockEventUsecase)
-	h := NewHandler(mockUC, nil)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
 
 	expectedEvents := []models.Event{}
 
-	mockUC.On("ListEvents", mock.Anything, mock.AnythingOfType("models.EventFilter")).Return(expectedEvents, nil)
+	mockUC.On("ListEvents", mock.Anything, mock.AnythingOfType("models.EventFilter")).Return(&models.EventPage{Events: expectedEvents}, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/events?from=2026-01-01T00:00:00Z&to=2026-12-31T23:59:59Z", nil)
 	rec := httptest.NewRecorder()
@@ -243,3 +563,74 @@ func TestListEvents_WithTimeFilters(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rec.Code)
 	mockUC.AssertExpectations(t)
 }
+
+func TestListEvents_Cursor(t *testing.T) {
+	mockUC := new(MockEventUsecase)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
+
+	cursor := models.EventCursor{Timestamp: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), ID: 7}
+	mockUC.On("ListEvents", mock.Anything, models.EventFilter{Limit: 2, Cursor: &cursor}).
+		Return(&models.EventPage{Events: []models.Event{{ID: 5}, {ID: 4}}, NextCursor: "next"}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/events?limit=2&cursor="+cursor.String(), nil)
+	rec := httptest.NewRecorder()
+
+	h.ListEvents(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Data       []models.Event `json:"data"`
+		NextCursor string         `json:"next_cursor"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Len(t, resp.Data, 2)
+	assert.Equal(t, "next", resp.NextCursor)
+	mockUC.AssertExpectations(t)
+}
+
+func TestListEvents_InvalidCursor(t *testing.T) {
+	h := NewHandler(new(MockEventUsecase), nil, nil, nil, nil, nil)
+
+	for _, query := range []string{"cursor=not-a-cursor", "cursor=MTcwMDAwMDAwMDAwMDAwMCw3&offset=10", "cursor=MTcwMDAwMDAwMDAwMDAwMCw3LGFzYw"} {
+		req := httptest.NewRequest(http.MethodGet, "/events?"+query, nil)
+		rec := httptest.NewRecorder()
+
+		h.ListEvents(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestListEvents_PayloadFilters(t *testing.T) {
+	mockUC := new(MockEventUsecase)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
+
+	mockUC.On("ListEvents", mock.Anything, models.EventFilter{
+		EventName: "app_launch",
+		Payload: []models.PayloadFilter{
+			{Path: []string{"device", "arch"}, Op: models.PayloadOpIn, Values: []string{"amd64", "arm64"}},
+			{Path: []string{"os"}, Op: models.PayloadOpEq, Values: []string{"linux"}},
+			{Path: []string{"version"}, Op: models.PayloadOpGte, Number: 23},
+		},
+	}).Return(&models.EventPage{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/events?event_name=app_launch&payload.version[gte]=23&payload.os=linux&payload.device.arch[in]=amd64,arm64", nil)
+	rec := httptest.NewRecorder()
+
+	h.ListEvents(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockUC.AssertExpectations(t)
+}
+
+func TestListEvents_InvalidPayloadFilter(t *testing.T) {
+	h := NewHandler(new(MockEventUsecase), nil, nil, nil, nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/events?payload.version[gte]=latest", nil)
+	rec := httptest.NewRecorder()
+
+	h.ListEvents(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+// ListUserSessions lists the latest sessions of one install with their
+// events, e.g. ?gap=30m&limit=20.
+func (h *Handler) ListUserSessions(w http.ResponseWriter, r *http.Request) {
+	q, err := sessionQuery(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var limit int
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 {
+			h.respondError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	sessions, err := h.eventUC.ListUserSessions(r.Context(), chi.URLParam(r, "user_id"), q, limit)
+	if err != nil {
+		h.respondAnalyticsError(w, "ListUserSessions", err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, sessions)
+}
+
+// ListUserEvents lists the events of one install oldest first, e.g.
+// ?event_name=app_launch,app_crash&limit=100&cursor=...; order=desc lists
+// newest first.
+func (h *Handler) ListUserEvents(w http.ResponseWriter, r *http.Request) {
+	filter := models.UserEventFilter{UserID: chi.URLParam(r, "user_id")}
+
+	from, to, err := timeRange(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !from.IsZero() {
+		filter.From = &from
+	}
+	if !to.IsZero() {
+		filter.To = &to
+	}
+
+	if names := r.URL.Query().Get("event_name"); names != "" {
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				filter.EventNames = append(filter.EventNames, name)
+			}
+		}
+	}
+
+	switch order := r.URL.Query().Get("order"); order {
+	case "", "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		h.respondError(w, http.StatusBadRequest, "order must be asc or desc")
+		return
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if filter.Limit, err = strconv.Atoi(limitStr); err != nil || filter.Limit <= 0 {
+			h.respondError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	if cursorStr := r.URL.Query().Get("cursor"); cursorStr != "" {
+		if filter.Cursor, err = models.ParseEventCursor(cursorStr); err != nil {
+			h.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		// Follow the order the cursor was issued for unless one is given;
+		// applied the other way its keyset would select the wrong page
+		switch {
+		case r.URL.Query().Get("order") == "":
+			filter.Descending = !filter.Cursor.Ascending
+		case filter.Cursor.Ascending == filter.Descending:
+			h.respondError(w, http.StatusBadRequest, "cursor does not match order")
+			return
+		}
+	}
+
+	page, err := h.eventUC.ListUserEvents(r.Context(), filter)
+	if err != nil {
+		h.respondAnalyticsError(w, "ListUserEvents", err)
+		return
+	}
+
+	h.respondPage(w, page)
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/usecase"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListUserSessions(t *testing.T) {
+	eventUC := new(MockEventUsecase)
+	h := NewHandler(eventUC, nil, nil, nil, nil, nil)
+
+	eventUC.On("ListUserSessions", mock.Anything, "install-1", models.SessionQuery{}, 5).
+		Return(&models.UserSessions{UserID: "install-1", Sessions: []models.Session{{EventCount: 3}}}, nil)
+	eventUC.On("ListUserSessions", mock.Anything, "install-2", models.SessionQuery{Gap: models.Interval{Count: 2, Unit: "w"}}, 0).
+		Return(nil, usecase.ErrInvalidAnalyticsQuery)
+
+	req := withURLParams(httptest.NewRequest(http.MethodGet, "/users/install-1/sessions?limit=5", nil), map[string]string{"user_id": "install-1"})
+	rec := httptest.NewRecorder()
+	h.ListUserSessions(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"event_count":3`)
+
+	req = withURLParams(httptest.NewRequest(http.MethodGet, "/users/install-2/sessions?gap=2w", nil), map[string]string{"user_id": "install-2"})
+	rec = httptest.NewRecorder()
+	h.ListUserSessions(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	eventUC.AssertExpectations(t)
+}
+
+func TestListUserEvents(t *testing.T) {
+	eventUC := new(MockEventUsecase)
+	h := NewHandler(eventUC, nil, nil, nil, nil, nil)
+
+	cursor := models.EventCursor{Timestamp: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), ID: 7}
+	eventUC.On("ListUserEvents", mock.Anything, models.UserEventFilter{
+		UserID:     "install-1",
+		EventNames: []string{"app_launch", "app_crash"},
+		Limit:      2,
+		Cursor:     &cursor,
+		Descending: true,
+	}).Return(&models.EventPage{Events: []models.Event{{ID: 5}, {ID: 4}}, NextCursor: "next"}, nil)
+
+	req := withURLParams(httptest.NewRequest(http.MethodGet, "/users/install-1/events?event_name=app_launch,app_crash&limit=2&order=desc&cursor="+cursor.String(), nil), map[string]string{"user_id": "install-1"})
+	rec := httptest.NewRecorder()
+	h.ListUserEvents(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"next_cursor":"next"`)
+
+	// Without order the cursor's own order is kept
+	req = withURLParams(httptest.NewRequest(http.MethodGet, "/users/install-1/events?event_name=app_launch,app_crash&limit=2&cursor="+cursor.String(), nil), map[string]string{"user_id": "install-1"})
+	rec = httptest.NewRecorder()
+	h.ListUserEvents(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	eventUC.AssertExpectations(t)
+
+	ascending := models.EventCursor{Timestamp: cursor.Timestamp, ID: 7, Ascending: true}
+	for _, query := range []string{
+		"order=sideways",
+		"cursor=not-a-cursor",
+		"order=desc&cursor=" + ascending.String(),
+		"order=asc&cursor=" + cursor.String(),
+		"limit=0",
+		"from=yesterday",
+	} {
+		req := withURLParams(httptest.NewRequest(http.MethodGet, "/users/install-1/events?"+query, nil), map[string]string{"user_id": "install-1"})
+		rec := httptest.NewRecorder()
+		h.ListUserEvents(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
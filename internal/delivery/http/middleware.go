@@ -0,0 +1,284 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/ratelimit"
+	"github.com/herpiko/blankon-telemetry-backend/internal/usecase"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+// gzipBody wraps a gzip reader so closing the request body also closes the
+// underlying connection body.
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
+
+// maxRequestBodySize bounds the body of an ingest request, after
+// decompression, so neither a large plain body nor a small compressed one
+// can keep the server reading without end.
+const maxRequestBodySize = 64 << 20
+
+// DecompressRequest transparently decodes request bodies sent with
+// Content-Encoding: gzip and caps every body at maxRequestBodySize. Other
+// encodings are rejected with 415.
+func (h *Handler) DecompressRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))) {
+		case "", "identity":
+			r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+		case "gzip", "x-gzip":
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				log.Printf("DecompressRequest: invalid gzip body: %v", err)
+				h.respondError(w, http.StatusBadRequest, "invalid gzip body")
+				return
+			}
+			r.Body = http.MaxBytesReader(w, &gzipBody{Reader: zr, body: r.Body}, maxRequestBodySize)
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+		default:
+			h.respondError(w, http.StatusUnsupportedMediaType, "unsupported content encoding")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bodyTooLarge reports whether err comes from reading a body past
+// maxRequestBodySize.
+func bodyTooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+type apiKeyContextKey struct{}
+
+// apiKeyFromContext returns the key a request was authenticated with, or
+// nil if the route does not require one.
+func apiKeyFromContext(ctx context.Context) *models.APIKey {
+	key, _ := ctx.Value(apiKeyContextKey{}).(*models.APIKey)
+	return key
+}
+
+// RequireScope rejects requests without an API key granting scope. Keys are
+// accepted as a bearer token or in the X-API-Key header.
+func (h *Handler) RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, err := h.authUC.Authenticate(r.Context(), requestAPIKey(r))
+			if err != nil {
+				if errors.Is(err, usecase.ErrUnauthorized) {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="telemetry"`)
+					h.respondError(w, http.StatusUnauthorized, err.Error())
+					return
+				}
+				log.Printf("RequireScope: authenticate: %v", err)
+				h.respondError(w, http.StatusServiceUnavailable, "unable to verify API key")
+				return
+			}
+
+			if !key.HasScope(scope) {
+				h.respondError(w, http.StatusForbidden, "API key lacks the "+scope+" scope")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key)))
+		})
+	}
+}
+
+// Signature headers sent by clients signing their requests.
+const (
+	headerRelease   = "X-Telemetry-Release"
+	headerTimestamp = "X-Telemetry-Timestamp"
+	headerSignature = "X-Telemetry-Signature"
+)
+
+// maxSignedBodySize bounds how much of a signed request is buffered; the
+// body has to be read completely before it can be verified.
+const maxSignedBodySize = 32 << 20
+
+// VerifySignature checks the HMAC signature of a request body before it
+// reaches the handler. It must run before DecompressRequest, as clients
+// sign the body as sent.
+func (h *Handler) VerifySignature(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.signingUC.Mode() == usecase.SigningOff {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		sig := models.RequestSignature{
+			Release:   r.Header.Get(headerRelease),
+			Timestamp: r.Header.Get(headerTimestamp),
+			Signature: r.Header.Get(headerSignature),
+		}
+
+		var body []byte
+		if sig.Signed() {
+			var err error
+			body, err = io.ReadAll(io.LimitReader(r.Body, maxSignedBodySize+1))
+			r.Body.Close()
+			if err != nil {
+				log.Printf("VerifySignature: read body: %v", err)
+				h.respondError(w, http.StatusBadRequest, "failed to read request body")
+				return
+			}
+			if len(body) > maxSignedBodySize {
+				h.respondError(w, http.StatusRequestEntityTooLarge, "signed request body too large")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		if err := h.signingUC.Verify(r.Context(), sig, body); err != nil {
+			if errors.Is(err, usecase.ErrSignatureRequired) || errors.Is(err, usecase.ErrInvalidSignature) {
+				h.respondError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+			log.Printf("VerifySignature: verify: %v", err)
+			h.respondError(w, http.StatusServiceUnavailable, "unable to verify request signature")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func requestAPIKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if scheme, token, ok := strings.Cut(auth, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// Rate limit keys. RateLimitByInstall uses the X-Install-ID header or the
+// payload's user_id and falls back to the client IP. Install IDs are chosen
+// by the client, which can spread its requests over made-up IDs, so keying
+// by install shares capacity fairly between well-behaved clients but is not
+// a security control.
+const (
+	RateLimitByAPIKey  = "api_key"
+	RateLimitByIP      = "ip"
+	RateLimitByInstall = "install"
+)
+
+// maxRateLimitPeek is how much of a body is read to find the install ID;
+// larger bodies are limited by client IP.
+const maxRateLimitPeek = 64 << 10
+
+type RateLimit struct {
+	// Rate is the sustained number of requests per second; zero disables
+	// the limit.
+	Rate float64
+	// Burst is the number of requests allowed at once.
+	Burst int
+	// Key is RateLimitByAPIKey, RateLimitByIP or RateLimitByInstall.
+	Key string
+}
+
+// RateLimit rejects requests exceeding cfg with 429 and Retry-After. Each
+// call creates its own set of buckets, so routes sharing a limit must share
+// the middleware. Keying by API key requires RequireScope to run first.
+func (h *Handler) RateLimit(cfg RateLimit) func(http.Handler) http.Handler {
+	if cfg.Rate <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	limiter := ratelimit.New(cfg.Rate, cfg.Burst)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var key string
+			switch cfg.Key {
+			case RateLimitByAPIKey:
+				if apiKey := apiKeyFromContext(r.Context()); apiKey != nil {
+					key = "key:" + strconv.FormatInt(apiKey.ID, 10)
+				}
+			case RateLimitByInstall:
+				if id := installID(r); id != "" {
+					key = "install:" + id
+				}
+			}
+			if key == "" {
+				key = "ip:" + clientIP(r)
+			}
+
+			if ok, wait := limiter.Allow(key); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+				h.respondError(w, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP returns the request's address without port. middleware.RealIP
+// has already replaced it with the forwarded address, if any.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// installID identifies the installation a request comes from. Without the
+// X-Install-ID header it looks for payload.user_id in small, uncompressed
+// single-event bodies; the body is left intact for the handler.
+func installID(r *http.Request) string {
+	if id := r.Header.Get("X-Install-ID"); id != "" {
+		return id
+	}
+	if r.Body == nil || r.Header.Get("Content-Encoding") != "" {
+		return ""
+	}
+
+	peeked, err := io.ReadAll(io.LimitReader(r.Body, maxRateLimitPeek+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peeked), r.Body), r.Body}
+	if err != nil || len(peeked) > maxRateLimitPeek {
+		return ""
+	}
+
+	var event struct {
+		Payload struct {
+			UserID interface{} `json:"user_id"`
+		} `json:"payload"`
+	}
+	if json.Unmarshal(peeked, &event) != nil {
+		return ""
+	}
+	switch id := event.Payload.UserID.(type) {
+	case string:
+		return id
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64)
+	}
+	return ""
+}
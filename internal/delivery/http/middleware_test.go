@@ -0,0 +1,128 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/usecase"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRateLimit(t *testing.T) {
+	h := NewHandler(nil, nil, nil, nil, nil, nil)
+	limited := h.RateLimit(RateLimit{Rate: 1, Burst: 2, Key: RateLimitByIP})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/analytics/hourly", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		limited.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusNoContent, send("10.0.0.1:1000").Code)
+	assert.Equal(t, http.StatusNoContent, send("10.0.0.1:1001").Code)
+
+	rec := send("10.0.0.1:1002")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusNoContent, send("10.0.0.2:1000").Code)
+}
+
+func TestRateLimit_ByAPIKey(t *testing.T) {
+	h := NewHandler(nil, nil, nil, nil, nil, nil)
+	limited := h.RateLimit(RateLimit{Rate: 1, Burst: 1, Key: RateLimitByAPIKey})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	send := func(keyID int64) int {
+		req := httptest.NewRequest(http.MethodGet, "/events", nil)
+		req = req.WithContext(context.WithValue(req.Context(), apiKeyContextKey{}, &models.APIKey{ID: keyID}))
+		rec := httptest.NewRecorder()
+		limited.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Same client IP, different keys
+	assert.Equal(t, http.StatusNoContent, send(1))
+	assert.Equal(t, http.StatusTooManyRequests, send(1))
+	assert.Equal(t, http.StatusNoContent, send(2))
+}
+
+func TestRateLimit_ByInstall(t *testing.T) {
+	h := NewHandler(nil, nil, nil, nil, nil, nil)
+
+	var bodies []string
+	limited := h.RateLimit(RateLimit{Rate: 1, Burst: 1, Key: RateLimitByInstall})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	send := func(body string, header string) int {
+		req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+		if header != "" {
+			req.Header.Set("X-Install-ID", header)
+		}
+		rec := httptest.NewRecorder()
+		limited.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	first := `{"event_name":"app_launch","payload":{"user_id":"install-a"}}`
+	assert.Equal(t, http.StatusNoContent, send(first, ""))
+	assert.Equal(t, http.StatusTooManyRequests, send(first, ""))
+	assert.Equal(t, http.StatusNoContent, send(`{"event_name":"app_launch","payload":{"user_id":"install-b"}}`, ""))
+	assert.Equal(t, http.StatusNoContent, send(`[]`, "install-c"))
+
+	// The handler still sees the complete body
+	assert.Equal(t, first, bodies[0])
+}
+
+func TestRateLimit_Disabled(t *testing.T) {
+	h := NewHandler(nil, nil, nil, nil, nil, nil)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	limited := h.RateLimit(RateLimit{})(next)
+
+	for i := 0; i < 100; i++ {
+		rec := httptest.NewRecorder()
+		limited.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+}
+
+func TestRouter_AuthRateLimitRunsBeforeAuthentication(t *testing.T) {
+	authUC := new(MockAuthUsecase)
+	router := NewRouter(NewHandler(new(MockEventUsecase), nil, nil, nil, authUC, nil), RouterConfig{
+		AuthRateLimit: RateLimit{Rate: 1, Burst: 1, Key: RateLimitByAPIKey},
+	})
+
+	authUC.On("Authenticate", mock.Anything, "btk_bogus").Return(nil, usecase.ErrUnauthorized).Once()
+
+	send := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/events", nil)
+		req.Header.Set("Authorization", "Bearer btk_bogus")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send())
+	// The key is not looked up again
+	assert.Equal(t, http.StatusTooManyRequests, send())
+	authUC.AssertExpectations(t)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
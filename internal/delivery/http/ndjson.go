@@ -0,0 +1,225 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/usecase"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+const (
+	// ndjsonChunkSize is the number of decoded events handed to the usecase
+	// at a time while streaming, bounding memory use regardless of body size.
+	ndjsonChunkSize = 500
+	// maxNDJSONLineSize is the largest single event line accepted.
+	maxNDJSONLineSize = 1 << 20
+	// maxNDJSONLines bounds the events read from one stream, as each keeps a
+	// small result entry until the response is written. The rest of a
+	// longer stream is left unread and the response is marked truncated.
+	maxNDJSONLines = 100000
+)
+
+var errLineTooLong = errors.New("line exceeds maximum size")
+
+func isNDJSON(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/x-ndjson" || mediaType == "application/jsonl"
+}
+
+// createEventStream ingests a newline-delimited JSON body one line at a time,
+// storing events in chunks of ndjsonChunkSize. Each non-empty line, up to
+// maxNDJSONLines, gets a result entry in submission order; stored events are
+// reported by ID rather than echoed back, so no event outlives its chunk.
+func (h *Handler) createEventStream(w http.ResponseWriter, r *http.Request) {
+	resp := &models.BatchResponse{Results: []models.BatchItemResult{}}
+	br := bufio.NewReader(r.Body)
+
+	var (
+		chunk       []models.CreateEventRequest
+		indexes     []int
+		index       int
+		quarantined []*models.QuarantinedEvent
+	)
+
+	flush := func() {
+		h.quarantine(r, quarantined...)
+		quarantined = quarantined[:0]
+		if len(chunk) == 0 {
+			return
+		}
+		h.storeChunk(r, resp, chunk, indexes)
+		chunk = chunk[:0]
+		indexes = indexes[:0]
+	}
+
+	for {
+		line, err := readLine(br, maxNDJSONLineSize)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, errLineTooLong) {
+			log.Printf("createEventStream: read body: %v", err)
+			resp.Truncated = true
+			break
+		}
+
+		if len(resp.Results) == maxNDJSONLines && (errors.Is(err, errLineTooLong) || len(bytes.TrimSpace(line)) > 0) {
+			resp.Truncated = true
+			break
+		}
+
+		if errors.Is(err, errLineTooLong) {
+			resp.Results = append(resp.Results, rejectedResult(index, errLineTooLong.Error()))
+			resp.Rejected++
+			// The line itself is discarded while reading, so only the
+			// rejection is kept
+			quarantined = append(quarantined, &models.QuarantinedEvent{
+				Reason:    models.QuarantineReasonPayloadTooLarge,
+				Error:     errLineTooLong.Error(),
+				Truncated: true,
+			})
+			index++
+			continue
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			var req models.CreateEventRequest
+			if jsonErr := json.Unmarshal(line, &req); jsonErr != nil {
+				resp.Results = append(resp.Results, rejectedResult(index, "invalid JSON"))
+				resp.Rejected++
+				entry := &models.QuarantinedEvent{
+					Reason: models.QuarantineReasonInvalidJSON,
+					Error:  jsonErr.Error(),
+				}
+				usecase.SetQuarantineRaw(entry, line, len(line))
+				quarantined = append(quarantined, entry)
+				if len(quarantined) == ndjsonChunkSize {
+					flush()
+				}
+			} else {
+				// Reserve the slot so results stay in submission order
+				resp.Results = append(resp.Results, models.BatchItemResult{Index: index})
+				chunk = append(chunk, req)
+				indexes = append(indexes, len(resp.Results)-1)
+				if len(chunk) == ndjsonChunkSize {
+					flush()
+				}
+			}
+			index++
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+	flush()
+
+	if len(resp.Results) == 0 {
+		h.respondError(w, http.StatusBadRequest, "request body contains no events")
+		return
+	}
+
+	h.respondJSON(w, batchStatus(resp), resp)
+}
+
+// storeChunk sends one chunk of decoded events to the usecase and copies the
+// per-item outcomes into the reserved result slots. A chunk that cannot be
+// stored is marked failed so the client can retry just those events.
+func (h *Handler) storeChunk(r *http.Request, resp *models.BatchResponse, chunk []models.CreateEventRequest, slots []int) {
+	chunkResp, err := h.eventUC.CreateEvents(r.Context(), chunk)
+	if err != nil {
+		// Queue saturation is transient too, so it is reported like any
+		// other storage failure
+		log.Printf("createEventStream: failed to store chunk of %d events: %v", len(chunk), err)
+		for _, slot := range slots {
+			resp.Results[slot].Status = models.BatchStatusFailed
+			resp.Results[slot].Error = "failed to store event"
+		}
+		resp.Failed += len(slots)
+		return
+	}
+
+	for i, result := range chunkResp.Results {
+		result.Index = resp.Results[slots[i]].Index
+		if result.Event != nil {
+			result.ID = result.Event.ID
+			result.Event = nil
+		}
+		resp.Results[slots[i]] = result
+	}
+	resp.Accepted += chunkResp.Accepted
+	resp.Duplicates += chunkResp.Duplicates
+	resp.Queued += chunkResp.Queued
+	resp.Spooled += chunkResp.Spooled
+	resp.Rejected += chunkResp.Rejected
+}
+
+// batchStatus picks the HTTP status for a batch response: 503 when no event
+// could be stored, 207 whenever the client has to inspect individual
+// results, 202 when events were queued or spooled and 201 when everything
+// was stored.
+func batchStatus(resp *models.BatchResponse) int {
+	switch {
+	case resp.Failed > 0 && resp.Failed == len(resp.Results):
+		return http.StatusServiceUnavailable
+	case resp.Rejected > 0 || resp.Failed > 0 || resp.Truncated:
+		return http.StatusMultiStatus
+	case resp.Queued > 0 || resp.Spooled > 0:
+		return http.StatusAccepted
+	default:
+		return http.StatusCreated
+	}
+}
+
+func rejectedResult(index int, message string) models.BatchItemResult {
+	return models.BatchItemResult{
+		Index:  index,
+		Status: models.BatchStatusRejected,
+		Error:  message,
+	}
+}
+
+// readLine returns the next line without its trailing newline. Lines longer
+// than max are discarded up to the next newline and reported as
+// errLineTooLong. io.EOF is returned together with the final line.
+func readLine(br *bufio.Reader, max int) ([]byte, error) {
+	var line []byte
+	tooLong := false
+
+	for {
+		part, err := br.ReadSlice('\n')
+		if !tooLong {
+			if len(line)+len(part) > max+1 {
+				tooLong = true
+				line = nil
+			} else {
+				line = append(line, part...)
+			}
+		}
+
+		switch {
+		case err == nil:
+			if tooLong {
+				return nil, errLineTooLong
+			}
+			return bytes.TrimRight(line, "\r\n"), nil
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF):
+			if tooLong {
+				// The oversized line is consumed; report EOF on the next call
+				return nil, errLineTooLong
+			}
+			return bytes.TrimRight(line, "\r\n"), io.EOF
+		default:
+			return nil, err
+		}
+	}
+}
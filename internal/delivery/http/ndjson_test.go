@@ -0,0 +1,111 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadLine(t *testing.T) {
+	br := bufio.NewReaderSize(strings.NewReader("first\r\n"+strings.Repeat("x", 64)+"\nlast"), 16)
+
+	line, err := readLine(br, 32)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", string(line))
+
+	line, err = readLine(br, 32)
+	assert.ErrorIs(t, err, errLineTooLong)
+	assert.Nil(t, line)
+
+	line, err = readLine(br, 32)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, "last", string(line))
+}
+
+func TestBatchStatus(t *testing.T) {
+	results := make([]models.BatchItemResult, 2)
+
+	tests := []struct {
+		name string
+		resp models.BatchResponse
+		want int
+	}{
+		{"all stored", models.BatchResponse{Accepted: 2, Results: results}, http.StatusCreated},
+		{"queued", models.BatchResponse{Accepted: 2, Queued: 2, Results: results}, http.StatusAccepted},
+		{"some failed", models.BatchResponse{Accepted: 1, Failed: 1, Results: results}, http.StatusMultiStatus},
+		{"truncated", models.BatchResponse{Accepted: 2, Truncated: true, Results: results}, http.StatusMultiStatus},
+		{"all failed", models.BatchResponse{Failed: 2, Results: results}, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, batchStatus(&tt.resp))
+		})
+	}
+}
+
+func TestCreateEvent_NDJSONLineLimit(t *testing.T) {
+	mockUC := new(MockEventUsecase)
+	h := NewHandler(mockUC, nil, nil, nil, nil, nil)
+
+	chunkResp := &models.BatchResponse{Accepted: ndjsonChunkSize, Results: make([]models.BatchItemResult, ndjsonChunkSize)}
+	for i := range chunkResp.Results {
+		chunkResp.Results[i].Status = models.BatchStatusCreated
+	}
+	mockUC.On("CreateEvents", mock.Anything, mock.AnythingOfType("[]models.CreateEventRequest")).Return(chunkResp, nil)
+
+	body := strings.Repeat("{\"event_name\":\"app_launch\"}\n", maxNDJSONLines+1)
+	req := httptest.NewRequest(http.MethodPost, "/events/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rec := httptest.NewRecorder()
+
+	h.CreateEvents(rec, req)
+
+	assert.Equal(t, http.StatusMultiStatus, rec.Code)
+
+	var resp struct {
+		Data models.BatchResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.True(t, resp.Data.Truncated)
+	assert.Len(t, resp.Data.Results, maxNDJSONLines)
+	mockUC.AssertNumberOfCalls(t, "CreateEvents", maxNDJSONLines/ndjsonChunkSize)
+}
+
+func TestDecompressRequest_TooLarge(t *testing.T) {
+	h := NewHandler(new(MockEventUsecase), nil, nil, nil, nil, nil)
+
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+	zw.Write(bytes.Repeat([]byte(" "), maxRequestBodySize+1))
+	zw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/events", &buf)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	h.DecompressRequest(http.HandlerFunc(h.CreateEvent)).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	// Plain bodies are capped the same way
+	req = httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(bytes.Repeat([]byte(" "), maxRequestBodySize+1)))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+
+	h.DecompressRequest(http.HandlerFunc(h.CreateEvent)).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
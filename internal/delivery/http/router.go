@@ -3,9 +3,22 @@ package http
 import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
 )
 
-func NewRouter(h *Handler) *chi.Mux {
+// RouterConfig holds the per route group settings.
+type RouterConfig struct {
+	// AuthRateLimit applies per client IP to every route needing an API
+	// key, before the key is looked up, so floods of unauthenticated
+	// requests never reach the database. Its Key is ignored.
+	AuthRateLimit RateLimit
+	// EventsRateLimit applies to /events, shared by ingest and reads.
+	EventsRateLimit RateLimit
+	// AnalyticsRateLimit applies to /analytics, /catalog and /users.
+	AnalyticsRateLimit RateLimit
+}
+
+func NewRouter(h *Handler, cfg RouterConfig) *chi.Mux {
 	r := chi.NewRouter()
 
 	// Middleware
@@ -17,15 +30,103 @@ func NewRouter(h *Handler) *chi.Mux {
 	// Routes
 	r.Get("/health", h.Health)
 
-	r.Route("/events", func(r chi.Router) {
-		r.Post("/", h.CreateEvent)
-		r.Get("/", h.ListEvents)
-		r.Get("/{id}", h.GetEvent)
+	authCfg := cfg.AuthRateLimit
+	authCfg.Key = RateLimitByIP
+	authLimit := h.RateLimit(authCfg)
+
+	r.With(authLimit).Route("/events", func(r chi.Router) {
+		eventsLimit := h.RateLimit(cfg.EventsRateLimit)
+
+		r.Group(func(r chi.Router) {
+			r.Use(h.RequireScope(models.ScopeIngest))
+			r.Use(eventsLimit)
+			r.Use(h.VerifySignature)
+			r.Use(h.DecompressRequest)
+
+			r.Post("/", h.CreateEvent)
+			r.Post("/batch", h.CreateEvents)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(h.RequireScope(models.ScopeRead))
+			r.Use(eventsLimit)
+
+			r.Get("/", h.ListEvents)
+			r.Get("/{id}", h.GetEvent)
+		})
 	})
 
-	r.Route("/analytics", func(r chi.Router) {
+	// The catalog and per user views run analytics queries as well and
+	// share their limit
+	analyticsLimit := h.RateLimit(cfg.AnalyticsRateLimit)
+
+	r.With(authLimit).Route("/analytics", func(r chi.Router) {
+		r.Use(h.RequireScope(models.ScopeRead))
+		r.Use(analyticsLimit)
+
 		r.Get("/hourly", h.GetHourlyStats)
 		r.Get("/daily", h.GetDailyStats)
+		r.Get("/breakdown", h.GetBreakdown)
+		r.Get("/timeseries", h.GetTimeseries)
+		r.Get("/active-users", h.GetActiveUsers)
+		r.Get("/unique-users", h.GetUniqueUsers)
+		r.Get("/retention", h.GetRetention)
+		r.Get("/funnel", h.GetFunnel)
+		r.Get("/metric", h.GetMetric)
+		r.Get("/sessions", h.GetSessionStats)
+	})
+
+	r.With(authLimit).Route("/catalog", func(r chi.Router) {
+		r.Use(h.RequireScope(models.ScopeRead))
+		r.Use(analyticsLimit)
+
+		r.Get("/event-names", h.ListEventNames)
+		r.Get("/event-names/{event_name}", h.DescribePayload)
+	})
+
+	r.With(authLimit).Route("/users", func(r chi.Router) {
+		r.Use(h.RequireScope(models.ScopeRead))
+		r.Use(analyticsLimit)
+
+		r.Get("/{user_id}/events", h.ListUserEvents)
+		r.Get("/{user_id}/sessions", h.ListUserSessions)
+	})
+
+	r.With(authLimit).Route("/schemas", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(h.RequireScope(models.ScopeRead))
+
+			r.Get("/", h.ListSchemas)
+			r.Get("/{event_name}", h.ListSchemas)
+			r.Get("/{event_name}/{version}", h.GetSchema)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(h.RequireScope(models.ScopeAdmin))
+
+			r.Post("/", h.CreateSchema)
+			r.Delete("/{event_name}/{version}", h.DeactivateSchema)
+		})
+	})
+
+	r.With(authLimit).Route("/admin", func(r chi.Router) {
+		r.Use(h.RequireScope(models.ScopeAdmin))
+
+		r.Get("/ingest", h.GetIngestStats)
+
+		r.Get("/quarantine", h.ListQuarantine)
+		r.Post("/quarantine/reprocess", h.ReprocessQuarantine)
+		r.Get("/quarantine/{id}", h.GetQuarantined)
+		r.Post("/quarantine/{id}/reprocess", h.ReprocessQuarantined)
+
+		r.Post("/keys", h.CreateAPIKey)
+		r.Get("/keys", h.ListAPIKeys)
+		r.Post("/keys/{id}/rotate", h.RotateAPIKey)
+		r.Delete("/keys/{id}", h.RevokeAPIKey)
+
+		r.Post("/signing-secrets", h.CreateSigningSecret)
+		r.Get("/signing-secrets", h.ListSigningSecrets)
+		r.Delete("/signing-secrets/{id}", h.RevokeSigningSecret)
 	})
 
 	return r
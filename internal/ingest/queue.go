@@ -0,0 +1,228 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+var (
+	ErrQueueFull   = errors.New("ingest queue is full")
+	ErrQueueClosed = errors.New("ingest queue is closed")
+)
+
+const (
+	flushTimeout  = 30 * time.Second
+	flushAttempts = 3
+)
+
+// Writer stores a batch of events. It is implemented by the event
+// repository's COPY path.
+type Writer interface {
+	CopyEvents(ctx context.Context, events []*models.Event) error
+}
+
+type Config struct {
+	// Capacity is the maximum number of events waiting to be flushed.
+	Capacity int
+	// BatchSize flushes as soon as this many events are buffered.
+	BatchSize int
+	// FlushInterval flushes a partial batch once its oldest event has
+	// waited this long.
+	FlushInterval time.Duration
+	// Fallback, if set, receives batches that could not be written after
+	// all retries instead of dropping them.
+	Fallback func(events []*models.Event) error
+}
+
+func (c Config) withDefaults() Config {
+	if c.Capacity <= 0 {
+		c.Capacity = 10000
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 1000
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = time.Second
+	}
+	return c
+}
+
+// Queue buffers events in memory and writes them in batches from a single
+// background goroutine.
+type Queue struct {
+	writer Writer
+	cfg    Config
+	events chan *models.Event
+
+	// mu serialises enqueuers so multi-event enqueues are all-or-nothing
+	// and no send happens after close.
+	mu     sync.Mutex
+	closed bool
+
+	stop    chan struct{}
+	stopped chan struct{}
+
+	flushed   atomic.Int64
+	dropped   atomic.Int64
+	lastFlush atomic.Pointer[time.Time]
+}
+
+func NewQueue(w Writer, cfg Config) *Queue {
+	cfg = cfg.withDefaults()
+	return &Queue{
+		writer:  w,
+		cfg:     cfg,
+		events:  make(chan *models.Event, cfg.Capacity),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+// Start launches the flushing goroutine.
+func (q *Queue) Start() {
+	go q.run()
+}
+
+// Enqueue adds events to the queue. Either all events are queued or none
+// are, in which case ErrQueueFull or ErrQueueClosed is returned.
+func (q *Queue) Enqueue(events ...*models.Event) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+	// Only the flusher removes events, so free space cannot shrink here
+	if cap(q.events)-len(q.events) < len(events) {
+		return ErrQueueFull
+	}
+
+	for _, event := range events {
+		q.events <- event
+	}
+	return nil
+}
+
+// Depth returns the number of events waiting in the queue.
+func (q *Queue) Depth() int {
+	return len(q.events)
+}
+
+func (q *Queue) Stats() models.QueueStats {
+	return models.QueueStats{
+		Depth:         q.Depth(),
+		Capacity:      q.cfg.Capacity,
+		BatchSize:     q.cfg.BatchSize,
+		FlushInterval: q.cfg.FlushInterval.String(),
+		Flushed:       q.flushed.Load(),
+		Dropped:       q.dropped.Load(),
+		LastFlushAt:   q.lastFlush.Load(),
+	}
+}
+
+// Close stops accepting events and waits until everything already queued
+// has been flushed or ctx expires.
+func (q *Queue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.stop)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) run() {
+	defer close(q.stopped)
+
+	batch := make([]*models.Event, 0, q.cfg.BatchSize)
+	timer := time.NewTimer(q.cfg.FlushInterval)
+	timer.Stop()
+
+	for {
+		select {
+		case event := <-q.events:
+			if len(batch) == 0 {
+				timer.Reset(q.cfg.FlushInterval)
+			}
+			batch = append(batch, event)
+			if len(batch) >= q.cfg.BatchSize {
+				timer.Stop()
+				q.flush(batch)
+				batch = batch[:0]
+			}
+		case <-timer.C:
+			q.flush(batch)
+			batch = batch[:0]
+		case <-q.stop:
+			timer.Stop()
+			q.drain(batch)
+			return
+		}
+	}
+}
+
+// drain flushes the pending batch and everything left in the channel. No
+// enqueues can happen once stop is closed.
+func (q *Queue) drain(batch []*models.Event) {
+	for {
+		select {
+		case event := <-q.events:
+			batch = append(batch, event)
+			if len(batch) >= q.cfg.BatchSize {
+				q.flush(batch)
+				batch = batch[:0]
+			}
+		default:
+			q.flush(batch)
+			return
+		}
+	}
+}
+
+func (q *Queue) flush(batch []*models.Event) {
+	if len(batch) == 0 {
+		return
+	}
+
+	var err error
+	for attempt := 1; attempt <= flushAttempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+		err = q.writer.CopyEvents(ctx, batch)
+		cancel()
+		if err == nil {
+			now := time.Now().UTC()
+			q.flushed.Add(int64(len(batch)))
+			q.lastFlush.Store(&now)
+			return
+		}
+		log.Printf("ingest.flush: attempt %d/%d to write %d events failed: %v", attempt, flushAttempts, len(batch), err)
+		if attempt < flushAttempts {
+			time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+		}
+	}
+
+	if q.cfg.Fallback != nil {
+		fallbackErr := q.cfg.Fallback(batch)
+		if fallbackErr == nil {
+			log.Printf("ingest.flush: handed %d events to fallback: %v", len(batch), err)
+			return
+		}
+		log.Printf("ingest.flush: fallback for %d events failed: %v", len(batch), fallbackErr)
+	}
+
+	q.dropped.Add(int64(len(batch)))
+	log.Printf("ingest.flush: dropped %d events: %v", len(batch), err)
+}
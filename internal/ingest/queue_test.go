@@ -0,0 +1,125 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeWriter records every batch it receives
+type fakeWriter struct {
+	mu      sync.Mutex
+	batches [][]string
+	err     error
+}
+
+func (w *fakeWriter) CopyEvents(ctx context.Context, events []*models.Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = e.EventName
+	}
+	w.batches = append(w.batches, names)
+	return nil
+}
+
+func (w *fakeWriter) Batches() [][]string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([][]string(nil), w.batches...)
+}
+
+func event(name string) *models.Event {
+	return &models.Event{EventName: name}
+}
+
+func TestQueue_FlushesFullBatch(t *testing.T) {
+	w := &fakeWriter{}
+	q := NewQueue(w, Config{Capacity: 10, BatchSize: 2, FlushInterval: time.Hour})
+	q.Start()
+
+	assert.NoError(t, q.Enqueue(event("a"), event("b"), event("c")))
+
+	assert.Eventually(t, func() bool { return len(w.Batches()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"a", "b"}, w.Batches()[0])
+
+	assert.NoError(t, q.Close(context.Background()))
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, w.Batches())
+}
+
+func TestQueue_FlushesByAge(t *testing.T) {
+	w := &fakeWriter{}
+	q := NewQueue(w, Config{Capacity: 10, BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	q.Start()
+	defer q.Close(context.Background())
+
+	assert.NoError(t, q.Enqueue(event("a")))
+
+	assert.Eventually(t, func() bool { return len(w.Batches()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int64(1), q.Stats().Flushed)
+	assert.NotNil(t, q.Stats().LastFlushAt)
+}
+
+func TestQueue_Full(t *testing.T) {
+	q := NewQueue(&fakeWriter{}, Config{Capacity: 2})
+
+	assert.NoError(t, q.Enqueue(event("a")))
+	assert.ErrorIs(t, q.Enqueue(event("b"), event("c")), ErrQueueFull)
+	// A rejected multi-event enqueue must not be partially applied
+	assert.Equal(t, 1, q.Depth())
+}
+
+func TestQueue_CloseDrainsAndRejects(t *testing.T) {
+	w := &fakeWriter{}
+	q := NewQueue(w, Config{Capacity: 10, BatchSize: 100, FlushInterval: time.Hour})
+	q.Start()
+
+	assert.NoError(t, q.Enqueue(event("a"), event("b")))
+	assert.NoError(t, q.Close(context.Background()))
+
+	assert.Equal(t, [][]string{{"a", "b"}}, w.Batches())
+	assert.Equal(t, 0, q.Depth())
+	assert.ErrorIs(t, q.Enqueue(event("c")), ErrQueueClosed)
+}
+
+func TestQueue_CountsDroppedEvents(t *testing.T) {
+	w := &fakeWriter{err: errors.New("db down")}
+	q := NewQueue(w, Config{Capacity: 10, BatchSize: 100, FlushInterval: time.Hour})
+	q.Start()
+
+	assert.NoError(t, q.Enqueue(event("a")))
+	assert.NoError(t, q.Close(context.Background()))
+
+	assert.Equal(t, int64(1), q.Stats().Dropped)
+	assert.Equal(t, int64(0), q.Stats().Flushed)
+}
+
+func TestQueue_FallbackOnFailure(t *testing.T) {
+	w := &fakeWriter{err: errors.New("db down")}
+	var spilled []*models.Event
+	q := NewQueue(w, Config{
+		Capacity:      10,
+		BatchSize:     100,
+		FlushInterval: time.Hour,
+		Fallback: func(events []*models.Event) error {
+			spilled = append(spilled, events...)
+			return nil
+		},
+	})
+	q.Start()
+
+	assert.NoError(t, q.Enqueue(event("a")))
+	assert.NoError(t, q.Close(context.Background()))
+
+	assert.Len(t, spilled, 1)
+	assert.Equal(t, int64(0), q.Stats().Dropped)
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock lets tests move time forward by hand
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.t }
+
+func newTestLimiter(rate float64, burst int) (*Limiter, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	l := New(rate, burst)
+	l.now = clock.Now
+	l.lastSweep = clock.t
+	return l, clock
+}
+
+func TestLimiter_Burst(t *testing.T) {
+	l, clock := newTestLimiter(2, 3)
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("a")
+		assert.True(t, ok, "request %d", i)
+	}
+
+	ok, wait := l.Allow("a")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// Other keys have their own bucket
+	ok, _ = l.Allow("b")
+	assert.True(t, ok)
+
+	clock.t = clock.t.Add(500 * time.Millisecond)
+	ok, _ = l.Allow("a")
+	assert.True(t, ok)
+	ok, _ = l.Allow("a")
+	assert.False(t, ok)
+}
+
+func TestLimiter_RefillIsCappedAtBurst(t *testing.T) {
+	l, clock := newTestLimiter(10, 2)
+
+	l.Allow("a")
+	clock.t = clock.t.Add(time.Hour)
+
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if ok, _ := l.Allow("a"); ok {
+			allowed++
+		}
+	}
+	assert.Equal(t, 2, allowed)
+}
+
+func TestLimiter_SweepsIdleBuckets(t *testing.T) {
+	l, clock := newTestLimiter(1, 1)
+
+	l.Allow("a")
+	l.Allow("b")
+	assert.Equal(t, 2, l.Len())
+
+	clock.t = clock.t.Add(sweepInterval)
+	l.Allow("c")
+	assert.Equal(t, 1, l.Len())
+}
@@ -2,23 +2,53 @@ package repo
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// EventStats is one bucket of an event's counts. UniqueUsers is estimated
+// from the bucket's HyperLogLog sketch, except for daily stats in zones
+// without an aggregate of their own.
 type EventStats struct {
 	Bucket      time.Time `json:"bucket"`
 	EventName   string    `json:"event_name"`
 	EventCount  int64     `json:"event_count"`
 	UniqueUsers int64     `json:"unique_users"`
+	// UniqueUsersError is the relative standard error of UniqueUsers, 0
+	// when it is an exact count rather than a HyperLogLog estimate.
+	UniqueUsersError float64 `json:"unique_users_error"`
+	// Partial marks the bucket that has not ended yet; its counts are
+	// computed live and still growing.
+	Partial bool `json:"partial,omitempty"`
+	// Compare is set when the stats are compared with an earlier period.
+	Compare *models.Comparison `json:"compare,omitempty"`
 }
 
+var (
+	hourInterval = models.Interval{Count: 1, Unit: "h"}
+	dayInterval  = models.Interval{Count: 1, Unit: "d"}
+)
+
 type AnalyticsRepository interface {
 	GetHourlyStats(ctx context.Context, eventName string, from, to time.Time) ([]EventStats, error)
-	GetDailyStats(ctx context.Context, eventName string, from, to time.Time) ([]EventStats, error)
+	GetDailyStats(ctx context.Context, eventName string, from, to time.Time, loc *time.Location) ([]EventStats, error)
+	ListEventNames(ctx context.Context, from, to time.Time) ([]models.EventNameSummary, error)
+	SamplePayloads(ctx context.Context, eventName string, from, to time.Time, limit int) ([]map[string]interface{}, error)
+	GetBreakdown(ctx context.Context, q models.BreakdownQuery) ([]models.BreakdownRow, error)
+	GetTimeseries(ctx context.Context, q models.TimeseriesQuery, source string) ([]models.TimeseriesPoint, error)
+	GetActiveUsers(ctx context.Context, from, to time.Time) ([]models.ActiveUsers, error)
+	GetUniqueUsers(ctx context.Context, eventName string, from, to time.Time) (int64, error)
+	GetRetention(ctx context.Context, q models.RetentionQuery) ([]models.RetentionCohort, error)
+	GetFunnel(ctx context.Context, q models.FunnelQuery) ([]models.FunnelGroup, error)
+	GetMetric(ctx context.Context, q models.MetricQuery) ([]models.MetricPoint, error)
+	GetHistogram(ctx context.Context, q models.HistogramQuery) (*models.HistogramResponse, error)
+	GetSessionStats(ctx context.Context, q models.SessionQuery) ([]models.SessionStats, error)
 }
 
 type analyticsRepo struct {
@@ -31,7 +61,7 @@ func NewAnalyticsRepository(db *pgxpool.Pool) AnalyticsRepository {
 
 func (r *analyticsRepo) GetHourlyStats(ctx context.Context, eventName string, from, to time.Time) ([]EventStats, error) {
 	query := `
-		SELECT bucket, event_name, event_count, unique_users
+		SELECT bucket, event_name, event_count, distinct_count(users_hll)
 		FROM events_hourly
 		WHERE bucket >= $1 AND bucket <= $2
 	`
@@ -44,6 +74,7 @@ func (r *analyticsRepo) GetHourlyStats(ctx context.Context, eventName string, fr
 
 	query += " ORDER BY bucket DESC"
 
+	now := time.Now()
 	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		log.Printf("repo.GetHourlyStats: query hourly stats: %v", err)
@@ -58,30 +89,64 @@ func (r *analyticsRepo) GetHourlyStats(ctx context.Context, eventName string, fr
 			log.Printf("repo.GetHourlyStats: scan hourly stats: %v", err)
 			return nil, fmt.Errorf("scan hourly stats: %w", err)
 		}
+		s.UniqueUsersError = models.HLLStdError
+		s.Partial = partialBucket(s.Bucket, hourInterval, nil, now)
 		stats = append(stats, s)
 	}
 
 	return stats, nil
 }
 
-func (r *analyticsRepo) GetDailyStats(ctx context.Context, eventName string, from, to time.Time) ([]EventStats, error) {
-	query := `
-		SELECT bucket, event_name, event_count, unique_users
+// dailyStatsQueries select the daily stats of the zones that have a
+// continuous aggregate.
+var dailyStatsQueries = map[string]string{
+	"UTC": `
+		SELECT bucket, event_name, event_count, distinct_count(users_hll)
 		FROM events_daily
-		WHERE bucket >= $1 AND bucket <= $2
-	`
-	args := []interface{}{from, to}
+		WHERE bucket >= $1 AND bucket <= $2 AND ($3 = '' OR event_name = $3)
+		ORDER BY bucket DESC
+	`,
+	models.JakartaTimezone: `
+		SELECT bucket, event_name, event_count, distinct_count(users_hll)
+		FROM events_daily_jakarta
+		WHERE bucket >= $1 AND bucket <= $2 AND ($3 = '' OR event_name = $3)
+		ORDER BY bucket DESC
+	`,
+}
 
-	if eventName != "" {
-		query += " AND event_name = $3"
-		args = append(args, eventName)
-	}
+// dailyStatsFromEvents buckets raw events on the local midnight of any
+// other zone.
+const dailyStatsFromEvents = `
+	SELECT time_bucket('1 day'::interval, timestamp, $4::text) AS bucket,
+	       event_name,
+	       COUNT(*),
+	       COUNT(DISTINCT payload->>'user_id')
+	FROM events
+	WHERE timestamp >= $1 AND timestamp <= $2 AND ($3 = '' OR event_name = $3)
+	GROUP BY bucket, event_name
+	ORDER BY bucket DESC
+`
 
-	query += " ORDER BY bucket DESC"
+// GetDailyStats returns per day stats with days starting on the midnight of
+// loc, nil meaning UTC.
+func (r *analyticsRepo) GetDailyStats(ctx context.Context, eventName string, from, to time.Time, loc *time.Location) ([]EventStats, error) {
+	tz := models.TimezoneName(loc)
+	args := []interface{}{from, to, eventName}
+
+	query, ok := dailyStatsQueries[tz]
+	if !ok {
+		query = dailyStatsFromEvents
+		args = append(args, tz)
+	}
+	var stdError float64
+	if ok {
+		stdError = models.HLLStdError
+	}
 
+	now := time.Now()
 	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
-		log.Printf("repo.GetDailyStats: query daily stats: %v", err)
+		log.Printf("repo.GetDailyStats: query daily stats in %s: %v", tz, err)
 		return nil, fmt.Errorf("query daily stats: %w", err)
 	}
 	defer rows.Close()
@@ -93,8 +158,695 @@ func (r *analyticsRepo) GetDailyStats(ctx context.Context, eventName string, fro
 			log.Printf("repo.GetDailyStats: scan daily stats: %v", err)
 			return nil, fmt.Errorf("scan daily stats: %w", err)
 		}
+		s.UniqueUsersError = stdError
+		s.Partial = partialBucket(s.Bucket, dayInterval, loc, now)
 		stats = append(stats, s)
 	}
 
 	return stats, nil
 }
+
+// ListEventNames summarises every event name. Zero from or to leave that
+// side of the range open; with neither set the summary is read from the
+// daily aggregate instead of scanning every event, so first and last seen
+// are the day buckets the name appeared in.
+func (r *analyticsRepo) ListEventNames(ctx context.Context, from, to time.Time) ([]models.EventNameSummary, error) {
+	query := `
+		SELECT event_name, MIN(timestamp), MAX(timestamp), COUNT(*)
+		FROM events
+		WHERE ($1::timestamptz IS NULL OR timestamp >= $1)
+		  AND ($2::timestamptz IS NULL OR timestamp <= $2)
+		GROUP BY event_name
+		ORDER BY event_name
+	`
+	args := []interface{}{nullTime(from), nullTime(to)}
+
+	if from.IsZero() && to.IsZero() {
+		query = `
+			SELECT event_name, MIN(bucket), MAX(bucket), SUM(event_count)::bigint
+			FROM events_daily
+			GROUP BY event_name
+			ORDER BY event_name
+		`
+		args = nil
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("repo.ListEventNames: query event names: %v", err)
+		return nil, fmt.Errorf("query event names: %w", err)
+	}
+	defer rows.Close()
+
+	names := []models.EventNameSummary{}
+	for rows.Next() {
+		var n models.EventNameSummary
+		if err := rows.Scan(&n.EventName, &n.FirstSeen, &n.LastSeen, &n.Count); err != nil {
+			log.Printf("repo.ListEventNames: scan event name: %v", err)
+			return nil, fmt.Errorf("scan event name: %w", err)
+		}
+		names = append(names, n)
+	}
+
+	return names, nil
+}
+
+// SamplePayloads returns the payloads of the latest limit events named
+// eventName within the range.
+func (r *analyticsRepo) SamplePayloads(ctx context.Context, eventName string, from, to time.Time, limit int) ([]map[string]interface{}, error) {
+	query := `
+		SELECT payload
+		FROM events
+		WHERE event_name = $1
+		  AND ($2::timestamptz IS NULL OR timestamp >= $2)
+		  AND ($3::timestamptz IS NULL OR timestamp <= $3)
+		ORDER BY timestamp DESC
+		LIMIT $4
+	`
+
+	rows, err := r.db.Query(ctx, query, eventName, nullTime(from), nullTime(to), limit)
+	if err != nil {
+		log.Printf("repo.SamplePayloads: query payloads: %v", err)
+		return nil, fmt.Errorf("query payloads: %w", err)
+	}
+	defer rows.Close()
+
+	var payloads []map[string]interface{}
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&raw); err != nil {
+			log.Printf("repo.SamplePayloads: scan payload: %v", err)
+			return nil, fmt.Errorf("scan payload: %w", err)
+		}
+
+		var payload map[string]interface{}
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &payload); err != nil {
+				log.Printf("repo.SamplePayloads: unmarshal payload: %v", err)
+				return nil, fmt.Errorf("unmarshal payload: %w", err)
+			}
+		}
+		payloads = append(payloads, payload)
+	}
+
+	return payloads, nil
+}
+
+// GetBreakdown counts events and unique users per bucket and payload value.
+// Values outside the q.Top most frequent ones over the whole range are
+// merged into an "other" row per bucket.
+func (r *analyticsRepo) GetBreakdown(ctx context.Context, q models.BreakdownQuery) ([]models.BreakdownRow, error) {
+	query := `
+		WITH base AS (
+			SELECT time_bucket($1::interval, timestamp, $7::text) AS bucket,
+			       payload #>> $2 AS value,
+			       payload->>'user_id' AS user_id
+			FROM events
+			WHERE event_name = $3 AND timestamp >= $4 AND timestamp < $5
+		),
+		top AS (
+			SELECT value FROM base GROUP BY value ORDER BY COUNT(*) DESC, value LIMIT $6
+		),
+		ranked AS (
+			SELECT base.*, EXISTS (SELECT 1 FROM top WHERE top.value IS NOT DISTINCT FROM base.value) AS in_top
+			FROM base
+		)
+		SELECT bucket,
+		       CASE WHEN in_top THEN value END,
+		       NOT in_top,
+		       COUNT(*),
+		       COUNT(DISTINCT user_id)
+		FROM ranked
+		GROUP BY bucket, 2, 3
+		ORDER BY bucket, 4 DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, q.Interval.SQL(), q.Path, q.EventName, q.From, q.To, q.Top, models.TimezoneName(q.Location))
+	if err != nil {
+		log.Printf("repo.GetBreakdown: query breakdown: %v", err)
+		return nil, fmt.Errorf("query breakdown: %w", err)
+	}
+	defer rows.Close()
+
+	result := []models.BreakdownRow{}
+	for rows.Next() {
+		var row models.BreakdownRow
+		if err := rows.Scan(&row.Bucket, &row.Value, &row.Other, &row.EventCount, &row.UniqueUsers); err != nil {
+			log.Printf("repo.GetBreakdown: scan breakdown: %v", err)
+			return nil, fmt.Errorf("scan breakdown: %w", err)
+		}
+		result = append(result, row)
+	}
+
+	return result, nil
+}
+
+// timeseriesQueries compute a gap-filled series from each source. The
+// aggregates are rolled up by adding their counts and merging their unique
+// user sketches.
+// Buckets start on the local midnight of the timezone in $5; the daily
+// aggregates must only be used with their own zone.
+var timeseriesQueries = map[string]string{
+	models.SourceEvents: `
+		SELECT time_bucket_gapfill($1::interval, timestamp, $5::text, $2, $3) AS b,
+		       COUNT(*),
+		       COUNT(DISTINCT payload->>'user_id')
+		FROM events
+		WHERE timestamp >= $2 AND timestamp < $3 AND ($4 = '' OR event_name = $4)
+		GROUP BY b
+		ORDER BY b
+	`,
+	models.SourceHourly: `
+		SELECT time_bucket_gapfill($1::interval, bucket, $5::text, $2, $3) AS b,
+		       SUM(event_count)::bigint,
+		       distinct_count(rollup(users_hll))
+		FROM events_hourly
+		WHERE bucket >= $2 AND bucket < $3 AND ($4 = '' OR event_name = $4)
+		GROUP BY b
+		ORDER BY b
+	`,
+	models.SourceDaily: `
+		SELECT time_bucket_gapfill($1::interval, bucket, $5::text, $2, $3) AS b,
+		       SUM(event_count)::bigint,
+		       distinct_count(rollup(users_hll))
+		FROM events_daily
+		WHERE bucket >= $2 AND bucket < $3 AND ($4 = '' OR event_name = $4)
+		GROUP BY b
+		ORDER BY b
+	`,
+	models.SourceDailyJakarta: `
+		SELECT time_bucket_gapfill($1::interval, bucket, $5::text, $2, $3) AS b,
+		       SUM(event_count)::bigint,
+		       distinct_count(rollup(users_hll))
+		FROM events_daily_jakarta
+		WHERE bucket >= $2 AND bucket < $3 AND ($4 = '' OR event_name = $4)
+		GROUP BY b
+		ORDER BY b
+	`,
+}
+
+// GetTimeseries returns one point per bucket of q.Interval between q.From
+// and q.To, including empty buckets, computed from source.
+func (r *analyticsRepo) GetTimeseries(ctx context.Context, q models.TimeseriesQuery, source string) ([]models.TimeseriesPoint, error) {
+	query, ok := timeseriesQueries[source]
+	if !ok {
+		return nil, fmt.Errorf("unknown timeseries source %q", source)
+	}
+
+	now := time.Now()
+	rows, err := r.db.Query(ctx, query, q.Interval.SQL(), q.From, q.To, q.EventName, models.TimezoneName(q.Location))
+	if err != nil {
+		log.Printf("repo.GetTimeseries: query %s: %v", source, err)
+		return nil, fmt.Errorf("query timeseries: %w", err)
+	}
+	defer rows.Close()
+
+	points := []models.TimeseriesPoint{}
+	for rows.Next() {
+		var p models.TimeseriesPoint
+		var count, users *int64
+		if err := rows.Scan(&p.Bucket, &count, &users); err != nil {
+			log.Printf("repo.GetTimeseries: scan point: %v", err)
+			return nil, fmt.Errorf("scan timeseries: %w", err)
+		}
+
+		// Gap-filled buckets come back as NULL
+		if count != nil {
+			p.EventCount = *count
+		}
+		if users != nil {
+			p.UniqueUsers = *users
+		}
+		p.Partial = partialBucket(p.Bucket, q.Interval, q.Location, now)
+		points = append(points, p)
+	}
+
+	return points, nil
+}
+
+// GetActiveUsers counts the installs active on each UTC day from from up to
+// but excluding to, and in the 7 and 28 days ending with it. from and to
+// must be UTC midnights.
+func (r *analyticsRepo) GetActiveUsers(ctx context.Context, from, to time.Time) ([]models.ActiveUsers, error) {
+	query := `
+		SELECT d.day,
+		       COUNT(DISTINCT a.user_id) FILTER (WHERE a.day = d.day),
+		       COUNT(DISTINCT a.user_id) FILTER (WHERE a.day > d.day - INTERVAL '7 days'),
+		       COUNT(DISTINCT a.user_id)
+		FROM generate_series($1::timestamptz, $2::timestamptz - INTERVAL '1 day', INTERVAL '1 day') AS d(day)
+		LEFT JOIN daily_active_users a
+		       ON a.day > d.day - INTERVAL '28 days' AND a.day <= d.day
+		GROUP BY d.day
+		ORDER BY d.day
+	`
+
+	now := time.Now()
+	rows, err := r.db.Query(ctx, query, from, to)
+	if err != nil {
+		log.Printf("repo.GetActiveUsers: query active users: %v", err)
+		return nil, fmt.Errorf("query active users: %w", err)
+	}
+	defer rows.Close()
+
+	days := []models.ActiveUsers{}
+	for rows.Next() {
+		var a models.ActiveUsers
+		if err := rows.Scan(&a.Day, &a.DAU, &a.WAU, &a.MAU); err != nil {
+			log.Printf("repo.GetActiveUsers: scan active users: %v", err)
+			return nil, fmt.Errorf("scan active users: %w", err)
+		}
+		a.Partial = partialBucket(a.Day, dayInterval, nil, now)
+		days = append(days, a)
+	}
+
+	return days, nil
+}
+
+// GetUniqueUsers estimates the distinct users of the hourly buckets from
+// from up to but excluding to by merging their sketches. An empty eventName
+// counts users across all events.
+func (r *analyticsRepo) GetUniqueUsers(ctx context.Context, eventName string, from, to time.Time) (int64, error) {
+	query := `
+		SELECT COALESCE(distinct_count(rollup(users_hll)), 0)
+		FROM events_hourly
+		WHERE bucket >= $1 AND bucket < $2 AND ($3 = '' OR event_name = $3)
+	`
+
+	var users int64
+	if err := r.db.QueryRow(ctx, query, from, to, eventName).Scan(&users); err != nil {
+		log.Printf("repo.GetUniqueUsers: query unique users: %v", err)
+		return 0, fmt.Errorf("query unique users: %w", err)
+	}
+
+	return users, nil
+}
+
+// GetRetention returns the cohort matrix of q, with Retained filled for
+// every period; cohorts without installs are left out. Only start events in
+// the range are aggregated; an install is dropped from the cohorts when it
+// has an earlier start event.
+func (r *analyticsRepo) GetRetention(ctx context.Context, q models.RetentionQuery) ([]models.RetentionCohort, error) {
+	query := `
+		WITH firsts AS (
+			SELECT payload->>'user_id' AS user_id, MIN(timestamp) AS first_seen
+			FROM events
+			WHERE event_name = ANY($1) AND timestamp >= $4 AND timestamp < $5
+			  AND payload->>'user_id' IS NOT NULL
+			GROUP BY 1
+		),
+		cohorts AS (
+			SELECT f.user_id, time_bucket($3::interval, f.first_seen) AS cohort
+			FROM firsts f
+			WHERE NOT EXISTS (
+				SELECT 1 FROM events e
+				WHERE e.event_name = ANY($1)
+				  AND e.payload->>'user_id' = f.user_id
+				  AND e.timestamp < $4
+			)
+		),
+		returns AS (
+			SELECT DISTINCT c.cohort, c.user_id, p.n
+			FROM events e
+			JOIN cohorts c ON c.user_id = e.payload->>'user_id'
+			JOIN generate_series(0, $6::int) AS p(n)
+			  ON e.timestamp >= c.cohort + $3::interval * p.n
+			 AND e.timestamp < c.cohort + $3::interval * (p.n + 1)
+			WHERE e.event_name = $2
+			  AND e.timestamp >= time_bucket($3::interval, $4::timestamptz)
+			  AND e.timestamp < $5::timestamptz + $3::interval * ($6 + 1)
+		),
+		retained AS (
+			SELECT cohort, n, COUNT(*) AS users
+			FROM returns
+			GROUP BY cohort, n
+		)
+		SELECT c.cohort, c.size, COALESCE(r.n, -1), COALESCE(r.users, 0)
+		FROM (SELECT cohort, COUNT(*) AS size FROM cohorts GROUP BY cohort) c
+		LEFT JOIN retained r ON r.cohort = c.cohort
+		ORDER BY c.cohort, r.n
+	`
+
+	rows, err := r.db.Query(ctx, query, q.StartEvents, q.ReturnEvent, q.Granularity.SQL(), q.From, q.To, q.Periods)
+	if err != nil {
+		log.Printf("repo.GetRetention: query retention: %v", err)
+		return nil, fmt.Errorf("query retention: %w", err)
+	}
+	defer rows.Close()
+
+	cohorts := []models.RetentionCohort{}
+	for rows.Next() {
+		var cohort time.Time
+		var size, users int64
+		var period int
+		if err := rows.Scan(&cohort, &size, &period, &users); err != nil {
+			log.Printf("repo.GetRetention: scan retention: %v", err)
+			return nil, fmt.Errorf("scan retention: %w", err)
+		}
+
+		if n := len(cohorts); n == 0 || !cohorts[n-1].Cohort.Equal(cohort) {
+			retained := make([]*int64, q.Periods+1)
+			for i := range retained {
+				retained[i] = new(int64)
+			}
+			cohorts = append(cohorts, models.RetentionCohort{Cohort: cohort, Size: size, Retained: retained})
+		}
+		// Cohorts nobody returned from come back once with period -1
+		if period >= 0 && period <= q.Periods {
+			*cohorts[len(cohorts)-1].Retained[period] = users
+		}
+	}
+
+	return cohorts, nil
+}
+
+// funnelSQL builds one CTE per step of a funnel with steps steps, each
+// holding the installs that reached it, when they entered (t0), reached it
+// (t) and reached the previous step (prev_t).
+func funnelSQL(steps int, by bool) string {
+	value := "NULL::text"
+	if by {
+		value = "payload #>> $6"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `
+		WITH entered AS (
+			SELECT DISTINCT ON (payload->>'user_id')
+			       payload->>'user_id' AS user_id, timestamp AS t, %s AS value
+			FROM events
+			WHERE event_name = ($1::text[])[1] AND timestamp >= $2 AND timestamp < $3
+			  AND payload->>'user_id' IS NOT NULL
+			ORDER BY payload->>'user_id', timestamp
+		),
+		top AS (
+			SELECT value FROM entered GROUP BY value ORDER BY COUNT(*) DESC, value LIMIT $5
+		),
+		step1 AS (
+			SELECT user_id, t AS t0, t, NULL::timestamptz AS prev_t, in_top,
+			       CASE WHEN in_top THEN value END AS value
+			FROM (
+				SELECT entered.*, EXISTS (SELECT 1 FROM top WHERE top.value IS NOT DISTINCT FROM entered.value) AS in_top
+				FROM entered
+			) ranked
+		)`, value)
+
+	for k := 2; k <= steps; k++ {
+		fmt.Fprintf(&b, `,
+		step%[1]d AS (
+			SELECT p.user_id, p.t0, MIN(e.timestamp) AS t, p.t AS prev_t, p.in_top, p.value
+			FROM step%[2]d p
+			JOIN events e ON e.payload->>'user_id' = p.user_id
+			             AND e.event_name = ($1::text[])[%[1]d]
+			             AND e.timestamp > p.t
+			             AND e.timestamp <= p.t0 + $4::interval
+			GROUP BY p.user_id, p.t0, p.t, p.in_top, p.value
+		)`, k, k-1)
+	}
+
+	for k := 1; k <= steps; k++ {
+		if k > 1 {
+			b.WriteString("\n\t\tUNION ALL")
+		}
+		fmt.Fprintf(&b, `
+		SELECT %[1]d, value, NOT in_top, COUNT(*),
+		       percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM t - prev_t))
+		FROM step%[1]d
+		GROUP BY value, in_top`, k)
+	}
+	b.WriteString("\n\t\tORDER BY 1, 4 DESC\n\t")
+
+	return b.String()
+}
+
+// GetFunnel counts the installs reaching each step of q, per By value.
+// Steps nobody reached are reported with zero users.
+func (r *analyticsRepo) GetFunnel(ctx context.Context, q models.FunnelQuery) ([]models.FunnelGroup, error) {
+	args := []interface{}{q.Steps, q.From, q.To, q.Window.SQL(), q.Top}
+	if len(q.By) > 0 {
+		args = append(args, q.By)
+	}
+
+	rows, err := r.db.Query(ctx, funnelSQL(len(q.Steps), len(q.By) > 0), args...)
+	if err != nil {
+		log.Printf("repo.GetFunnel: query funnel: %v", err)
+		return nil, fmt.Errorf("query funnel: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []models.FunnelGroup{}
+	index := make(map[string]int)
+	for rows.Next() {
+		var step int
+		var value *string
+		var other bool
+		var users int64
+		var median *float64
+		if err := rows.Scan(&step, &value, &other, &users, &median); err != nil {
+			log.Printf("repo.GetFunnel: scan funnel step: %v", err)
+			return nil, fmt.Errorf("scan funnel step: %w", err)
+		}
+
+		key := fmt.Sprint(other)
+		if value != nil {
+			key += ":" + *value
+		}
+		i, ok := index[key]
+		if !ok {
+			// Every group enters at step 1, which is returned first
+			steps := make([]models.FunnelStep, len(q.Steps))
+			for n, event := range q.Steps {
+				steps[n].Event = event
+			}
+			groups = append(groups, models.FunnelGroup{Value: value, Other: other, Steps: steps})
+			i = len(groups) - 1
+			index[key] = i
+		}
+		groups[i].Steps[step-1].Users = users
+		groups[i].Steps[step-1].MedianSecondsToConvert = median
+	}
+
+	return groups, nil
+}
+
+// GetMetric computes q.Aggs of a numeric payload field per bucket. Buckets
+// without events are left out.
+func (r *analyticsRepo) GetMetric(ctx context.Context, q models.MetricQuery) ([]models.MetricPoint, error) {
+	query := `
+		WITH vals AS (
+			SELECT time_bucket($1::interval, timestamp, $2::text) AS bucket,
+			       CASE WHEN jsonb_typeof(payload #> $3) = 'number' THEN (payload #>> $3)::float8 END AS v
+			FROM events
+			WHERE event_name = $4 AND timestamp >= $5 AND timestamp < $6
+		)
+		SELECT bucket, COUNT(v), AVG(v), MIN(v), MAX(v), SUM(v),
+		       percentile_cont($7::float8[]) WITHIN GROUP (ORDER BY v)
+		FROM vals
+		GROUP BY bucket
+		ORDER BY bucket
+	`
+
+	var fractions []float64
+	for _, agg := range q.Aggs {
+		if fraction, ok := models.PercentileFraction(agg); ok {
+			fractions = append(fractions, fraction)
+		}
+	}
+
+	rows, err := r.db.Query(ctx, query, q.Interval.SQL(), models.TimezoneName(q.Location), q.Path, q.EventName, q.From, q.To, fractions)
+	if err != nil {
+		log.Printf("repo.GetMetric: query metric: %v", err)
+		return nil, fmt.Errorf("query metric: %w", err)
+	}
+	defer rows.Close()
+
+	points := []models.MetricPoint{}
+	for rows.Next() {
+		var p models.MetricPoint
+		var avg, lo, hi, sum *float64
+		var percentiles []float64
+		if err := rows.Scan(&p.Bucket, &p.Count, &avg, &lo, &hi, &sum, &percentiles); err != nil {
+			log.Printf("repo.GetMetric: scan metric: %v", err)
+			return nil, fmt.Errorf("scan metric: %w", err)
+		}
+
+		count := float64(p.Count)
+		p.Values = make(map[string]*float64, len(q.Aggs))
+		for _, agg := range q.Aggs {
+			switch agg {
+			case models.MetricCount:
+				p.Values[agg] = &count
+			case models.MetricAvg:
+				p.Values[agg] = avg
+			case models.MetricMin:
+				p.Values[agg] = lo
+			case models.MetricMax:
+				p.Values[agg] = hi
+			case models.MetricSum:
+				p.Values[agg] = sum
+			default:
+				// Percentiles come back in the order they were asked for,
+				// and as NULL when the bucket has no numeric values
+				if len(percentiles) > 0 {
+					p.Values[agg] = &percentiles[0]
+					percentiles = percentiles[1:]
+				} else {
+					p.Values[agg] = nil
+				}
+			}
+		}
+		points = append(points, p)
+	}
+
+	return points, nil
+}
+
+// GetHistogram counts the values of a numeric payload field per bin. Only
+// the bins, bounds and counts of the response are filled in.
+func (r *analyticsRepo) GetHistogram(ctx context.Context, q models.HistogramQuery) (*models.HistogramResponse, error) {
+	values := `
+		SELECT CASE WHEN jsonb_typeof(payload #> $1) = 'number' THEN (payload #>> $1)::float8 END AS v
+		FROM events
+		WHERE event_name = $2 AND timestamp >= $3 AND timestamp < $4
+	`
+
+	var lo, hi *float64
+	if err := r.db.QueryRow(ctx, "SELECT MIN(v), MAX(v) FROM ("+values+") vals", q.Path, q.EventName, q.From, q.To).Scan(&lo, &hi); err != nil {
+		log.Printf("repo.GetHistogram: query bounds: %v", err)
+		return nil, fmt.Errorf("query histogram bounds: %w", err)
+	}
+	if q.Min != nil {
+		lo = q.Min
+	}
+	if q.Max != nil {
+		hi = q.Max
+	}
+
+	hist := &models.HistogramResponse{Bins: []models.HistogramBin{}}
+	if lo == nil || hi == nil {
+		// No numeric values at all
+		return hist, nil
+	}
+	if *hi <= *lo {
+		if q.Min != nil || q.Max != nil {
+			return nil, ErrEmptyHistogramRange
+		}
+		// Every value is the same; give the single value a unit wide range
+		*hi = *lo + 1
+	}
+	hist.Min, hist.Max = *lo, *hi
+
+	query := `
+		SELECT CASE WHEN v = $6 THEN $7 ELSE width_bucket(v, $5, $6, $7) END AS bin, COUNT(*)
+		FROM (` + values + `) vals
+		WHERE v IS NOT NULL
+		GROUP BY bin
+	`
+
+	rows, err := r.db.Query(ctx, query, q.Path, q.EventName, q.From, q.To, hist.Min, hist.Max, q.Bins)
+	if err != nil {
+		log.Printf("repo.GetHistogram: query histogram: %v", err)
+		return nil, fmt.Errorf("query histogram: %w", err)
+	}
+	defer rows.Close()
+
+	width := (hist.Max - hist.Min) / float64(q.Bins)
+	for i := 0; i < q.Bins; i++ {
+		hist.Bins = append(hist.Bins, models.HistogramBin{
+			Lower: hist.Min + float64(i)*width,
+			Upper: hist.Min + float64(i+1)*width,
+		})
+	}
+
+	for rows.Next() {
+		var bin int
+		var count int64
+		if err := rows.Scan(&bin, &count); err != nil {
+			log.Printf("repo.GetHistogram: scan histogram: %v", err)
+			return nil, fmt.Errorf("scan histogram: %w", err)
+		}
+
+		hist.Count += count
+		switch {
+		case bin < 1:
+			hist.Underflow += count
+		case bin > q.Bins:
+			hist.Overflow += count
+		default:
+			hist.Bins[bin-1].Count = count
+		}
+	}
+
+	return hist, nil
+}
+
+// GetSessionStats reconstructs the sessions of every install from its
+// events in the range and summarises them per day of their start. Sessions
+// crossing the range bounds are cut at them.
+func (r *analyticsRepo) GetSessionStats(ctx context.Context, q models.SessionQuery) ([]models.SessionStats, error) {
+	query := `
+		WITH ev AS (
+			SELECT payload->>'user_id' AS user_id, timestamp,
+			       timestamp - LAG(timestamp) OVER (PARTITION BY payload->>'user_id' ORDER BY timestamp) AS since_prev
+			FROM events
+			WHERE timestamp >= $1 AND timestamp < $2 AND payload->>'user_id' IS NOT NULL
+		),
+		numbered AS (
+			SELECT user_id, timestamp,
+			       COUNT(*) FILTER (WHERE since_prev IS NULL OR since_prev >= $3::interval)
+			           OVER (PARTITION BY user_id ORDER BY timestamp) AS session
+			FROM ev
+		),
+		sessions AS (
+			SELECT user_id, MIN(timestamp) AS started, MAX(timestamp) AS ended, COUNT(*) AS events
+			FROM numbered
+			GROUP BY user_id, session
+		)
+		SELECT time_bucket('1 day'::interval, started, $4::text) AS day,
+		       COUNT(*),
+		       COUNT(DISTINCT user_id),
+		       AVG(EXTRACT(EPOCH FROM ended - started))::float8,
+		       AVG(events)::float8
+		FROM sessions
+		GROUP BY day
+		ORDER BY day
+	`
+
+	rows, err := r.db.Query(ctx, query, q.From, q.To, q.Gap.SQL(), models.TimezoneName(q.Location))
+	if err != nil {
+		log.Printf("repo.GetSessionStats: query sessions: %v", err)
+		return nil, fmt.Errorf("query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	days := []models.SessionStats{}
+	for rows.Next() {
+		var d models.SessionStats
+		if err := rows.Scan(&d.Day, &d.Sessions, &d.Users, &d.AvgDurationSeconds, &d.AvgEvents); err != nil {
+			log.Printf("repo.GetSessionStats: scan sessions: %v", err)
+			return nil, fmt.Errorf("scan sessions: %w", err)
+		}
+		days = append(days, d)
+	}
+
+	return days, nil
+}
+
+// partialBucket reports whether the bucket of width interval starting at
+// bucket is still open at now. Calendar units are counted in loc, nil
+// meaning UTC.
+func partialBucket(bucket time.Time, interval models.Interval, loc *time.Location, now time.Time) bool {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	end := interval.AddTo(bucket.In(loc), 1)
+	return end.After(now)
+}
+
+// nullTime maps the zero time to NULL for optional range bounds.
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
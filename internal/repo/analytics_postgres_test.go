@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartialBucket(t *testing.T) {
+	now := time.Date(2026, 3, 8, 10, 30, 0, 0, time.UTC)
+
+	assert.True(t, partialBucket(time.Date(2026, 3, 8, 10, 0, 0, 0, time.UTC), hourInterval, nil, now))
+	assert.False(t, partialBucket(time.Date(2026, 3, 8, 9, 0, 0, 0, time.UTC), hourInterval, nil, now))
+	assert.True(t, partialBucket(time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC), dayInterval, nil, now))
+	assert.False(t, partialBucket(time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC), dayInterval, nil, now))
+	assert.True(t, partialBucket(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), models.Interval{Count: 1, Unit: "mo"}, nil, now))
+	assert.False(t, partialBucket(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), models.Interval{Count: 1, Unit: "mo"}, nil, now))
+
+	// 2026-03-08 00:00 in Jakarta is still open at 10:30 UTC (17:30 WIB)
+	jakarta, err := time.LoadLocation(models.JakartaTimezone)
+	require.NoError(t, err)
+	assert.True(t, partialBucket(time.Date(2026, 3, 7, 17, 0, 0, 0, time.UTC), dayInterval, jakarta, now))
+	assert.False(t, partialBucket(time.Date(2026, 3, 6, 17, 0, 0, 0, time.UTC), dayInterval, jakarta, now))
+}
+
+func TestFunnelSQL(t *testing.T) {
+	query := funnelSQL(4, false)
+	assert.Contains(t, query, "step4 AS (")
+	assert.Contains(t, query, "FROM step3 p")
+	assert.Equal(t, 3, strings.Count(query, "UNION ALL"))
+	assert.NotContains(t, query, "$6")
+
+	assert.Contains(t, funnelSQL(2, true), "payload #>> $6 AS value")
+}
@@ -0,0 +1,189 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+	TouchLastUsed(ctx context.Context, id int64) error
+	Rotate(ctx context.Context, oldID int64, replacement *models.APIKey, grace time.Duration) (bool, error)
+	Revoke(ctx context.Context, id int64) (bool, error)
+}
+
+type apiKeyRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool) APIKeyRepository {
+	return &apiKeyRepo{db: db}
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, scopes, created_at, last_used_at, expires_at, revoked_at`
+
+func (r *apiKeyRepo) Create(ctx context.Context, key *models.APIKey) error {
+	query := `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRow(ctx, query, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.ExpiresAt).
+		Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		log.Printf("repo.APIKey.Create: insert key %q: %v", key.Name, err)
+		return fmt.Errorf("insert api key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepo) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix = $1`
+
+	key, err := scanAPIKey(r.db.QueryRow(ctx, query, prefix))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("repo.APIKey.GetByPrefix: get key %q: %v", prefix, err)
+		return nil, fmt.Errorf("get api key: %w", err)
+	}
+
+	return key, nil
+}
+
+func (r *apiKeyRepo) List(ctx context.Context) ([]models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		log.Printf("repo.APIKey.List: list keys: %v", err)
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			log.Printf("repo.APIKey.List: scan key: %v", err)
+			return nil, fmt.Errorf("scan api key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeyRepo) TouchLastUsed(ctx context.Context, id int64) error {
+	if _, err := r.db.Exec(ctx, `UPDATE api_keys SET last_used_at = NOW() WHERE id = $1`, id); err != nil {
+		log.Printf("repo.APIKey.TouchLastUsed: update key %d: %v", id, err)
+		return fmt.Errorf("update api key: %w", err)
+	}
+	return nil
+}
+
+// ErrAPIKeyRetired is returned by Rotate for a key that has expired or is
+// already in the grace period of an earlier rotation.
+var ErrAPIKeyRetired = errors.New("API key is expired or already rotated")
+
+// Rotate creates replacement with the old key's name, scopes and expiry and
+// retires the old key, immediately or after grace, in one transaction. It
+// reports false if the old key does not exist or is already revoked.
+func (r *apiKeyRepo) Rotate(ctx context.Context, oldID int64, replacement *models.APIKey, grace time.Duration) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		log.Printf("repo.APIKey.Rotate: begin: %v", err)
+		return false, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var retired bool
+	err = tx.QueryRow(ctx, `
+		SELECT name, scopes, expires_at, rotated_at IS NOT NULL OR COALESCE(expires_at <= NOW(), false)
+		FROM api_keys
+		WHERE id = $1 AND revoked_at IS NULL
+		FOR UPDATE
+	`, oldID).Scan(&replacement.Name, &replacement.Scopes, &replacement.ExpiresAt, &retired)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		log.Printf("repo.APIKey.Rotate: lock key %d: %v", oldID, err)
+		return false, fmt.Errorf("lock api key: %w", err)
+	}
+	// An expired key would pass its expiry on, and a rotated one the end of
+	// its grace period
+	if retired {
+		return true, ErrAPIKeyRetired
+	}
+
+	// During the grace period the old key keeps working, unless it was
+	// going to expire earlier anyway
+	retire := `UPDATE api_keys SET revoked_at = NOW(), rotated_at = NOW() WHERE id = $1`
+	args := []interface{}{oldID}
+	if grace > 0 {
+		retire = `
+			UPDATE api_keys
+			SET expires_at = LEAST(COALESCE(expires_at, 'infinity'), NOW() + $2 * INTERVAL '1 second'),
+			    rotated_at = NOW()
+			WHERE id = $1
+		`
+		args = append(args, grace.Seconds())
+	}
+	if _, err := tx.Exec(ctx, retire, args...); err != nil {
+		log.Printf("repo.APIKey.Rotate: retire key %d: %v", oldID, err)
+		return false, fmt.Errorf("retire api key: %w", err)
+	}
+
+	insert := `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(ctx, insert, replacement.Name, replacement.Prefix, replacement.KeyHash, replacement.Scopes, replacement.ExpiresAt).
+		Scan(&replacement.ID, &replacement.CreatedAt)
+	if err != nil {
+		log.Printf("repo.APIKey.Rotate: insert replacement for key %d: %v", oldID, err)
+		return false, fmt.Errorf("insert api key: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("repo.APIKey.Rotate: commit: %v", err)
+		return false, fmt.Errorf("commit: %w", err)
+	}
+
+	return true, nil
+}
+
+// Revoke disables a key immediately. It reports false if the key does not
+// exist or is already revoked.
+func (r *apiKeyRepo) Revoke(ctx context.Context, id int64) (bool, error) {
+	tag, err := r.db.Exec(ctx, `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		log.Printf("repo.APIKey.Revoke: update key %d: %v", id, err)
+		return false, fmt.Errorf("revoke api key: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func scanAPIKey(row pgx.Row) (*models.APIKey, error) {
+	var key models.APIKey
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.KeyHash, &key.Scopes,
+		&key.CreatedAt, &key.LastUsedAt, &key.ExpiresAt, &key.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// IsDataError reports whether the database rejected err's statement because
+// of the data itself: a data exception (class 22) or an integrity constraint
+// violation (class 23). Retrying the same data fails the same way, unlike
+// timeouts, lock conflicts or lost connections.
+func IsDataError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "23")
+}
+
+// ErrEmptyHistogramRange is returned by GetHistogram when an explicit min
+// or max leaves no room between the bounds, e.g. a min above every value.
+var ErrEmptyHistogramRange = errors.New("histogram range is empty")
+
+// IsConnectionError reports whether err means the database could not be
+// reached, as opposed to the database rejecting the statement. Writes that
+// fail this way can be safely retried later.
+func IsConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Class 08 is connection exception; 57P0x are server shutdowns and
+		// 53300 is too_many_connections
+		return strings.HasPrefix(pgErr.Code, "08") ||
+			strings.HasPrefix(pgErr.Code, "57P0") ||
+			pgErr.Code == "53300"
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return pgconn.SafeToRetry(err) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain error", errors.New("boom"), false},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"connection failure", fmt.Errorf("insert event: %w", &pgconn.PgError{Code: "08006"}), true},
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, true},
+		{"too many connections", &pgconn.PgError{Code: "53300"}, true},
+		{"network error", fmt.Errorf("insert event: %w", &net.OpError{Op: "dial", Err: errors.New("refused")}), true},
+		{"unexpected eof", fmt.Errorf("insert event: %w", io.ErrUnexpectedEOF), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsConnectionError(tt.err))
+		})
+	}
+}
+
+func TestIsDataError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain error", errors.New("boom"), false},
+		{"invalid text representation", fmt.Errorf("copy events: %w", &pgconn.PgError{Code: "22P02"}), true},
+		{"check violation", &pgconn.PgError{Code: "23514"}, true},
+		{"statement timeout", &pgconn.PgError{Code: "57014"}, false},
+		{"lock timeout", &pgconn.PgError{Code: "55P03"}, false},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsDataError(tt.err))
+		})
+	}
+}
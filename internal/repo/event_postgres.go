@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
 	"github.com/jackc/pgx/v5"
@@ -13,8 +14,12 @@ import (
 
 type EventRepository interface {
 	Create(ctx context.Context, event *models.Event) error
+	CreateBatch(ctx context.Context, events []*models.Event) error
+	CopyEvents(ctx context.Context, events []*models.Event) error
+	CreateIdempotent(ctx context.Context, event *models.Event, window time.Duration) (bool, error)
 	GetByID(ctx context.Context, id int64) (*models.Event, error)
 	List(ctx context.Context, filter models.EventFilter) ([]models.Event, error)
+	ListByUser(ctx context.Context, filter models.UserEventFilter) ([]models.Event, error)
 }
 
 type eventRepo struct {
@@ -33,12 +38,12 @@ func (r *eventRepo) Create(ctx context.Context, event *models.Event) error {
 	}
 
 	query := `
-		INSERT INTO events (event_name, timestamp, payload)
-		VALUES ($1, $2, $3)
+		INSERT INTO events (event_name, timestamp, payload, idempotency_key)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
 		RETURNING id, created_at
 	`
 
-	err = r.db.QueryRow(ctx, query, event.EventName, event.Timestamp, payloadJSON).
+	err = r.db.QueryRow(ctx, query, event.EventName, event.Timestamp, payloadJSON, event.IdempotencyKey).
 		Scan(&event.ID, &event.CreatedAt)
 	if err != nil {
 		log.Printf("repo.Create: insert event: %v", err)
@@ -48,9 +53,174 @@ func (r *eventRepo) Create(ctx context.Context, event *models.Event) error {
 	return nil
 }
 
+// CreateBatch inserts all events in a single round-trip. The statements are
+// pipelined as one implicit transaction, so either every event is stored or
+// none is.
+func (r *eventRepo) CreateBatch(ctx context.Context, events []*models.Event) error {
+	query := `
+		INSERT INTO events (event_name, timestamp, payload, idempotency_key)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		RETURNING id, created_at
+	`
+
+	batch := &pgx.Batch{}
+	for _, event := range events {
+		payloadJSON, err := json.Marshal(event.Payload)
+		if err != nil {
+			log.Printf("repo.CreateBatch: marshal payload: %v", err)
+			return fmt.Errorf("marshal payload: %w", err)
+		}
+
+		batch.Queue(query, event.EventName, event.Timestamp, payloadJSON, event.IdempotencyKey).QueryRow(func(row pgx.Row) error {
+			return row.Scan(&event.ID, &event.CreatedAt)
+		})
+	}
+
+	if err := r.db.SendBatch(ctx, batch).Close(); err != nil {
+		log.Printf("repo.CreateBatch: insert %d events: %v", len(events), err)
+		return fmt.Errorf("insert events: %w", err)
+	}
+
+	return nil
+}
+
+// CopyEvents bulk loads events with the COPY protocol. It is faster than
+// CreateBatch but does not return generated IDs.
+func (r *eventRepo) CopyEvents(ctx context.Context, events []*models.Event) error {
+	rows := make([][]interface{}, 0, len(events))
+	for _, event := range events {
+		payloadJSON, err := json.Marshal(event.Payload)
+		if err != nil {
+			log.Printf("repo.CopyEvents: marshal payload: %v", err)
+			return fmt.Errorf("marshal payload: %w", err)
+		}
+		var key *string
+		if event.IdempotencyKey != "" {
+			key = &event.IdempotencyKey
+		}
+		rows = append(rows, []interface{}{event.EventName, event.Timestamp, payloadJSON, key})
+	}
+
+	_, err := r.db.CopyFrom(ctx,
+		pgx.Identifier{"events"},
+		[]string{"event_name", "timestamp", "payload", "idempotency_key"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		log.Printf("repo.CopyEvents: copy %d events: %v", len(events), err)
+		return fmt.Errorf("copy events: %w", err)
+	}
+
+	return nil
+}
+
+// CreateIdempotent stores event unless its idempotency key was already used
+// within window, in which case event is overwritten with the originally
+// stored event and true is returned. Concurrent requests with the same key
+// serialise on the key's primary key, so exactly one of them inserts.
+func (r *eventRepo) CreateIdempotent(ctx context.Context, event *models.Event, window time.Duration) (bool, error) {
+	payloadJSON, err := json.Marshal(event.Payload)
+	if err != nil {
+		log.Printf("repo.CreateIdempotent: marshal payload: %v", err)
+		return false, fmt.Errorf("marshal payload: %w", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		log.Printf("repo.CreateIdempotent: begin: %v", err)
+		return false, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Claim the key; an expired key is reclaimed, a live one yields no row
+	claimQuery := `
+		INSERT INTO event_idempotency_keys (idempotency_key, created_at)
+		VALUES ($1, NOW())
+		ON CONFLICT (idempotency_key) DO UPDATE
+			SET created_at = NOW(), event_id = NULL, event_timestamp = NULL
+			WHERE event_idempotency_keys.created_at < NOW() - $2 * INTERVAL '1 second'
+		RETURNING idempotency_key
+	`
+
+	var claimed string
+	err = tx.QueryRow(ctx, claimQuery, event.IdempotencyKey, window.Seconds()).Scan(&claimed)
+	if err != nil && err != pgx.ErrNoRows {
+		log.Printf("repo.CreateIdempotent: claim key %q: %v", event.IdempotencyKey, err)
+		return false, fmt.Errorf("claim idempotency key: %w", err)
+	}
+
+	if err == pgx.ErrNoRows {
+		existing, err := r.getByKey(ctx, tx, event.IdempotencyKey)
+		if err != nil {
+			return false, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			log.Printf("repo.CreateIdempotent: commit: %v", err)
+			return false, fmt.Errorf("commit transaction: %w", err)
+		}
+		*event = *existing
+		return true, nil
+	}
+
+	insertQuery := `
+		INSERT INTO events (event_name, timestamp, payload, idempotency_key)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(ctx, insertQuery, event.EventName, event.Timestamp, payloadJSON, event.IdempotencyKey).
+		Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		log.Printf("repo.CreateIdempotent: insert event: %v", err)
+		return false, fmt.Errorf("insert event: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE event_idempotency_keys
+		SET event_id = $2, event_timestamp = $3
+		WHERE idempotency_key = $1
+	`, event.IdempotencyKey, event.ID, event.Timestamp)
+	if err != nil {
+		log.Printf("repo.CreateIdempotent: record key %q: %v", event.IdempotencyKey, err)
+		return false, fmt.Errorf("record idempotency key: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("repo.CreateIdempotent: commit: %v", err)
+		return false, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return false, nil
+}
+
+func (r *eventRepo) getByKey(ctx context.Context, tx pgx.Tx, key string) (*models.Event, error) {
+	query := `
+		SELECT e.id, e.event_name, e.timestamp, e.payload, COALESCE(e.idempotency_key, ''), e.created_at
+		FROM event_idempotency_keys k
+		JOIN events e ON e.id = k.event_id AND e.timestamp = k.event_timestamp
+		WHERE k.idempotency_key = $1
+	`
+
+	var event models.Event
+	var payloadJSON []byte
+
+	err := tx.QueryRow(ctx, query, key).
+		Scan(&event.ID, &event.EventName, &event.Timestamp, &payloadJSON, &event.IdempotencyKey, &event.CreatedAt)
+	if err != nil {
+		log.Printf("repo.getByKey: get event for key %q: %v", key, err)
+		return nil, fmt.Errorf("get event by idempotency key: %w", err)
+	}
+
+	if err := json.Unmarshal(payloadJSON, &event.Payload); err != nil {
+		log.Printf("repo.getByKey: unmarshal payload for key %q: %v", key, err)
+		return nil, fmt.Errorf("unmarshal payload: %w", err)
+	}
+
+	return &event, nil
+}
+
 func (r *eventRepo) GetByID(ctx context.Context, id int64) (*models.Event, error) {
 	query := `
-		SELECT id, event_name, timestamp, payload, created_at
+		SELECT id, event_name, timestamp, payload, COALESCE(idempotency_key, ''), created_at
 		FROM events
 		WHERE id = $1
 	`
@@ -59,7 +229,7 @@ func (r *eventRepo) GetByID(ctx context.Context, id int64) (*models.Event, error
 	var payloadJSON []byte
 
 	err := r.db.QueryRow(ctx, query, id).
-		Scan(&event.ID, &event.EventName, &event.Timestamp, &payloadJSON, &event.CreatedAt)
+		Scan(&event.ID, &event.EventName, &event.Timestamp, &payloadJSON, &event.IdempotencyKey, &event.CreatedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
@@ -78,7 +248,7 @@ func (r *eventRepo) GetByID(ctx context.Context, id int64) (*models.Event, error
 
 func (r *eventRepo) List(ctx context.Context, filter models.EventFilter) ([]models.Event, error) {
 	query := `
-		SELECT id, event_name, timestamp, payload, created_at
+		SELECT id, event_name, timestamp, payload, COALESCE(idempotency_key, ''), created_at
 		FROM events
 		WHERE 1=1
 	`
@@ -103,7 +273,25 @@ func (r *eventRepo) List(ctx context.Context, filter models.EventFilter) ([]mode
 		argNum++
 	}
 
-	query += " ORDER BY timestamp DESC"
+	for _, pf := range filter.Payload {
+		cond, condArgs, err := payloadFilterSQL(pf, argNum)
+		if err != nil {
+			return nil, err
+		}
+		query += " AND " + cond
+		args = append(args, condArgs...)
+		argNum += len(condArgs)
+	}
+
+	// Keyset pagination: the id breaks ties between events sharing a
+	// timestamp
+	if filter.Cursor != nil {
+		query += fmt.Sprintf(" AND (timestamp, id) < ($%d, $%d)", argNum, argNum+1)
+		args = append(args, filter.Cursor.Timestamp, filter.Cursor.ID)
+		argNum += 2
+	}
+
+	query += " ORDER BY timestamp DESC, id DESC"
 
 	if filter.Limit > 0 {
 		query += fmt.Sprintf(" LIMIT $%d", argNum)
@@ -111,7 +299,7 @@ func (r *eventRepo) List(ctx context.Context, filter models.EventFilter) ([]mode
 		argNum++
 	}
 
-	if filter.Offset > 0 {
+	if filter.Offset > 0 && filter.Cursor == nil {
 		query += fmt.Sprintf(" OFFSET $%d", argNum)
 		args = append(args, filter.Offset)
 	}
@@ -123,18 +311,81 @@ func (r *eventRepo) List(ctx context.Context, filter models.EventFilter) ([]mode
 	}
 	defer rows.Close()
 
+	return scanEvents(rows, "repo.List")
+}
+
+// ListByUser lists the events of one install in timestamp order, using the
+// expression index on payload->>'user_id'.
+func (r *eventRepo) ListByUser(ctx context.Context, filter models.UserEventFilter) ([]models.Event, error) {
+	query := `
+		SELECT id, event_name, timestamp, payload, COALESCE(idempotency_key, ''), created_at
+		FROM events
+		WHERE payload->>'user_id' = $1
+	`
+	args := []interface{}{filter.UserID}
+	argNum := 2
+
+	if len(filter.EventNames) > 0 {
+		query += fmt.Sprintf(" AND event_name = ANY($%d)", argNum)
+		args = append(args, filter.EventNames)
+		argNum++
+	}
+
+	if filter.From != nil {
+		query += fmt.Sprintf(" AND timestamp >= $%d", argNum)
+		args = append(args, *filter.From)
+		argNum++
+	}
+
+	if filter.To != nil {
+		query += fmt.Sprintf(" AND timestamp <= $%d", argNum)
+		args = append(args, *filter.To)
+		argNum++
+	}
+
+	cmp, order := ">", "ASC"
+	if filter.Descending {
+		cmp, order = "<", "DESC"
+	}
+
+	if filter.Cursor != nil {
+		query += fmt.Sprintf(" AND (timestamp, id) %s ($%d, $%d)", cmp, argNum, argNum+1)
+		args = append(args, filter.Cursor.Timestamp, filter.Cursor.ID)
+		argNum += 2
+	}
+
+	query += fmt.Sprintf(" ORDER BY timestamp %s, id %s", order, order)
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("repo.ListByUser: list events of %s: %v", filter.UserID, err)
+		return nil, fmt.Errorf("list user events: %w", err)
+	}
+	defer rows.Close()
+
+	return scanEvents(rows, "repo.ListByUser")
+}
+
+// scanEvents reads rows selected as id, event_name, timestamp, payload,
+// idempotency_key, created_at. op prefixes logged errors.
+func scanEvents(rows pgx.Rows, op string) ([]models.Event, error) {
 	var events []models.Event
 	for rows.Next() {
 		var event models.Event
 		var payloadJSON []byte
 
-		if err := rows.Scan(&event.ID, &event.EventName, &event.Timestamp, &payloadJSON, &event.CreatedAt); err != nil {
-			log.Printf("repo.List: scan event: %v", err)
+		if err := rows.Scan(&event.ID, &event.EventName, &event.Timestamp, &payloadJSON, &event.IdempotencyKey, &event.CreatedAt); err != nil {
+			log.Printf("%s: scan event: %v", op, err)
 			return nil, fmt.Errorf("scan event: %w", err)
 		}
 
 		if err := json.Unmarshal(payloadJSON, &event.Payload); err != nil {
-			log.Printf("repo.List: unmarshal payload: %v", err)
+			log.Printf("%s: unmarshal payload: %v", op, err)
 			return nil, fmt.Errorf("unmarshal payload: %w", err)
 		}
 
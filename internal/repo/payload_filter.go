@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+var payloadRangeOps = map[string]string{
+	models.PayloadOpGt:  ">",
+	models.PayloadOpGte: ">=",
+	models.PayloadOpLt:  "<",
+	models.PayloadOpLte: "<=",
+}
+
+// payloadFilterSQL translates a payload filter into a predicate on the
+// payload column. Paths and values are only ever passed as parameters,
+// starting at $argNum. Equality uses containment so it can be served by
+// the GIN index on payload.
+func payloadFilterSQL(filter models.PayloadFilter, argNum int) (string, []interface{}, error) {
+	switch filter.Op {
+	case models.PayloadOpEq, models.PayloadOpIn:
+		var conds []string
+		var args []interface{}
+		for _, value := range filter.Values {
+			for _, candidate := range payloadCandidates(value) {
+				doc, err := json.Marshal(nestPayloadValue(filter.Path, candidate))
+				if err != nil {
+					return "", nil, fmt.Errorf("encode payload filter: %w", err)
+				}
+				conds = append(conds, fmt.Sprintf("payload @> $%d::jsonb", argNum))
+				args = append(args, string(doc))
+				argNum++
+			}
+		}
+		return "(" + strings.Join(conds, " OR ") + ")", args, nil
+
+	case models.PayloadOpGt, models.PayloadOpGte, models.PayloadOpLt, models.PayloadOpLte:
+		// The CASE keeps non-numeric values from reaching the cast
+		cond := fmt.Sprintf("CASE WHEN jsonb_typeof(payload #> $%d) = 'number' THEN (payload #>> $%d)::numeric END %s $%d::numeric",
+			argNum, argNum, payloadRangeOps[filter.Op], argNum+1)
+		return cond, []interface{}{filter.Path, filter.Number}, nil
+
+	case models.PayloadOpExists:
+		cond := fmt.Sprintf("payload #> $%d IS NOT NULL", argNum)
+		if !filter.Exists {
+			cond = fmt.Sprintf("payload #> $%d IS NULL", argNum)
+		}
+		return cond, []interface{}{filter.Path}, nil
+	}
+
+	return "", nil, fmt.Errorf("%w: unknown operator %q", models.ErrInvalidPayloadFilter, filter.Op)
+}
+
+// payloadCandidates returns the JSON values a textual filter value stands
+// for: always the string, plus the number or boolean it spells. NaN and
+// infinities are not JSON numbers, so they only match as strings.
+func payloadCandidates(value string) []interface{} {
+	candidates := []interface{}{value}
+	if n, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(n) && !math.IsInf(n, 0) {
+		candidates = append(candidates, json.Number(strconv.FormatFloat(n, 'g', -1, 64)))
+	} else if value == "true" || value == "false" {
+		candidates = append(candidates, value == "true")
+	}
+	return candidates
+}
+
+func nestPayloadValue(path []string, value interface{}) map[string]interface{} {
+	doc := map[string]interface{}{path[len(path)-1]: value}
+	for i := len(path) - 2; i >= 0; i-- {
+		doc = map[string]interface{}{path[i]: doc}
+	}
+	return doc
+}
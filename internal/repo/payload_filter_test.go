@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayloadFilterSQL_Equality(t *testing.T) {
+	cond, args, err := payloadFilterSQL(models.PayloadFilter{
+		Path:   []string{"device", "version"},
+		Op:     models.PayloadOpIn,
+		Values: []string{"23.0", "beta"},
+	}, 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "(payload @> $3::jsonb OR payload @> $4::jsonb OR payload @> $5::jsonb)", cond)
+	assert.Equal(t, []interface{}{
+		`{"device":{"version":"23.0"}}`,
+		`{"device":{"version":23}}`,
+		`{"device":{"version":"beta"}}`,
+	}, args)
+}
+
+func TestPayloadFilterSQL_Range(t *testing.T) {
+	cond, args, err := payloadFilterSQL(models.PayloadFilter{Path: []string{"duration_ms"}, Op: models.PayloadOpGte, Number: 500}, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "CASE WHEN jsonb_typeof(payload #> $2) = 'number' THEN (payload #>> $2)::numeric END >= $3::numeric", cond)
+	assert.Equal(t, []interface{}{[]string{"duration_ms"}, 500.0}, args)
+}
+
+func TestPayloadFilterSQL_Exists(t *testing.T) {
+	cond, args, err := payloadFilterSQL(models.PayloadFilter{Path: []string{"crash"}, Op: models.PayloadOpExists}, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "payload #> $1 IS NULL", cond)
+	assert.Equal(t, []interface{}{[]string{"crash"}}, args)
+}
+
+func TestPayloadCandidates(t *testing.T) {
+	assert.Equal(t, []interface{}{"linux"}, payloadCandidates("linux"))
+	assert.Equal(t, []interface{}{"true", true}, payloadCandidates("true"))
+	assert.Equal(t, []interface{}{"1e3", json.Number("1000")}, payloadCandidates("1e3"))
+	assert.Equal(t, []interface{}{"NaN"}, payloadCandidates("NaN"))
+	assert.Equal(t, []interface{}{"-Inf"}, payloadCandidates("-Inf"))
+}
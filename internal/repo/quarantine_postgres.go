@@ -0,0 +1,196 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type QuarantineRepository interface {
+	CreateBatch(ctx context.Context, entries []*models.QuarantinedEvent) error
+	GetByID(ctx context.Context, id int64) (*models.QuarantinedEvent, error)
+	List(ctx context.Context, filter models.QuarantineFilter) ([]models.QuarantinedEvent, error)
+	MarkReprocessed(ctx context.Context, id int64, eventID *int64) (bool, error)
+}
+
+type quarantineRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewQuarantineRepository(db *pgxpool.Pool) QuarantineRepository {
+	return &quarantineRepo{db: db}
+}
+
+// CreateBatch stores quarantine entries in a single round-trip.
+func (r *quarantineRepo) CreateBatch(ctx context.Context, entries []*models.QuarantinedEvent) error {
+	query := `
+		INSERT INTO events_quarantine (event_name, reason, error, details, raw, raw_size, truncated)
+		VALUES (NULLIF($1, ''), $2, $3, $4, $5, $6, $7)
+		RETURNING id, received_at
+	`
+
+	batch := &pgx.Batch{}
+	for _, entry := range entries {
+		var detailsJSON []byte
+		if len(entry.Details) > 0 {
+			var err error
+			detailsJSON, err = json.Marshal(entry.Details)
+			if err != nil {
+				log.Printf("repo.Quarantine.CreateBatch: marshal details: %v", err)
+				return fmt.Errorf("marshal details: %w", err)
+			}
+		}
+
+		batch.Queue(query, entry.EventName, entry.Reason, entry.Error, detailsJSON,
+			sanitizeText(entry.Raw), entry.RawSize, entry.Truncated).QueryRow(func(row pgx.Row) error {
+			return row.Scan(&entry.ID, &entry.ReceivedAt)
+		})
+	}
+
+	if err := r.db.SendBatch(ctx, batch).Close(); err != nil {
+		log.Printf("repo.Quarantine.CreateBatch: insert %d entries: %v", len(entries), err)
+		return fmt.Errorf("insert quarantine entries: %w", err)
+	}
+
+	return nil
+}
+
+func (r *quarantineRepo) GetByID(ctx context.Context, id int64) (*models.QuarantinedEvent, error) {
+	query := `
+		SELECT id, COALESCE(event_name, ''), reason, error, details, raw, raw_size, truncated,
+			received_at, reprocessed_at, event_id
+		FROM events_quarantine
+		WHERE id = $1
+	`
+
+	entry, err := scanQuarantined(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("repo.Quarantine.GetByID: get entry %d: %v", id, err)
+		return nil, fmt.Errorf("get quarantine entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// List returns entries oldest first, so pending entries are reprocessed in
+// the order they arrived.
+func (r *quarantineRepo) List(ctx context.Context, filter models.QuarantineFilter) ([]models.QuarantinedEvent, error) {
+	query := `
+		SELECT id, COALESCE(event_name, ''), reason, error, details, raw, raw_size, truncated,
+			received_at, reprocessed_at, event_id
+		FROM events_quarantine
+		WHERE 1=1
+	`
+	args := []interface{}{}
+	argNum := 1
+
+	if filter.Reason != "" {
+		query += fmt.Sprintf(" AND reason = $%d", argNum)
+		args = append(args, filter.Reason)
+		argNum++
+	}
+
+	if filter.EventName != "" {
+		query += fmt.Sprintf(" AND event_name = $%d", argNum)
+		args = append(args, filter.EventName)
+		argNum++
+	}
+
+	if filter.AfterID > 0 {
+		query += fmt.Sprintf(" AND id > $%d", argNum)
+		args = append(args, filter.AfterID)
+		argNum++
+	}
+
+	if filter.Reprocessed != nil {
+		if *filter.Reprocessed {
+			query += " AND reprocessed_at IS NOT NULL"
+		} else {
+			query += " AND reprocessed_at IS NULL"
+		}
+	}
+
+	query += " ORDER BY id"
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argNum)
+		args = append(args, filter.Limit)
+		argNum++
+	}
+
+	if filter.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argNum)
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("repo.Quarantine.List: list entries: %v", err)
+		return nil, fmt.Errorf("list quarantine entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.QuarantinedEvent{}
+	for rows.Next() {
+		entry, err := scanQuarantined(rows)
+		if err != nil {
+			log.Printf("repo.Quarantine.List: scan entry: %v", err)
+			return nil, fmt.Errorf("scan quarantine entry: %w", err)
+		}
+		entries = append(entries, *entry)
+	}
+
+	return entries, nil
+}
+
+// MarkReprocessed records that an entry was resubmitted. It reports false if
+// the entry does not exist or was already marked.
+func (r *quarantineRepo) MarkReprocessed(ctx context.Context, id int64, eventID *int64) (bool, error) {
+	query := `
+		UPDATE events_quarantine
+		SET reprocessed_at = NOW(), event_id = $2
+		WHERE id = $1 AND reprocessed_at IS NULL
+	`
+
+	tag, err := r.db.Exec(ctx, query, id, eventID)
+	if err != nil {
+		log.Printf("repo.Quarantine.MarkReprocessed: update entry %d: %v", id, err)
+		return false, fmt.Errorf("update quarantine entry: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func scanQuarantined(row pgx.Row) (*models.QuarantinedEvent, error) {
+	var entry models.QuarantinedEvent
+	var detailsJSON []byte
+
+	err := row.Scan(&entry.ID, &entry.EventName, &entry.Reason, &entry.Error, &detailsJSON,
+		&entry.Raw, &entry.RawSize, &entry.Truncated, &entry.ReceivedAt, &entry.ReprocessedAt, &entry.EventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(detailsJSON) > 0 {
+		if err := json.Unmarshal(detailsJSON, &entry.Details); err != nil {
+			return nil, fmt.Errorf("unmarshal details: %w", err)
+		}
+	}
+
+	return &entry, nil
+}
+
+// sanitizeText makes arbitrary client bytes storable in a TEXT column,
+// which rejects NUL and invalid UTF-8.
+func sanitizeText(s string) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(s, "�"), "\x00", "�")
+}
@@ -0,0 +1,13 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeText(t *testing.T) {
+	assert.Equal(t, `{"a":1}`, sanitizeText(`{"a":1}`))
+	assert.Equal(t, "a�b", sanitizeText("a\x00b"))
+	assert.Equal(t, "a�b", sanitizeText("a\xffb"))
+}
@@ -0,0 +1,155 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SchemaRepository interface {
+	Create(ctx context.Context, schema *models.EventSchema) error
+	Get(ctx context.Context, eventName string, version int) (*models.EventSchema, error)
+	GetLatest(ctx context.Context, eventName string) (*models.EventSchema, error)
+	List(ctx context.Context, eventName string) ([]models.EventSchema, error)
+	SetActive(ctx context.Context, eventName string, version int, active bool) (bool, error)
+}
+
+type schemaRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewSchemaRepository(db *pgxpool.Pool) SchemaRepository {
+	return &schemaRepo{db: db}
+}
+
+// Create stores schema as the next version for its event name. Concurrent
+// creates for the same name race on the primary key and one of them fails.
+func (r *schemaRepo) Create(ctx context.Context, schema *models.EventSchema) error {
+	query := `
+		INSERT INTO event_schemas (event_name, version, schema)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2
+		FROM event_schemas
+		WHERE event_name = $1
+		RETURNING version, active, created_at
+	`
+
+	err := r.db.QueryRow(ctx, query, schema.EventName, []byte(schema.Schema)).
+		Scan(&schema.Version, &schema.Active, &schema.CreatedAt)
+	if err != nil {
+		log.Printf("repo.Schema.Create: insert schema for %q: %v", schema.EventName, err)
+		return fmt.Errorf("insert schema: %w", err)
+	}
+
+	return nil
+}
+
+func (r *schemaRepo) Get(ctx context.Context, eventName string, version int) (*models.EventSchema, error) {
+	query := `
+		SELECT event_name, version, schema, active, created_at
+		FROM event_schemas
+		WHERE event_name = $1 AND version = $2
+	`
+
+	schema, err := scanSchema(r.db.QueryRow(ctx, query, eventName, version))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("repo.Schema.Get: get schema %q v%d: %v", eventName, version, err)
+		return nil, fmt.Errorf("get schema: %w", err)
+	}
+
+	return schema, nil
+}
+
+// GetLatest returns the highest active version for eventName, or nil if the
+// event name has no active schema.
+func (r *schemaRepo) GetLatest(ctx context.Context, eventName string) (*models.EventSchema, error) {
+	query := `
+		SELECT event_name, version, schema, active, created_at
+		FROM event_schemas
+		WHERE event_name = $1 AND active
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	schema, err := scanSchema(r.db.QueryRow(ctx, query, eventName))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		log.Printf("repo.Schema.GetLatest: get latest schema %q: %v", eventName, err)
+		return nil, fmt.Errorf("get latest schema: %w", err)
+	}
+
+	return schema, nil
+}
+
+// List returns all schema versions, optionally restricted to one event name.
+func (r *schemaRepo) List(ctx context.Context, eventName string) ([]models.EventSchema, error) {
+	query := `
+		SELECT event_name, version, schema, active, created_at
+		FROM event_schemas
+	`
+	args := []interface{}{}
+
+	if eventName != "" {
+		query += " WHERE event_name = $1"
+		args = append(args, eventName)
+	}
+
+	query += " ORDER BY event_name, version DESC"
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("repo.Schema.List: list schemas: %v", err)
+		return nil, fmt.Errorf("list schemas: %w", err)
+	}
+	defer rows.Close()
+
+	schemas := []models.EventSchema{}
+	for rows.Next() {
+		schema, err := scanSchema(rows)
+		if err != nil {
+			log.Printf("repo.Schema.List: scan schema: %v", err)
+			return nil, fmt.Errorf("scan schema: %w", err)
+		}
+		schemas = append(schemas, *schema)
+	}
+
+	return schemas, nil
+}
+
+// SetActive toggles whether a schema version is used for validation. It
+// reports false if the version does not exist.
+func (r *schemaRepo) SetActive(ctx context.Context, eventName string, version int, active bool) (bool, error) {
+	query := `
+		UPDATE event_schemas
+		SET active = $3
+		WHERE event_name = $1 AND version = $2
+	`
+
+	tag, err := r.db.Exec(ctx, query, eventName, version, active)
+	if err != nil {
+		log.Printf("repo.Schema.SetActive: update schema %q v%d: %v", eventName, version, err)
+		return false, fmt.Errorf("update schema: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+func scanSchema(row pgx.Row) (*models.EventSchema, error) {
+	var schema models.EventSchema
+	var schemaJSON []byte
+
+	if err := row.Scan(&schema.EventName, &schema.Version, &schemaJSON, &schema.Active, &schema.CreatedAt); err != nil {
+		return nil, err
+	}
+	schema.Schema = schemaJSON
+
+	return &schema, nil
+}
@@ -0,0 +1,95 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SigningSecretRepository interface {
+	Create(ctx context.Context, secret *models.SigningSecret) error
+	ListActive(ctx context.Context, release string) ([]models.SigningSecret, error)
+	List(ctx context.Context) ([]models.SigningSecret, error)
+	Revoke(ctx context.Context, id int64) (bool, error)
+}
+
+type signingSecretRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewSigningSecretRepository(db *pgxpool.Pool) SigningSecretRepository {
+	return &signingSecretRepo{db: db}
+}
+
+const signingSecretColumns = `id, release, secret, created_at, revoked_at`
+
+func (r *signingSecretRepo) Create(ctx context.Context, secret *models.SigningSecret) error {
+	query := `
+		INSERT INTO signing_secrets (release, secret)
+		VALUES ($1, $2)
+		RETURNING id, created_at
+	`
+
+	if err := r.db.QueryRow(ctx, query, secret.Release, secret.Key).Scan(&secret.ID, &secret.CreatedAt); err != nil {
+		log.Printf("repo.SigningSecret.Create: insert secret for %q: %v", secret.Release, err)
+		return fmt.Errorf("insert signing secret: %w", err)
+	}
+
+	return nil
+}
+
+// ListActive returns the secrets a release may currently sign with; more
+// than one while clients move to a new secret.
+func (r *signingSecretRepo) ListActive(ctx context.Context, release string) ([]models.SigningSecret, error) {
+	query := `SELECT ` + signingSecretColumns + ` FROM signing_secrets WHERE release = $1 AND revoked_at IS NULL ORDER BY id`
+	return r.list(ctx, "ListActive", query, release)
+}
+
+func (r *signingSecretRepo) List(ctx context.Context) ([]models.SigningSecret, error) {
+	query := `SELECT ` + signingSecretColumns + ` FROM signing_secrets ORDER BY id`
+	return r.list(ctx, "List", query)
+}
+
+func (r *signingSecretRepo) list(ctx context.Context, method, query string, args ...interface{}) ([]models.SigningSecret, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("repo.SigningSecret.%s: list secrets: %v", method, err)
+		return nil, fmt.Errorf("list signing secrets: %w", err)
+	}
+	defer rows.Close()
+
+	secrets := []models.SigningSecret{}
+	for rows.Next() {
+		secret, err := scanSigningSecret(rows)
+		if err != nil {
+			log.Printf("repo.SigningSecret.%s: scan secret: %v", method, err)
+			return nil, fmt.Errorf("scan signing secret: %w", err)
+		}
+		secrets = append(secrets, *secret)
+	}
+
+	return secrets, nil
+}
+
+// Revoke disables a secret immediately. It reports false if the secret does
+// not exist or is already revoked.
+func (r *signingSecretRepo) Revoke(ctx context.Context, id int64) (bool, error) {
+	tag, err := r.db.Exec(ctx, `UPDATE signing_secrets SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		log.Printf("repo.SigningSecret.Revoke: update secret %d: %v", id, err)
+		return false, fmt.Errorf("revoke signing secret: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func scanSigningSecret(row pgx.Row) (*models.SigningSecret, error) {
+	var secret models.SigningSecret
+	if err := row.Scan(&secret.ID, &secret.Release, &secret.Key, &secret.CreatedAt, &secret.RevokedAt); err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
@@ -0,0 +1,557 @@
+package spool
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/repo"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+const (
+	segmentExt = ".seg"
+	offsetFile = "offset"
+)
+
+// Writer stores replayed events. It is implemented by the event
+// repository's COPY path.
+type Writer interface {
+	CopyEvents(ctx context.Context, events []*models.Event) error
+}
+
+// Pinger reports whether the database is reachable again.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type Config struct {
+	Dir string
+	// SegmentSize rotates to a new segment file once the active one grows
+	// past this many bytes.
+	SegmentSize int64
+	// ReplayInterval is how often the replayer checks for spooled events.
+	ReplayInterval time.Duration
+	// ReplayBatchSize is the number of events written per COPY on replay.
+	ReplayBatchSize int
+}
+
+func (c Config) withDefaults() Config {
+	if c.SegmentSize <= 0 {
+		c.SegmentSize = 16 << 20
+	}
+	if c.ReplayInterval <= 0 {
+		c.ReplayInterval = 5 * time.Second
+	}
+	if c.ReplayBatchSize <= 0 {
+		c.ReplayBatchSize = 500
+	}
+	return c
+}
+
+// Spool is an append-only write-ahead log of events that could not be
+// written to the database. Events are appended as NDJSON to numbered
+// segment files; a background replayer copies them into the database once
+// it is reachable and records its progress in an offset file, which is
+// replaced atomically after every successful write. Delivery is
+// at-least-once: a crash between a write and the offset update replays
+// that chunk again.
+type Spool struct {
+	cfg    Config
+	writer Writer
+	pinger Pinger
+
+	// mu guards the active segment
+	mu         sync.Mutex
+	active     *os.File
+	activeSeq  uint64
+	activeSize int64
+	nextSeq    uint64
+
+	// replayMu ensures a single replay runs at a time
+	replayMu sync.Mutex
+
+	started  atomic.Bool
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+
+	spooled    atomic.Int64
+	replayed   atomic.Int64
+	discarded  atomic.Int64
+	lastReplay atomic.Pointer[time.Time]
+	lastError  atomic.Pointer[string]
+}
+
+// Open prepares the spool directory. Existing segments are kept for replay;
+// new events always go to a fresh segment so a torn write from a previous
+// crash can only ever be the last line of an old segment. Numbering never
+// goes back behind the replay offset, which would mark new segments as
+// replayed already.
+func Open(cfg Config, w Writer, p Pinger) (*Spool, error) {
+	cfg = cfg.withDefaults()
+	if cfg.Dir == "" {
+		return nil, errors.New("spool directory is required")
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create spool dir: %w", err)
+	}
+
+	s := &Spool{
+		cfg:     cfg,
+		writer:  w,
+		pinger:  p,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	segs, err := s.segments()
+	if err != nil {
+		return nil, err
+	}
+	if len(segs) > 0 {
+		s.nextSeq = segs[len(segs)-1] + 1
+	}
+
+	off, err := s.readOffset()
+	if err != nil {
+		return nil, err
+	}
+	if off.seq > s.nextSeq {
+		s.nextSeq = off.seq
+	}
+
+	return s, nil
+}
+
+// Append durably writes events to the active segment. It returns once the
+// data has been synced to disk.
+func (s *Spool) Append(events ...*models.Event) error {
+	var buf bytes.Buffer
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("marshal event: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active == nil || s.activeSize >= s.cfg.SegmentSize {
+		if err := s.rotateLocked(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.active.Write(buf.Bytes()); err != nil {
+		s.abortAppendLocked()
+		return fmt.Errorf("write spool segment: %w", err)
+	}
+	if err := s.active.Sync(); err != nil {
+		s.abortAppendLocked()
+		return fmt.Errorf("sync spool segment: %w", err)
+	}
+	s.activeSize += int64(buf.Len())
+
+	s.spooled.Add(int64(len(events)))
+	return nil
+}
+
+// abortAppendLocked drops what a failed append may have left in the active
+// segment, so that the next append does not continue a torn line. If the
+// segment cannot be cut back it is sealed instead; replay discards the torn
+// line at its end.
+func (s *Spool) abortAppendLocked() {
+	if err := s.active.Truncate(s.activeSize); err == nil {
+		return
+	}
+	log.Printf("spool.Append: cannot truncate segment %d, sealing it", s.activeSeq)
+	if err := s.sealLocked(); err != nil {
+		log.Printf("spool.Append: seal segment %d: %v", s.activeSeq, err)
+	}
+}
+
+// Start launches the background replayer.
+func (s *Spool) Start() {
+	if s.started.CompareAndSwap(false, true) {
+		go s.run()
+	}
+}
+
+// Close stops the replayer and closes the active segment.
+func (s *Spool) Close() error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	if s.started.Load() {
+		<-s.stopped
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sealLocked()
+}
+
+func (s *Spool) run() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.cfg.ReplayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), s.cfg.ReplayInterval*10)
+			if err := s.Replay(ctx); err != nil {
+				log.Printf("spool.run: replay: %v", err)
+			}
+			cancel()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Replay copies every spooled event into the database, oldest first. It
+// returns early without error when there is nothing to replay and with an
+// error when the database is still unreachable.
+func (s *Spool) Replay(ctx context.Context) error {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	// Seal the active segment so replay only ever reads immutable files.
+	// An empty one is left open; segments from it on are not replayed.
+	s.mu.Lock()
+	var err error
+	if s.active != nil && s.activeSize > 0 {
+		err = s.sealLocked()
+	}
+	limit := s.nextSeq
+	if s.active != nil {
+		limit = s.activeSeq
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	all, err := s.segments()
+	if err != nil {
+		return err
+	}
+	var segs []uint64
+	for _, seq := range all {
+		if seq < limit {
+			segs = append(segs, seq)
+		}
+	}
+	if len(segs) == 0 {
+		return nil
+	}
+
+	if err := s.pinger.Ping(ctx); err != nil {
+		return s.fail(fmt.Errorf("database unavailable: %w", err))
+	}
+
+	off, err := s.readOffset()
+	if err != nil {
+		return s.fail(err)
+	}
+
+	for _, seq := range segs {
+		if seq < off.seq {
+			// Fully replayed but not yet removed before a crash
+			os.Remove(s.segmentPath(seq))
+			continue
+		}
+
+		start := int64(0)
+		if seq == off.seq {
+			start = off.pos
+		}
+		if err := s.replaySegment(ctx, seq, start); err != nil {
+			return s.fail(err)
+		}
+
+		off = offset{seq: seq + 1}
+		if err := s.writeOffset(off); err != nil {
+			return s.fail(err)
+		}
+		if err := os.Remove(s.segmentPath(seq)); err != nil {
+			return s.fail(fmt.Errorf("remove replayed segment: %w", err))
+		}
+	}
+
+	now := time.Now().UTC()
+	s.lastReplay.Store(&now)
+	s.lastError.Store(nil)
+	return nil
+}
+
+func (s *Spool) replaySegment(ctx context.Context, seq uint64, start int64) error {
+	f, err := os.Open(s.segmentPath(seq))
+	if err != nil {
+		return fmt.Errorf("open segment: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return fmt.Errorf("seek segment: %w", err)
+	}
+
+	br := bufio.NewReader(f)
+	pos := start
+	batch := make([]*models.Event, 0, s.cfg.ReplayBatchSize)
+
+	for {
+		line, readErr := br.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return fmt.Errorf("read segment: %w", readErr)
+		}
+
+		if errors.Is(readErr, io.EOF) {
+			if len(line) > 0 {
+				// Torn write from a crash while appending; nothing after it
+				log.Printf("spool.replaySegment: discarding incomplete record at end of segment %d", seq)
+				s.discarded.Add(1)
+			}
+			break
+		}
+
+		pos += int64(len(line))
+
+		var event models.Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			log.Printf("spool.replaySegment: discarding corrupt record in segment %d: %v", seq, err)
+			s.discarded.Add(1)
+			continue
+		}
+		batch = append(batch, &event)
+
+		if len(batch) == s.cfg.ReplayBatchSize {
+			if err := s.write(ctx, batch); err != nil {
+				return err
+			}
+			if err := s.writeOffset(offset{seq: seq, pos: pos}); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	return s.write(ctx, batch)
+}
+
+// write stores a replayed chunk. If the database rejects the data of the
+// chunk, events are retried one by one and the ones it still rejects are
+// discarded so a single bad record cannot block the spool forever. Any other
+// error, such as a timeout, is returned so the chunk is replayed again; the
+// events of a chunk retried one by one may then be stored twice.
+func (s *Spool) write(ctx context.Context, batch []*models.Event) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	err := s.writer.CopyEvents(ctx, batch)
+	if err == nil {
+		s.replayed.Add(int64(len(batch)))
+		return nil
+	}
+	if !repo.IsDataError(err) {
+		return fmt.Errorf("write replayed events: %w", err)
+	}
+
+	for _, event := range batch {
+		if err := s.writer.CopyEvents(ctx, []*models.Event{event}); err != nil {
+			if !repo.IsDataError(err) {
+				return fmt.Errorf("write replayed event: %w", err)
+			}
+			log.Printf("spool.write: discarding event %q: %v", event.EventName, err)
+			s.discarded.Add(1)
+			continue
+		}
+		s.replayed.Add(1)
+	}
+	return nil
+}
+
+func (s *Spool) fail(err error) error {
+	msg := err.Error()
+	s.lastError.Store(&msg)
+	return err
+}
+
+func (s *Spool) Stats() models.SpoolStats {
+	stats := models.SpoolStats{
+		Dir:          s.cfg.Dir,
+		Spooled:      s.spooled.Load(),
+		Replayed:     s.replayed.Load(),
+		Discarded:    s.discarded.Load(),
+		LastReplayAt: s.lastReplay.Load(),
+	}
+	if msg := s.lastError.Load(); msg != nil {
+		stats.LastError = *msg
+	}
+
+	segs, err := s.segments()
+	if err != nil {
+		stats.LastError = err.Error()
+		return stats
+	}
+	off, _ := s.readOffset()
+	for _, seq := range segs {
+		info, err := os.Stat(s.segmentPath(seq))
+		if err != nil {
+			continue
+		}
+		size := info.Size()
+		if seq == off.seq {
+			size -= off.pos
+		}
+		stats.PendingBytes += size
+		stats.Segments++
+	}
+	return stats
+}
+
+func (s *Spool) rotateLocked() error {
+	if err := s.sealLocked(); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.segmentPath(s.nextSeq), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("create spool segment: %w", err)
+	}
+	if err := syncDir(s.cfg.Dir); err != nil {
+		f.Close()
+		return err
+	}
+
+	s.active = f
+	s.activeSeq = s.nextSeq
+	s.activeSize = 0
+	s.nextSeq++
+	return nil
+}
+
+// sealLocked closes the active segment; the next Append starts a new one.
+// Empty segments are removed right away.
+func (s *Spool) sealLocked() error {
+	if s.active == nil {
+		return nil
+	}
+
+	err := s.active.Close()
+	if s.activeSize == 0 {
+		os.Remove(s.segmentPath(s.activeSeq))
+	}
+	s.active = nil
+	if err != nil {
+		return fmt.Errorf("close spool segment: %w", err)
+	}
+	return nil
+}
+
+// segments lists segment sequence numbers in ascending order.
+func (s *Spool) segments() ([]uint64, error) {
+	entries, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("read spool dir: %w", err)
+	}
+
+	var segs []uint64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		segs = append(segs, seq)
+	}
+	sort.Slice(segs, func(i, j int) bool { return segs[i] < segs[j] })
+	return segs, nil
+}
+
+func (s *Spool) segmentPath(seq uint64) string {
+	return filepath.Join(s.cfg.Dir, fmt.Sprintf("%020d%s", seq, segmentExt))
+}
+
+// offset is the replay position: the segment being replayed and the byte
+// position of the first record not yet stored.
+type offset struct {
+	seq uint64
+	pos int64
+}
+
+func (s *Spool) readOffset() (offset, error) {
+	data, err := os.ReadFile(filepath.Join(s.cfg.Dir, offsetFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return offset{}, nil
+	}
+	if err != nil {
+		return offset{}, fmt.Errorf("read spool offset: %w", err)
+	}
+
+	var off offset
+	if _, err := fmt.Sscanf(string(data), "%d %d", &off.seq, &off.pos); err != nil {
+		return offset{}, fmt.Errorf("parse spool offset: %w", err)
+	}
+	return off, nil
+}
+
+// writeOffset replaces the offset file atomically via rename so a crash
+// leaves either the old or the new offset, never a partial one.
+func (s *Spool) writeOffset(off offset) error {
+	path := filepath.Join(s.cfg.Dir, offsetFile)
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o640)
+	if err != nil {
+		return fmt.Errorf("write spool offset: %w", err)
+	}
+	if _, err := fmt.Fprintf(f, "%d %d\n", off.seq, off.pos); err != nil {
+		f.Close()
+		return fmt.Errorf("write spool offset: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync spool offset: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close spool offset: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename spool offset: %w", err)
+	}
+	return syncDir(s.cfg.Dir)
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("open spool dir: %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("sync spool dir: %w", err)
+	}
+	return nil
+}
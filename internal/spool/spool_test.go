@@ -0,0 +1,266 @@
+package spool
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDB acts as both writer and pinger
+type fakeDB struct {
+	mu      sync.Mutex
+	written []string
+	down    bool
+	reject  string
+	// fail is returned by every copy while the database stays reachable
+	fail error
+}
+
+func (d *fakeDB) CopyEvents(ctx context.Context, events []*models.Event) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.down {
+		return errors.New("connection refused")
+	}
+	if d.fail != nil {
+		return d.fail
+	}
+	for _, e := range events {
+		if e.EventName == d.reject {
+			return &pgconn.PgError{Code: "22P02", Message: "invalid input"}
+		}
+	}
+	for _, e := range events {
+		d.written = append(d.written, e.EventName)
+	}
+	return nil
+}
+
+func (d *fakeDB) Ping(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.down {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func events(names ...string) []*models.Event {
+	out := make([]*models.Event, len(names))
+	for i, name := range names {
+		out[i] = &models.Event{EventName: name}
+	}
+	return out
+}
+
+func TestSpool_AppendAndReplay(t *testing.T) {
+	db := &fakeDB{}
+	s, err := Open(Config{Dir: t.TempDir()}, db, db)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Append(events("a", "b")...))
+	require.NoError(t, s.Append(events("c")...))
+	assert.Equal(t, int64(3), s.Stats().Spooled)
+	assert.Positive(t, s.Stats().PendingBytes)
+
+	require.NoError(t, s.Replay(context.Background()))
+
+	assert.Equal(t, []string{"a", "b", "c"}, db.written)
+	stats := s.Stats()
+	assert.Equal(t, int64(3), stats.Replayed)
+	assert.Equal(t, 0, stats.Segments)
+	assert.Zero(t, stats.PendingBytes)
+	assert.NotNil(t, stats.LastReplayAt)
+}
+
+func TestSpool_ReplayWaitsForDatabase(t *testing.T) {
+	db := &fakeDB{down: true}
+	s, err := Open(Config{Dir: t.TempDir()}, db, db)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Append(events("a")...))
+
+	assert.Error(t, s.Replay(context.Background()))
+	assert.Empty(t, db.written)
+	assert.NotEmpty(t, s.Stats().LastError)
+
+	db.down = false
+	require.NoError(t, s.Replay(context.Background()))
+	assert.Equal(t, []string{"a"}, db.written)
+	assert.Empty(t, s.Stats().LastError)
+}
+
+func TestSpool_ResumesFromOffset(t *testing.T) {
+	dir := t.TempDir()
+	db := &fakeDB{}
+	s, err := Open(Config{Dir: dir, ReplayBatchSize: 2}, db, db)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Append(events("a", "b", "c", "d")...))
+
+	// The first chunk is stored, then the connection drops
+	s.writer = &failAfter{db: db, ok: 1}
+
+	assert.Error(t, s.Replay(context.Background()))
+	assert.Equal(t, []string{"a", "b"}, db.written)
+
+	// Simulate a restart: the offset file must skip the stored chunk
+	require.NoError(t, s.Close())
+	db.down = false
+	s, err = Open(Config{Dir: dir, ReplayBatchSize: 2}, db, db)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Replay(context.Background()))
+	assert.Equal(t, []string{"a", "b", "c", "d"}, db.written)
+}
+
+// failAfter lets ok calls through and then takes the database down
+type failAfter struct {
+	db *fakeDB
+	ok int
+}
+
+func (f *failAfter) CopyEvents(ctx context.Context, events []*models.Event) error {
+	if f.ok == 0 {
+		f.db.mu.Lock()
+		f.db.down = true
+		f.db.mu.Unlock()
+	}
+	f.ok--
+	return f.db.CopyEvents(ctx, events)
+}
+
+func TestSpool_DiscardsTornAndRejectedRecords(t *testing.T) {
+	dir := t.TempDir()
+	db := &fakeDB{reject: "bad"}
+	s, err := Open(Config{Dir: dir}, db, db)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Append(events("a", "bad", "b")...))
+
+	// A crash in the middle of an append leaves an incomplete last line
+	f, err := os.OpenFile(s.segmentPath(s.activeSeq), os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"event_name":"tor`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	require.NoError(t, s.Replay(context.Background()))
+
+	assert.Equal(t, []string{"a", "b"}, db.written)
+	assert.Equal(t, int64(2), s.Stats().Discarded)
+}
+
+func TestSpool_KeepsEventsOnTransientWriteError(t *testing.T) {
+	db := &fakeDB{fail: &pgconn.PgError{Code: "57014", Message: "canceling statement due to statement timeout"}}
+	s, err := Open(Config{Dir: t.TempDir()}, db, db)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Append(events("a", "b")...))
+	assert.Error(t, s.Replay(context.Background()))
+	assert.Zero(t, s.Stats().Discarded)
+
+	db.fail = nil
+	require.NoError(t, s.Replay(context.Background()))
+	assert.Equal(t, []string{"a", "b"}, db.written)
+}
+
+func TestSpool_FailedAppendLeavesNoTornLine(t *testing.T) {
+	db := &fakeDB{}
+	s, err := Open(Config{Dir: t.TempDir()}, db, db)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Append(events("a")...))
+
+	// A write that stopped halfway is cut back off the segment
+	s.mu.Lock()
+	_, err = s.active.Write([]byte(`{"event_name":"tor`))
+	require.NoError(t, err)
+	s.abortAppendLocked()
+	s.mu.Unlock()
+	require.NoError(t, s.Append(events("b")...))
+
+	// A segment that cannot be written to any more is sealed instead
+	s.mu.Lock()
+	require.NoError(t, s.active.Close())
+	s.mu.Unlock()
+	assert.Error(t, s.Append(events("lost")...))
+	require.NoError(t, s.Append(events("c")...))
+
+	require.NoError(t, s.Replay(context.Background()))
+	assert.Equal(t, []string{"a", "b", "c"}, db.written)
+	assert.Zero(t, s.Stats().Discarded)
+}
+
+func TestOpen_ContinuesSegmentSequence(t *testing.T) {
+	dir := t.TempDir()
+	db := &fakeDB{down: true}
+
+	s, err := Open(Config{Dir: dir}, db, db)
+	require.NoError(t, err)
+	require.NoError(t, s.Append(events("a")...))
+	require.NoError(t, s.Close())
+
+	s, err = Open(Config{Dir: dir}, db, db)
+	require.NoError(t, err)
+	require.NoError(t, s.Append(events("b")...))
+	assert.Equal(t, 2, s.Stats().Segments)
+
+	db.down = false
+	require.NoError(t, s.Replay(context.Background()))
+	assert.Equal(t, []string{"a", "b"}, db.written)
+}
+
+func TestOpen_AfterFullReplay(t *testing.T) {
+	dir := t.TempDir()
+	db := &fakeDB{}
+
+	s, err := Open(Config{Dir: dir}, db, db)
+	require.NoError(t, err)
+	require.NoError(t, s.Append(events("a")...))
+	require.NoError(t, s.Replay(context.Background()))
+	require.NoError(t, s.Close())
+
+	// Only the offset file is left; new segments must not be taken for
+	// replayed ones
+	s, err = Open(Config{Dir: dir}, db, db)
+	require.NoError(t, err)
+	require.NoError(t, s.Append(events("b")...))
+	require.NoError(t, s.Replay(context.Background()))
+	require.NoError(t, s.Close())
+
+	assert.Equal(t, []string{"a", "b"}, db.written)
+}
+
+func TestSpool_ReplayKeepsEmptyActiveSegment(t *testing.T) {
+	dir := t.TempDir()
+	db := &fakeDB{}
+	s, err := Open(Config{Dir: dir}, db, db)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Append(events("a")...))
+	require.NoError(t, s.Replay(context.Background()))
+
+	// Nothing appended since: the replay must not rotate segments
+	s.mu.Lock()
+	require.NoError(t, s.rotateLocked())
+	seq := s.activeSeq
+	s.mu.Unlock()
+	require.NoError(t, s.Replay(context.Background()))
+
+	s.mu.Lock()
+	assert.Equal(t, seq, s.activeSeq)
+	assert.NotNil(t, s.active)
+	s.mu.Unlock()
+
+	require.NoError(t, s.Append(events("b")...))
+	require.NoError(t, s.Replay(context.Background()))
+	assert.Equal(t, []string{"a", "b"}, db.written)
+}
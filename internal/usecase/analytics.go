@@ -2,15 +2,75 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/herpiko/blankon-telemetry-backend/internal/repo"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+var ErrInvalidAnalyticsQuery = errors.New("invalid analytics query")
+
+const (
+	// DefaultPayloadSample is how many recent events DescribePayload looks
+	// at unless asked otherwise; MaxPayloadSample caps it.
+	DefaultPayloadSample = 1000
+	MaxPayloadSample     = 10000
+	// maxDescribedKeys bounds the response for payloads with generated
+	// key names, and maxDescribedDepth how far nested objects are followed.
+	maxDescribedKeys  = 500
+	maxDescribedDepth = 8
+	maxExamples       = 3
+	maxExampleLength  = 100
+	// DefaultBreakdownTop and MaxBreakdownTop bound how many values a
+	// breakdown reports on their own.
+	DefaultBreakdownTop = 10
+	MaxBreakdownTop     = 100
+	// MaxBuckets bounds the number of buckets one query may return.
+	MaxBuckets = 1000
+	// DefaultRetentionPeriods and MaxRetentionPeriods bound how many
+	// periods cohorts are followed for, MaxRetentionCohorts how many
+	// cohorts one matrix may hold.
+	DefaultRetentionPeriods = 8
+	MaxRetentionPeriods     = 52
+	MaxRetentionCohorts     = 104
+	// MaxFunnelSteps bounds the length of a funnel.
+	MaxFunnelSteps = 10
+	// DefaultHistogramBins and MaxHistogramBins bound the bins of a
+	// histogram, MaxMetricAggs the aggregates of one metric query.
+	DefaultHistogramBins = 10
+	MaxHistogramBins     = 100
+	MaxMetricAggs        = 10
+)
+
+// DefaultMetricAggs are computed when a metric query names none.
+var DefaultMetricAggs = []string{"p50", "p90", "p99"}
+
+// Retention defaults: installs are followed from their first install or
+// first boot and count as retained when they launch an application.
+var (
+	DefaultRetentionStartEvents = []string{"install", "first_boot"}
+	DefaultRetentionReturnEvent = "app_launch"
 )
 
 type AnalyticsUsecase interface {
-	GetHourlyStats(ctx context.Context, eventName string, from, to time.Time) ([]repo.EventStats, error)
-	GetDailyStats(ctx context.Context, eventName string, from, to time.Time) ([]repo.EventStats, error)
+	GetHourlyStats(ctx context.Context, eventName string, from, to time.Time, compare string) ([]repo.EventStats, error)
+	GetDailyStats(ctx context.Context, eventName string, from, to time.Time, loc *time.Location, compare string) ([]repo.EventStats, error)
+	ListEventNames(ctx context.Context, from, to time.Time) ([]models.EventNameSummary, error)
+	DescribePayload(ctx context.Context, eventName string, from, to time.Time, sample int) (*models.PayloadDescription, error)
+	GetBreakdown(ctx context.Context, q models.BreakdownQuery) (*models.BreakdownResponse, error)
+	GetTimeseries(ctx context.Context, q models.TimeseriesQuery) (*models.TimeseriesResponse, error)
+	GetActiveUsers(ctx context.Context, from, to time.Time) (*models.ActiveUsersResponse, error)
+	GetUniqueUsers(ctx context.Context, eventName string, from, to time.Time) (*models.UniqueUsersResponse, error)
+	GetRetention(ctx context.Context, q models.RetentionQuery) (*models.RetentionResponse, error)
+	GetFunnel(ctx context.Context, q models.FunnelQuery) (*models.FunnelResponse, error)
+	GetMetric(ctx context.Context, q models.MetricQuery) (*models.MetricResponse, error)
+	GetHistogram(ctx context.Context, q models.HistogramQuery) (*models.HistogramResponse, error)
+	GetSessionStats(ctx context.Context, q models.SessionQuery) (*models.SessionStatsResponse, error)
 }
 
 type analyticsUsecase struct {
@@ -21,7 +81,13 @@ func NewAnalyticsUsecase(repo repo.AnalyticsRepository) AnalyticsUsecase {
 	return &analyticsUsecase{repo: repo}
 }
 
-func (u *analyticsUsecase) GetHourlyStats(ctx context.Context, eventName string, from, to time.Time) ([]repo.EventStats, error) {
+// GetHourlyStats returns per hour stats, each compared with the aligned hour
+// of an earlier period if compare is set.
+func (u *analyticsUsecase) GetHourlyStats(ctx context.Context, eventName string, from, to time.Time, compare string) ([]repo.EventStats, error) {
+	if err := checkCompare(compare); err != nil {
+		return nil, err
+	}
+
 	// Default to last 24 hours if not specified
 	if from.IsZero() {
 		from = time.Now().UTC().Add(-24 * time.Hour)
@@ -35,10 +101,27 @@ func (u *analyticsUsecase) GetHourlyStats(ctx context.Context, eventName string,
 		log.Printf("usecase.GetHourlyStats: repo.GetHourlyStats failed: %v", err)
 		return nil, err
 	}
+
+	if compare != "" {
+		prevFrom, prevTo, shift := comparedRange(compare, hourInterval, nil, from, to)
+		prev, err := u.repo.GetHourlyStats(ctx, eventName, prevFrom, prevTo)
+		if err != nil {
+			log.Printf("usecase.GetHourlyStats: repo.GetHourlyStats of compared period failed: %v", err)
+			return nil, err
+		}
+		compareStats(stats, prev, shift)
+	}
 	return stats, nil
 }
 
-func (u *analyticsUsecase) GetDailyStats(ctx context.Context, eventName string, from, to time.Time) ([]repo.EventStats, error) {
+// GetDailyStats returns per day stats with days starting on the midnight of
+// loc, nil meaning UTC, each compared with the aligned day of an earlier
+// period if compare is set.
+func (u *analyticsUsecase) GetDailyStats(ctx context.Context, eventName string, from, to time.Time, loc *time.Location, compare string) ([]repo.EventStats, error) {
+	if err := checkCompare(compare); err != nil {
+		return nil, err
+	}
+
 	// Default to last 30 days if not specified
 	if from.IsZero() {
 		from = time.Now().UTC().Add(-30 * 24 * time.Hour)
@@ -47,10 +130,560 @@ func (u *analyticsUsecase) GetDailyStats(ctx context.Context, eventName string,
 		to = time.Now().UTC()
 	}
 
-	stats, err := u.repo.GetDailyStats(ctx, eventName, from, to)
+	stats, err := u.repo.GetDailyStats(ctx, eventName, from, to, loc)
 	if err != nil {
 		log.Printf("usecase.GetDailyStats: repo.GetDailyStats failed: %v", err)
 		return nil, err
 	}
+
+	if compare != "" {
+		prevFrom, prevTo, shift := comparedRange(compare, dayInterval, loc, from, to)
+		prev, err := u.repo.GetDailyStats(ctx, eventName, prevFrom, prevTo, loc)
+		if err != nil {
+			log.Printf("usecase.GetDailyStats: repo.GetDailyStats of compared period failed: %v", err)
+			return nil, err
+		}
+		compareStats(stats, prev, shift)
+	}
 	return stats, nil
 }
+
+// GetBreakdown groups an event's counts by a payload field. It defaults to
+// daily buckets over the last 7 days.
+func (u *analyticsUsecase) GetBreakdown(ctx context.Context, q models.BreakdownQuery) (*models.BreakdownResponse, error) {
+	if q.EventName == "" {
+		return nil, fmt.Errorf("%w: event_name is required", ErrInvalidAnalyticsQuery)
+	}
+	if len(q.Path) == 0 {
+		return nil, fmt.Errorf("%w: by is required", ErrInvalidAnalyticsQuery)
+	}
+	if q.Interval == (models.Interval{}) {
+		q.Interval = models.Interval{Count: 1, Unit: "d"}
+	}
+	if q.To.IsZero() {
+		q.To = time.Now().UTC()
+	}
+	if q.From.IsZero() {
+		q.From = q.To.Add(-7 * 24 * time.Hour)
+	}
+	if err := checkBuckets(q.From, q.To, q.Interval); err != nil {
+		return nil, err
+	}
+	if q.Top <= 0 {
+		q.Top = DefaultBreakdownTop
+	}
+	if q.Top > MaxBreakdownTop {
+		q.Top = MaxBreakdownTop
+	}
+
+	rows, err := u.repo.GetBreakdown(ctx, q)
+	if err != nil {
+		log.Printf("usecase.GetBreakdown: repo.GetBreakdown failed: %v", err)
+		return nil, err
+	}
+
+	return &models.BreakdownResponse{
+		EventName: q.EventName,
+		By:        "payload." + strings.Join(q.Path, "."),
+		Interval:  q.Interval.String(),
+		From:      q.From,
+		To:        q.To,
+		Timezone:  models.TimezoneName(q.Location),
+		Rows:      rows,
+	}, nil
+}
+
+// GetTimeseries returns a zero-filled series of event counts. It defaults
+// to hourly buckets over the last 24 hours.
+func (u *analyticsUsecase) GetTimeseries(ctx context.Context, q models.TimeseriesQuery) (*models.TimeseriesResponse, error) {
+	if q.Interval == (models.Interval{}) {
+		q.Interval = models.Interval{Count: 1, Unit: "h"}
+	}
+	if q.To.IsZero() {
+		q.To = time.Now().UTC()
+	}
+	if q.From.IsZero() {
+		q.From = q.To.Add(-24 * time.Hour)
+	}
+	if err := checkBuckets(q.From, q.To, q.Interval); err != nil {
+		return nil, err
+	}
+	if err := checkCompare(q.Compare); err != nil {
+		return nil, err
+	}
+
+	source := timeseriesSource(q.Interval, q.Location)
+	points, err := u.repo.GetTimeseries(ctx, q, source)
+	if err != nil {
+		log.Printf("usecase.GetTimeseries: repo.GetTimeseries failed: %v", err)
+		return nil, err
+	}
+
+	if q.Compare != "" {
+		prevQ := q
+		var shift func(time.Time) time.Time
+		prevQ.From, prevQ.To, shift = comparedRange(q.Compare, q.Interval, q.Location, q.From, q.To)
+		prev, err := u.repo.GetTimeseries(ctx, prevQ, source)
+		if err != nil {
+			log.Printf("usecase.GetTimeseries: repo.GetTimeseries of compared period failed: %v", err)
+			return nil, err
+		}
+		comparePoints(points, prev, shift)
+	}
+
+	resp := &models.TimeseriesResponse{
+		EventName: q.EventName,
+		Interval:  q.Interval.String(),
+		From:      q.From,
+		To:        q.To,
+		Timezone:  models.TimezoneName(q.Location),
+		Source:    source,
+		Compare:   q.Compare,
+		Points:    points,
+	}
+	if source != models.SourceEvents {
+		resp.UniqueUsersError = models.HLLStdError
+	}
+	return resp, nil
+}
+
+// GetActiveUsers reports rolling DAU, WAU and MAU for every UTC day in the
+// range, which is widened to whole days. It defaults to the last 30 days
+// including today.
+func (u *analyticsUsecase) GetActiveUsers(ctx context.Context, from, to time.Time) (*models.ActiveUsersResponse, error) {
+	day := 24 * time.Hour
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if t := to.UTC().Truncate(day); t.Before(to) {
+		to = t.Add(day)
+	}
+	if from.IsZero() {
+		from = to.Add(-30 * day)
+	}
+	from = from.UTC().Truncate(day)
+	if err := checkBuckets(from, to, models.Interval{Count: 1, Unit: "d"}); err != nil {
+		return nil, err
+	}
+
+	days, err := u.repo.GetActiveUsers(ctx, from, to)
+	if err != nil {
+		log.Printf("usecase.GetActiveUsers: repo.GetActiveUsers failed: %v", err)
+		return nil, err
+	}
+
+	for i := range days {
+		days[i].DAUOverWAU = ratio(days[i].DAU, days[i].WAU)
+		days[i].DAUOverMAU = ratio(days[i].DAU, days[i].MAU)
+	}
+
+	return &models.ActiveUsersResponse{From: from, To: to, Days: days}, nil
+}
+
+// GetUniqueUsers estimates the distinct users over a range by merging the
+// sketches of the hourly aggregate. The range is widened to whole hours and
+// defaults to the last 28 days.
+func (u *analyticsUsecase) GetUniqueUsers(ctx context.Context, eventName string, from, to time.Time) (*models.UniqueUsersResponse, error) {
+	if to.IsZero() {
+		to = time.Now().UTC()
+	}
+	if t := to.UTC().Truncate(time.Hour); t.Before(to) {
+		to = t.Add(time.Hour)
+	}
+	if from.IsZero() {
+		from = to.Add(-28 * 24 * time.Hour)
+	}
+	from = from.UTC().Truncate(time.Hour)
+	if !to.After(from) {
+		return nil, fmt.Errorf("%w: to must be after from", ErrInvalidAnalyticsQuery)
+	}
+
+	users, err := u.repo.GetUniqueUsers(ctx, eventName, from, to)
+	if err != nil {
+		log.Printf("usecase.GetUniqueUsers: repo.GetUniqueUsers failed: %v", err)
+		return nil, err
+	}
+
+	return &models.UniqueUsersResponse{
+		EventName:   eventName,
+		From:        from,
+		To:          to,
+		UniqueUsers: users,
+		StdError:    models.HLLStdError,
+	}, nil
+}
+
+// GetRetention builds a cohort retention matrix. It defaults to weekly
+// cohorts followed for DefaultRetentionPeriods weeks, with cohorts over as
+// many weeks back.
+func (u *analyticsUsecase) GetRetention(ctx context.Context, q models.RetentionQuery) (*models.RetentionResponse, error) {
+	if len(q.StartEvents) == 0 {
+		q.StartEvents = DefaultRetentionStartEvents
+	}
+	if q.ReturnEvent == "" {
+		q.ReturnEvent = DefaultRetentionReturnEvent
+	}
+	if q.Granularity == (models.Interval{}) {
+		q.Granularity = models.Interval{Count: 1, Unit: "w"}
+	}
+	if q.Granularity.Count != 1 || (q.Granularity.Unit != "d" && q.Granularity.Unit != "w" && q.Granularity.Unit != "mo") {
+		return nil, fmt.Errorf("%w: granularity must be 1d, 1w or 1mo", ErrInvalidAnalyticsQuery)
+	}
+	if q.Periods <= 0 {
+		q.Periods = DefaultRetentionPeriods
+	}
+	if q.Periods > MaxRetentionPeriods {
+		return nil, fmt.Errorf("%w: at most %d periods", ErrInvalidAnalyticsQuery, MaxRetentionPeriods)
+	}
+	if q.To.IsZero() {
+		q.To = time.Now().UTC()
+	}
+	if q.From.IsZero() {
+		q.From = q.Granularity.AddTo(q.To, -q.Periods)
+	}
+	if !q.To.After(q.From) {
+		return nil, fmt.Errorf("%w: to must be after from", ErrInvalidAnalyticsQuery)
+	}
+	if q.To.Sub(q.From)/q.Granularity.Approx() > MaxRetentionCohorts {
+		return nil, fmt.Errorf("%w: more than %d cohorts", ErrInvalidAnalyticsQuery, MaxRetentionCohorts)
+	}
+
+	cohorts, err := u.repo.GetRetention(ctx, q)
+	if err != nil {
+		log.Printf("usecase.GetRetention: repo.GetRetention failed: %v", err)
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range cohorts {
+		c := &cohorts[i]
+		c.Rates = make([]*float64, len(c.Retained))
+		for n, retained := range c.Retained {
+			if retained == nil || q.Granularity.AddTo(c.Cohort.UTC(), n).After(now) {
+				c.Retained[n] = nil
+				continue
+			}
+			rate := ratio(*retained, c.Size)
+			c.Rates[n] = &rate
+		}
+	}
+
+	return &models.RetentionResponse{
+		StartEvents: q.StartEvents,
+		ReturnEvent: q.ReturnEvent,
+		Granularity: q.Granularity.String(),
+		Periods:     q.Periods,
+		From:        q.From,
+		To:          q.To,
+		Cohorts:     cohorts,
+	}, nil
+}
+
+// GetFunnel measures how installs progress through q.Steps. It defaults to
+// a one day conversion window and installs entering in the last 7 days.
+func (u *analyticsUsecase) GetFunnel(ctx context.Context, q models.FunnelQuery) (*models.FunnelResponse, error) {
+	if len(q.Steps) < 2 || len(q.Steps) > MaxFunnelSteps {
+		return nil, fmt.Errorf("%w: a funnel needs 2 to %d steps", ErrInvalidAnalyticsQuery, MaxFunnelSteps)
+	}
+	for _, step := range q.Steps {
+		if step == "" {
+			return nil, fmt.Errorf("%w: step names must not be empty", ErrInvalidAnalyticsQuery)
+		}
+	}
+	if q.Window == (models.Interval{}) {
+		q.Window = models.Interval{Count: 1, Unit: "d"}
+	}
+	if q.To.IsZero() {
+		q.To = time.Now().UTC()
+	}
+	if q.From.IsZero() {
+		q.From = q.To.Add(-7 * 24 * time.Hour)
+	}
+	if !q.To.After(q.From) {
+		return nil, fmt.Errorf("%w: to must be after from", ErrInvalidAnalyticsQuery)
+	}
+	if q.Top <= 0 {
+		q.Top = DefaultBreakdownTop
+	}
+	if q.Top > MaxBreakdownTop {
+		q.Top = MaxBreakdownTop
+	}
+
+	groups, err := u.repo.GetFunnel(ctx, q)
+	if err != nil {
+		log.Printf("usecase.GetFunnel: repo.GetFunnel failed: %v", err)
+		return nil, err
+	}
+
+	for _, group := range groups {
+		entered := group.Steps[0].Users
+		for n := range group.Steps {
+			step := &group.Steps[n]
+			step.OverallRate = ratio(step.Users, entered)
+			step.ConversionRate = 1
+			if n > 0 {
+				step.ConversionRate = ratio(step.Users, group.Steps[n-1].Users)
+			}
+		}
+	}
+
+	resp := &models.FunnelResponse{
+		Steps:  q.Steps,
+		Window: q.Window.String(),
+		From:   q.From,
+		To:     q.To,
+		Groups: groups,
+	}
+	if len(q.By) > 0 {
+		resp.By = "payload." + strings.Join(q.By, ".")
+	}
+	return resp, nil
+}
+
+// GetMetric aggregates a numeric payload field per bucket. It defaults to
+// DefaultMetricAggs in hourly buckets over the last 24 hours.
+func (u *analyticsUsecase) GetMetric(ctx context.Context, q models.MetricQuery) (*models.MetricResponse, error) {
+	if q.EventName == "" {
+		return nil, fmt.Errorf("%w: event_name is required", ErrInvalidAnalyticsQuery)
+	}
+	if len(q.Path) == 0 {
+		return nil, fmt.Errorf("%w: field is required", ErrInvalidAnalyticsQuery)
+	}
+	if len(q.Aggs) == 0 {
+		q.Aggs = DefaultMetricAggs
+	}
+	if len(q.Aggs) > MaxMetricAggs {
+		return nil, fmt.Errorf("%w: at most %d aggregates", ErrInvalidAnalyticsQuery, MaxMetricAggs)
+	}
+	if q.Interval == (models.Interval{}) {
+		q.Interval = models.Interval{Count: 1, Unit: "h"}
+	}
+	if q.To.IsZero() {
+		q.To = time.Now().UTC()
+	}
+	if q.From.IsZero() {
+		q.From = q.To.Add(-24 * time.Hour)
+	}
+	if err := checkBuckets(q.From, q.To, q.Interval); err != nil {
+		return nil, err
+	}
+
+	points, err := u.repo.GetMetric(ctx, q)
+	if err != nil {
+		log.Printf("usecase.GetMetric: repo.GetMetric failed: %v", err)
+		return nil, err
+	}
+
+	return &models.MetricResponse{
+		EventName: q.EventName,
+		Field:     "payload." + strings.Join(q.Path, "."),
+		Aggs:      q.Aggs,
+		Interval:  q.Interval.String(),
+		From:      q.From,
+		To:        q.To,
+		Timezone:  models.TimezoneName(q.Location),
+		Points:    points,
+	}, nil
+}
+
+// GetHistogram distributes a numeric payload field over equal-width bins.
+// It defaults to DefaultHistogramBins bins over the last 24 hours.
+func (u *analyticsUsecase) GetHistogram(ctx context.Context, q models.HistogramQuery) (*models.HistogramResponse, error) {
+	if q.EventName == "" {
+		return nil, fmt.Errorf("%w: event_name is required", ErrInvalidAnalyticsQuery)
+	}
+	if len(q.Path) == 0 {
+		return nil, fmt.Errorf("%w: field is required", ErrInvalidAnalyticsQuery)
+	}
+	if q.Bins <= 0 {
+		q.Bins = DefaultHistogramBins
+	}
+	if q.Bins > MaxHistogramBins {
+		return nil, fmt.Errorf("%w: at most %d bins", ErrInvalidAnalyticsQuery, MaxHistogramBins)
+	}
+	if q.Min != nil && q.Max != nil && *q.Max <= *q.Min {
+		return nil, fmt.Errorf("%w: max must be greater than min", ErrInvalidAnalyticsQuery)
+	}
+	if q.To.IsZero() {
+		q.To = time.Now().UTC()
+	}
+	if q.From.IsZero() {
+		q.From = q.To.Add(-24 * time.Hour)
+	}
+	if !q.To.After(q.From) {
+		return nil, fmt.Errorf("%w: to must be after from", ErrInvalidAnalyticsQuery)
+	}
+
+	hist, err := u.repo.GetHistogram(ctx, q)
+	if errors.Is(err, repo.ErrEmptyHistogramRange) {
+		return nil, fmt.Errorf("%w: no values between min and max", ErrInvalidAnalyticsQuery)
+	}
+	if err != nil {
+		log.Printf("usecase.GetHistogram: repo.GetHistogram failed: %v", err)
+		return nil, err
+	}
+
+	hist.EventName = q.EventName
+	hist.Field = "payload." + strings.Join(q.Path, ".")
+	hist.From = q.From
+	hist.To = q.To
+	return hist, nil
+}
+
+func ratio(n, d int64) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+// timeseriesSource picks the coarsest continuous aggregate whose buckets
+// add up to interval in loc, falling back to raw events. Daily aggregates
+// only exist for UTC and Asia/Jakarta; other zones roll up hours as long as
+// their hours line up with UTC hours.
+func timeseriesSource(interval models.Interval, loc *time.Location) string {
+	switch interval.Unit {
+	case "d", "w", "mo":
+		switch models.TimezoneName(loc) {
+		case "UTC":
+			return models.SourceDaily
+		case models.JakartaTimezone:
+			return models.SourceDailyJakarta
+		}
+	}
+	if interval.Approx()%time.Hour == 0 && models.WholeHourOffsets(loc) {
+		return models.SourceHourly
+	}
+	return models.SourceEvents
+}
+
+// checkBuckets rejects ranges that are empty or would produce more than
+// MaxBuckets buckets of interval.
+func checkBuckets(from, to time.Time, interval models.Interval) error {
+	if !to.After(from) {
+		return fmt.Errorf("%w: to must be after from", ErrInvalidAnalyticsQuery)
+	}
+	if to.Sub(from)/interval.Approx() > MaxBuckets {
+		return fmt.Errorf("%w: more than %d buckets of %s, use a larger interval", ErrInvalidAnalyticsQuery, MaxBuckets, interval)
+	}
+	return nil
+}
+
+func (u *analyticsUsecase) ListEventNames(ctx context.Context, from, to time.Time) ([]models.EventNameSummary, error) {
+	names, err := u.repo.ListEventNames(ctx, from, to)
+	if err != nil {
+		log.Printf("usecase.ListEventNames: repo.ListEventNames failed: %v", err)
+		return nil, err
+	}
+	return names, nil
+}
+
+// DescribePayload infers the payload keys of an event name from its most
+// recent events.
+func (u *analyticsUsecase) DescribePayload(ctx context.Context, eventName string, from, to time.Time, sample int) (*models.PayloadDescription, error) {
+	if eventName == "" {
+		return nil, fmt.Errorf("%w: event_name is required", ErrInvalidAnalyticsQuery)
+	}
+	if sample <= 0 {
+		sample = DefaultPayloadSample
+	}
+	if sample > MaxPayloadSample {
+		sample = MaxPayloadSample
+	}
+
+	payloads, err := u.repo.SamplePayloads(ctx, eventName, from, to, sample)
+	if err != nil {
+		log.Printf("usecase.DescribePayload: repo.SamplePayloads failed: %v", err)
+		return nil, err
+	}
+
+	keys := make(map[string]*models.PayloadKey)
+	for _, payload := range payloads {
+		describeObject(keys, "", payload, 1)
+	}
+
+	desc := &models.PayloadDescription{EventName: eventName, Sampled: len(payloads), Keys: []models.PayloadKey{}}
+	for _, key := range keys {
+		key.Type = dominantType(key.Types)
+		key.PresenceRate = float64(key.Count) / float64(len(payloads))
+		key.NullRate = float64(key.Types["null"]) / float64(key.Count)
+		desc.Keys = append(desc.Keys, *key)
+	}
+	sort.Slice(desc.Keys, func(i, j int) bool { return desc.Keys[i].Path < desc.Keys[j].Path })
+
+	return desc, nil
+}
+
+func describeObject(keys map[string]*models.PayloadKey, prefix string, obj map[string]interface{}, depth int) {
+	for name, value := range obj {
+		path := prefix + name
+		key, ok := keys[path]
+		if !ok {
+			if len(keys) >= maxDescribedKeys {
+				continue
+			}
+			key = &models.PayloadKey{Path: path, Types: make(map[string]int)}
+			keys[path] = key
+		}
+
+		key.Count++
+		typ := jsonType(value)
+		key.Types[typ]++
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if depth < maxDescribedDepth {
+				describeObject(keys, path+".", v, depth+1)
+			}
+		case string, float64, bool:
+			addExample(key, v)
+		}
+	}
+}
+
+func addExample(key *models.PayloadKey, value interface{}) {
+	if s, ok := value.(string); ok && len(s) > maxExampleLength {
+		value = strings.ToValidUTF8(s[:maxExampleLength], "") + "..."
+	}
+	if len(key.Examples) >= maxExamples {
+		return
+	}
+	for _, example := range key.Examples {
+		if example == value {
+			return
+		}
+	}
+	key.Examples = append(key.Examples, value)
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// dominantType returns the most frequent non-null type, so a mostly null
+// field still reports what it holds when set.
+func dominantType(types map[string]int) string {
+	best, bestCount := "null", 0
+	for typ, count := range types {
+		if typ == "null" {
+			continue
+		}
+		if count > bestCount || (count == bestCount && typ < best) {
+			best, bestCount = typ, count
+		}
+	}
+	return best
+}
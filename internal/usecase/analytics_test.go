@@ -0,0 +1,460 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/repo"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAnalyticsRepository is a mock implementation of AnalyticsRepository
+type MockAnalyticsRepository struct {
+	mock.Mock
+}
+
+func (m *MockAnalyticsRepository) GetHourlyStats(ctx context.Context, eventName string, from, to time.Time) ([]repo.EventStats, error) {
+	args := m.Called(ctx, eventName, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repo.EventStats), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) GetDailyStats(ctx context.Context, eventName string, from, to time.Time, loc *time.Location) ([]repo.EventStats, error) {
+	args := m.Called(ctx, eventName, from, to, loc)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repo.EventStats), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) ListEventNames(ctx context.Context, from, to time.Time) ([]models.EventNameSummary, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.EventNameSummary), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) SamplePayloads(ctx context.Context, eventName string, from, to time.Time, limit int) ([]map[string]interface{}, error) {
+	args := m.Called(ctx, eventName, from, to, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]map[string]interface{}), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) GetBreakdown(ctx context.Context, q models.BreakdownQuery) ([]models.BreakdownRow, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.BreakdownRow), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) GetTimeseries(ctx context.Context, q models.TimeseriesQuery, source string) ([]models.TimeseriesPoint, error) {
+	args := m.Called(ctx, q, source)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TimeseriesPoint), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) GetActiveUsers(ctx context.Context, from, to time.Time) ([]models.ActiveUsers, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ActiveUsers), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) GetUniqueUsers(ctx context.Context, eventName string, from, to time.Time) (int64, error) {
+	args := m.Called(ctx, eventName, from, to)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) GetRetention(ctx context.Context, q models.RetentionQuery) ([]models.RetentionCohort, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.RetentionCohort), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) GetFunnel(ctx context.Context, q models.FunnelQuery) ([]models.FunnelGroup, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.FunnelGroup), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) GetMetric(ctx context.Context, q models.MetricQuery) ([]models.MetricPoint, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.MetricPoint), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) GetHistogram(ctx context.Context, q models.HistogramQuery) (*models.HistogramResponse, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.HistogramResponse), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) GetSessionStats(ctx context.Context, q models.SessionQuery) ([]models.SessionStats, error) {
+	args := m.Called(ctx, q)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SessionStats), args.Error(1)
+}
+
+func TestDescribePayload(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	uc := NewAnalyticsUsecase(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("SamplePayloads", ctx, "app_launch", time.Time{}, time.Time{}, DefaultPayloadSample).Return([]map[string]interface{}{
+		{"version": "23.0", "device": map[string]interface{}{"arch": "amd64"}, "crash": nil},
+		{"version": "23.0", "device": map[string]interface{}{"arch": "arm64"}},
+		{"version": 24.0, "crash": true},
+		{"version": "23.1"},
+	}, nil)
+
+	desc, err := uc.DescribePayload(ctx, "app_launch", time.Time{}, time.Time{}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 4, desc.Sampled)
+
+	paths := make([]string, len(desc.Keys))
+	for i, key := range desc.Keys {
+		paths[i] = key.Path
+	}
+	assert.Equal(t, []string{"crash", "device", "device.arch", "version"}, paths)
+
+	crash := desc.Keys[0]
+	assert.Equal(t, "boolean", crash.Type)
+	assert.Equal(t, 0.5, crash.PresenceRate)
+	assert.Equal(t, 0.5, crash.NullRate)
+
+	arch := desc.Keys[2]
+	assert.Equal(t, "string", arch.Type)
+	assert.ElementsMatch(t, []interface{}{"amd64", "arm64"}, arch.Examples)
+
+	version := desc.Keys[3]
+	assert.Equal(t, "string", version.Type)
+	assert.Equal(t, map[string]int{"string": 3, "number": 1}, version.Types)
+	assert.Equal(t, 1.0, version.PresenceRate)
+	assert.Len(t, version.Examples, 3)
+}
+
+func TestDescribePayload_SampleBounds(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	uc := NewAnalyticsUsecase(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("SamplePayloads", ctx, "app_launch", time.Time{}, time.Time{}, MaxPayloadSample).Return([]map[string]interface{}{}, nil)
+
+	desc, err := uc.DescribePayload(ctx, "app_launch", time.Time{}, time.Time{}, 1000000)
+	require.NoError(t, err)
+	assert.Empty(t, desc.Keys)
+
+	_, err = uc.DescribePayload(ctx, "", time.Time{}, time.Time{}, 0)
+	assert.ErrorIs(t, err, ErrInvalidAnalyticsQuery)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetBreakdown_Defaults(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	uc := NewAnalyticsUsecase(mockRepo)
+	ctx := context.Background()
+
+	to := time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)
+	mockRepo.On("GetBreakdown", ctx, models.BreakdownQuery{
+		EventName: "app_launch",
+		Path:      []string{"version"},
+		Interval:  models.Interval{Count: 1, Unit: "d"},
+		From:      to.Add(-7 * 24 * time.Hour),
+		To:        to,
+		Top:       DefaultBreakdownTop,
+	}).Return([]models.BreakdownRow{{Bucket: to, EventCount: 5}}, nil)
+
+	resp, err := uc.GetBreakdown(ctx, models.BreakdownQuery{EventName: "app_launch", Path: []string{"version"}, To: to})
+	require.NoError(t, err)
+	assert.Equal(t, "payload.version", resp.By)
+	assert.Equal(t, "1d", resp.Interval)
+	assert.Len(t, resp.Rows, 1)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetBreakdown_Invalid(t *testing.T) {
+	uc := NewAnalyticsUsecase(new(MockAnalyticsRepository))
+	now := time.Now()
+
+	for _, q := range []models.BreakdownQuery{
+		{Path: []string{"version"}},
+		{EventName: "app_launch"},
+		{EventName: "app_launch", Path: []string{"version"}, From: now, To: now.Add(-time.Hour)},
+		{EventName: "app_launch", Path: []string{"version"}, Interval: models.Interval{Count: 1, Unit: "m"}, From: now.Add(-30 * 24 * time.Hour), To: now},
+	} {
+		_, err := uc.GetBreakdown(context.Background(), q)
+		assert.ErrorIs(t, err, ErrInvalidAnalyticsQuery)
+	}
+}
+
+func TestTimeseriesSource(t *testing.T) {
+	tests := map[string]string{
+		"15m": models.SourceEvents,
+		"90m": models.SourceEvents,
+		"60m": models.SourceHourly,
+		"1h":  models.SourceHourly,
+		"6h":  models.SourceHourly,
+		"1d":  models.SourceDaily,
+		"1w":  models.SourceDaily,
+		"1mo": models.SourceDaily,
+	}
+
+	for in, want := range tests {
+		interval, err := models.ParseInterval(in)
+		require.NoError(t, err)
+		assert.Equal(t, want, timeseriesSource(interval, nil), in)
+	}
+}
+
+func TestTimeseriesSource_Timezone(t *testing.T) {
+	tests := []struct {
+		tz       string
+		interval string
+		want     string
+	}{
+		{models.JakartaTimezone, "1d", models.SourceDailyJakarta},
+		{models.JakartaTimezone, "1mo", models.SourceDailyJakarta},
+		{models.JakartaTimezone, "1h", models.SourceHourly},
+		{"Asia/Makassar", "1w", models.SourceHourly},
+		{"Asia/Kolkata", "1d", models.SourceEvents},
+	}
+
+	for _, tt := range tests {
+		loc, err := models.ParseTimezone(tt.tz)
+		require.NoError(t, err)
+		interval, err := models.ParseInterval(tt.interval)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, timeseriesSource(interval, loc), tt.tz+" "+tt.interval)
+	}
+}
+
+func TestGetTimeseries_UniqueUsers(t *testing.T) {
+	to := time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		eventName string
+		interval  string
+		source    string
+		exact     bool
+	}{
+		{"raw events", "", "15m", models.SourceEvents, true},
+		{"hourly aggregate", "app_launch", "1h", models.SourceHourly, false},
+		{"rolled up hourly", "app_launch", "6h", models.SourceHourly, false},
+		{"all event names", "", "1d", models.SourceDaily, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockAnalyticsRepository)
+			uc := NewAnalyticsUsecase(mockRepo)
+			interval, _ := models.ParseInterval(tt.interval)
+
+			mockRepo.On("GetTimeseries", mock.Anything, mock.Anything, tt.source).
+				Return([]models.TimeseriesPoint{{Bucket: to, EventCount: 9, UniqueUsers: 4}}, nil)
+
+			resp, err := uc.GetTimeseries(context.Background(), models.TimeseriesQuery{
+				EventName: tt.eventName,
+				Interval:  interval,
+				From:      to.Add(-7 * 24 * time.Hour),
+				To:        to,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.source, resp.Source)
+			assert.Equal(t, int64(4), resp.Points[0].UniqueUsers)
+			assert.Equal(t, tt.exact, resp.UniqueUsersError == 0)
+		})
+	}
+}
+
+func TestGetUniqueUsers(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	uc := NewAnalyticsUsecase(mockRepo)
+	ctx := context.Background()
+
+	from := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 8, 18, 0, 0, 0, time.UTC)
+	mockRepo.On("GetUniqueUsers", ctx, "", from, to).Return(int64(1200), nil)
+
+	// Partial hours are widened to whole hours
+	resp, err := uc.GetUniqueUsers(ctx, "", from.Add(20*time.Minute), to.Add(-40*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1200), resp.UniqueUsers)
+	assert.InDelta(t, 0.0115, resp.StdError, 0.0001)
+	mockRepo.AssertExpectations(t)
+
+	_, err = uc.GetUniqueUsers(ctx, "", to, from)
+	assert.ErrorIs(t, err, ErrInvalidAnalyticsQuery)
+}
+
+func TestGetActiveUsers(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	uc := NewAnalyticsUsecase(mockRepo)
+	ctx := context.Background()
+
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)
+	mockRepo.On("GetActiveUsers", ctx, from, to).Return([]models.ActiveUsers{
+		{Day: from, DAU: 5, WAU: 10, MAU: 20},
+		{Day: from.Add(24 * time.Hour)},
+	}, nil)
+
+	// Partial days are widened to whole days
+	resp, err := uc.GetActiveUsers(ctx, from.Add(6*time.Hour), to.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, from, resp.From)
+	assert.Equal(t, to, resp.To)
+	assert.Equal(t, 0.5, resp.Days[0].DAUOverWAU)
+	assert.Equal(t, 0.25, resp.Days[0].DAUOverMAU)
+	assert.Zero(t, resp.Days[1].DAUOverMAU)
+	mockRepo.AssertExpectations(t)
+
+	_, err = uc.GetActiveUsers(ctx, to, from)
+	assert.ErrorIs(t, err, ErrInvalidAnalyticsQuery)
+}
+
+func TestGetRetention(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	uc := NewAnalyticsUsecase(mockRepo)
+	ctx := context.Background()
+
+	// The second cohort started this week, so only its first period is known
+	thisWeek := time.Now().UTC().Truncate(24 * time.Hour)
+	lastWeek := thisWeek.AddDate(0, 0, -7)
+	count := func(n int64) *int64 { return &n }
+
+	mockRepo.On("GetRetention", ctx, mock.MatchedBy(func(q models.RetentionQuery) bool {
+		return q.ReturnEvent == "app_launch" && len(q.StartEvents) == 2 && q.Periods == 2 && q.Granularity.String() == "1w"
+	})).Return([]models.RetentionCohort{
+		{Cohort: lastWeek, Size: 10, Retained: []*int64{count(10), count(4), count(0)}},
+		{Cohort: thisWeek, Size: 4, Retained: []*int64{count(2), count(0), count(0)}},
+	}, nil)
+
+	resp, err := uc.GetRetention(ctx, models.RetentionQuery{Periods: 2})
+	require.NoError(t, err)
+	require.Len(t, resp.Cohorts, 2)
+	assert.Equal(t, 0.4, *resp.Cohorts[0].Rates[1])
+	assert.Nil(t, resp.Cohorts[0].Rates[2])
+	assert.Equal(t, 0.5, *resp.Cohorts[1].Rates[0])
+	assert.Nil(t, resp.Cohorts[1].Retained[1])
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetRetention_Invalid(t *testing.T) {
+	uc := NewAnalyticsUsecase(new(MockAnalyticsRepository))
+	now := time.Now()
+
+	for _, q := range []models.RetentionQuery{
+		{Granularity: models.Interval{Count: 1, Unit: "h"}},
+		{Granularity: models.Interval{Count: 2, Unit: "w"}},
+		{Periods: MaxRetentionPeriods + 1},
+		{Granularity: models.Interval{Count: 1, Unit: "d"}, From: now.AddDate(-1, 0, 0), To: now},
+	} {
+		_, err := uc.GetRetention(context.Background(), q)
+		assert.ErrorIs(t, err, ErrInvalidAnalyticsQuery)
+	}
+}
+
+func TestGetFunnel(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	uc := NewAnalyticsUsecase(mockRepo)
+	ctx := context.Background()
+
+	steps := []string{"installer_start", "partition_done", "install_complete"}
+	mockRepo.On("GetFunnel", ctx, mock.MatchedBy(func(q models.FunnelQuery) bool {
+		return q.Window.String() == "1d" && q.Top == DefaultBreakdownTop && !q.From.IsZero()
+	})).Return([]models.FunnelGroup{{Steps: []models.FunnelStep{
+		{Event: steps[0], Users: 200},
+		{Event: steps[1], Users: 150},
+		{Event: steps[2], Users: 120},
+	}}}, nil)
+
+	resp, err := uc.GetFunnel(ctx, models.FunnelQuery{Steps: steps})
+	require.NoError(t, err)
+	funnel := resp.Groups[0].Steps
+	assert.Equal(t, 1.0, funnel[0].ConversionRate)
+	assert.Equal(t, 0.75, funnel[1].ConversionRate)
+	assert.Equal(t, 0.8, funnel[2].ConversionRate)
+	assert.Equal(t, 0.6, funnel[2].OverallRate)
+	mockRepo.AssertExpectations(t)
+
+	for _, q := range []models.FunnelQuery{
+		{Steps: []string{"installer_start"}},
+		{Steps: []string{"installer_start", ""}},
+		{Steps: make([]string, MaxFunnelSteps+1)},
+	} {
+		_, err := uc.GetFunnel(ctx, q)
+		assert.ErrorIs(t, err, ErrInvalidAnalyticsQuery)
+	}
+}
+
+func TestGetMetric_Defaults(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	uc := NewAnalyticsUsecase(mockRepo)
+	ctx := context.Background()
+
+	to := time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)
+	mockRepo.On("GetMetric", ctx, models.MetricQuery{
+		EventName: "boot_time",
+		Path:      []string{"duration_ms"},
+		Aggs:      DefaultMetricAggs,
+		Interval:  models.Interval{Count: 1, Unit: "h"},
+		From:      to.Add(-24 * time.Hour),
+		To:        to,
+	}).Return([]models.MetricPoint{}, nil)
+
+	resp, err := uc.GetMetric(ctx, models.MetricQuery{EventName: "boot_time", Path: []string{"duration_ms"}, To: to})
+	require.NoError(t, err)
+	assert.Equal(t, "payload.duration_ms", resp.Field)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetHistogram_Invalid(t *testing.T) {
+	uc := NewAnalyticsUsecase(new(MockAnalyticsRepository))
+	lo, hi := 10.0, 5.0
+
+	for _, q := range []models.HistogramQuery{
+		{Path: []string{"duration_ms"}},
+		{EventName: "boot_time"},
+		{EventName: "boot_time", Path: []string{"duration_ms"}, Bins: MaxHistogramBins + 1},
+		{EventName: "boot_time", Path: []string{"duration_ms"}, Min: &lo, Max: &hi},
+	} {
+		_, err := uc.GetHistogram(context.Background(), q)
+		assert.ErrorIs(t, err, ErrInvalidAnalyticsQuery)
+	}
+}
+
+func TestGetHistogram_EmptyRange(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	uc := NewAnalyticsUsecase(mockRepo)
+	lo := 5000.0
+
+	mockRepo.On("GetHistogram", mock.Anything, mock.Anything).Return(nil, repo.ErrEmptyHistogramRange)
+
+	_, err := uc.GetHistogram(context.Background(), models.HistogramQuery{EventName: "boot_time", Path: []string{"duration_ms"}, Min: &lo})
+	assert.ErrorIs(t, err, ErrInvalidAnalyticsQuery)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,276 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/repo"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+var (
+	ErrUnauthorized    = errors.New("invalid or missing API key")
+	ErrInvalidAPIKey   = errors.New("invalid API key request")
+	ErrAPIKeyNotFound  = errors.New("API key not found")
+	ErrAPIKeyRetired   = repo.ErrAPIKeyRetired
+	errMalformedAPIKey = errors.New("malformed API key")
+)
+
+const (
+	// apiKeyPrefix marks keys issued by this service, so they are easy to
+	// recognise in configuration files and secret scanners.
+	apiKeyPrefix = "btk"
+	// apiKeyCacheTTL bounds how long a looked-up key is trusted before the
+	// database is consulted again, i.e. how long a key revoked through
+	// another instance keeps working.
+	apiKeyCacheTTL = 30 * time.Second
+)
+
+type AuthUsecase interface {
+	Authenticate(ctx context.Context, key string) (*models.APIKey, error)
+	CreateAPIKey(ctx context.Context, req models.CreateAPIKeyRequest) (*models.CreatedAPIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RotateAPIKey(ctx context.Context, id int64, grace time.Duration) (*models.CreatedAPIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) error
+}
+
+type AuthConfig struct {
+	// BootstrapAdminKey is accepted as an admin key without being stored,
+	// so the first keys can be created. Empty disables it.
+	BootstrapAdminKey string
+}
+
+type cachedAPIKey struct {
+	key      *models.APIKey
+	loadedAt time.Time
+}
+
+type authUsecase struct {
+	repo repo.APIKeyRepository
+	cfg  AuthConfig
+
+	mu sync.RWMutex
+	// cache holds known keys by prefix. Unknown prefixes are not cached so
+	// random keys cannot grow it.
+	cache map[string]cachedAPIKey
+}
+
+func NewAuthUsecase(repo repo.APIKeyRepository, cfg AuthConfig) AuthUsecase {
+	return &authUsecase{
+		repo:  repo,
+		cfg:   cfg,
+		cache: make(map[string]cachedAPIKey),
+	}
+}
+
+// Authenticate resolves a presented key. Unknown, revoked, expired and
+// malformed keys all yield ErrUnauthorized.
+func (u *authUsecase) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	if key == "" {
+		return nil, ErrUnauthorized
+	}
+
+	if u.cfg.BootstrapAdminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(u.cfg.BootstrapAdminKey)) == 1 {
+		return &models.APIKey{Name: "bootstrap", Scopes: []string{models.ScopeAdmin}}, nil
+	}
+
+	prefix, err := parseAPIKey(key)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	stored, err := u.lookup(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || !stored.Active(time.Now()) {
+		return nil, ErrUnauthorized
+	}
+
+	hash := hashAPIKey(key)
+	if subtle.ConstantTimeCompare(hash, stored.KeyHash) != 1 {
+		return nil, ErrUnauthorized
+	}
+
+	return stored, nil
+}
+
+func (u *authUsecase) lookup(ctx context.Context, prefix string) (*models.APIKey, error) {
+	u.mu.RLock()
+	cached, ok := u.cache[prefix]
+	u.mu.RUnlock()
+	if ok && time.Since(cached.loadedAt) < apiKeyCacheTTL {
+		return cached.key, nil
+	}
+
+	key, err := u.repo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		// Keep accepting known keys while the database is unreachable, so
+		// ingestion can fall back to the spool
+		if ok {
+			log.Printf("usecase.Authenticate: repo.GetByPrefix failed, using cached key: %v", err)
+			return cached.key, nil
+		}
+		log.Printf("usecase.Authenticate: repo.GetByPrefix failed: %v", err)
+		return nil, err
+	}
+
+	if key == nil {
+		return nil, nil
+	}
+
+	// last_used_at is only as precise as the cache, which keeps it to one
+	// write per key and instance every apiKeyCacheTTL
+	if err := u.repo.TouchLastUsed(ctx, key.ID); err != nil {
+		log.Printf("usecase.Authenticate: repo.TouchLastUsed failed: %v", err)
+	}
+
+	u.mu.Lock()
+	u.cache[prefix] = cachedAPIKey{key: key, loadedAt: time.Now()}
+	u.mu.Unlock()
+
+	return key, nil
+}
+
+func (u *authUsecase) CreateAPIKey(ctx context.Context, req models.CreateAPIKeyRequest) (*models.CreatedAPIKey, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAPIKey)
+	}
+	if err := validateScopes(req.Scopes); err != nil {
+		return nil, err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expires_at is in the past", ErrInvalidAPIKey)
+	}
+
+	plaintext, key, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	key.Name = req.Name
+	key.Scopes = req.Scopes
+	key.ExpiresAt = req.ExpiresAt
+
+	if err := u.repo.Create(ctx, key); err != nil {
+		log.Printf("usecase.CreateAPIKey: repo.Create failed: %v", err)
+		return nil, err
+	}
+
+	return &models.CreatedAPIKey{APIKey: *key, Key: plaintext}, nil
+}
+
+func (u *authUsecase) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := u.repo.List(ctx)
+	if err != nil {
+		log.Printf("usecase.ListAPIKeys: repo.List failed: %v", err)
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RotateAPIKey issues a replacement for a key. The old key stops working
+// immediately, or after grace so clients can be updated first. Expired keys
+// and keys already rotated cannot be rotated.
+func (u *authUsecase) RotateAPIKey(ctx context.Context, id int64, grace time.Duration) (*models.CreatedAPIKey, error) {
+	if grace < 0 {
+		return nil, fmt.Errorf("%w: grace period must not be negative", ErrInvalidAPIKey)
+	}
+
+	plaintext, key, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := u.repo.Rotate(ctx, id, key, grace)
+	if errors.Is(err, ErrAPIKeyRetired) {
+		return nil, ErrAPIKeyRetired
+	}
+	if err != nil {
+		log.Printf("usecase.RotateAPIKey: repo.Rotate failed: %v", err)
+		return nil, err
+	}
+	if !found {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	u.flushCache()
+	return &models.CreatedAPIKey{APIKey: *key, Key: plaintext}, nil
+}
+
+func (u *authUsecase) RevokeAPIKey(ctx context.Context, id int64) error {
+	found, err := u.repo.Revoke(ctx, id)
+	if err != nil {
+		log.Printf("usecase.RevokeAPIKey: repo.Revoke failed: %v", err)
+		return err
+	}
+	if !found {
+		return ErrAPIKeyNotFound
+	}
+
+	u.flushCache()
+	return nil
+}
+
+func (u *authUsecase) flushCache() {
+	u.mu.Lock()
+	u.cache = make(map[string]cachedAPIKey)
+	u.mu.Unlock()
+}
+
+func validateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKey)
+	}
+	for _, scope := range scopes {
+		switch scope {
+		case models.ScopeIngest, models.ScopeRead, models.ScopeAdmin:
+		default:
+			return fmt.Errorf("%w: unknown scope %q", ErrInvalidAPIKey, scope)
+		}
+	}
+	return nil
+}
+
+// generateAPIKey returns a new key of the form btk_<prefix>_<secret> and
+// its stored form. The prefix identifies the key; only the hash of the
+// whole key is kept.
+func generateAPIKey() (string, *models.APIKey, error) {
+	prefixBytes := make([]byte, 6)
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", nil, fmt.Errorf("generate api key: %w", err)
+	}
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", nil, fmt.Errorf("generate api key: %w", err)
+	}
+
+	prefix := hex.EncodeToString(prefixBytes)
+	plaintext := apiKeyPrefix + "_" + prefix + "_" + base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	return plaintext, &models.APIKey{Prefix: prefix, KeyHash: hashAPIKey(plaintext)}, nil
+}
+
+func parseAPIKey(key string) (string, error) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", errMalformedAPIKey
+	}
+	return parts[1], nil
+}
+
+// hashAPIKey uses a plain SHA-256: keys carry 256 bits of randomness, so a
+// slow password hash would only add latency to every request.
+func hashAPIKey(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAPIKeyRepository is a mock implementation of APIKeyRepository
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	args := m.Called(ctx, key)
+	if args.Error(0) == nil {
+		key.ID = 1
+		key.CreatedAt = time.Now()
+	}
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	args := m.Called(ctx, prefix)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) TouchLastUsed(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) Rotate(ctx context.Context, oldID int64, replacement *models.APIKey, grace time.Duration) (bool, error) {
+	args := m.Called(ctx, oldID, replacement, grace)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Revoke(ctx context.Context, id int64) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+// issueKey creates a key through the usecase and returns its plaintext and
+// stored form.
+func issueKey(t *testing.T, uc AuthUsecase, mockRepo *MockAPIKeyRepository, scopes ...string) (string, *models.APIKey) {
+	t.Helper()
+
+	var stored *models.APIKey
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.APIKey")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*models.APIKey)
+	}).Return(nil).Once()
+
+	created, err := uc.CreateAPIKey(context.Background(), models.CreateAPIKeyRequest{Name: "desktop", Scopes: scopes})
+	require.NoError(t, err)
+	return created.Key, stored
+}
+
+func TestCreateAPIKey_StoresOnlyHash(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	uc := NewAuthUsecase(mockRepo, AuthConfig{})
+
+	plaintext, stored := issueKey(t, uc, mockRepo, models.ScopeIngest)
+
+	assert.True(t, strings.HasPrefix(plaintext, "btk_"+stored.Prefix+"_"))
+	assert.Len(t, stored.KeyHash, 32)
+	assert.NotContains(t, string(stored.KeyHash), plaintext)
+	assert.Equal(t, []string{models.ScopeIngest}, stored.Scopes)
+}
+
+func TestCreateAPIKey_Invalid(t *testing.T) {
+	uc := NewAuthUsecase(new(MockAPIKeyRepository), AuthConfig{})
+	past := time.Now().Add(-time.Hour)
+
+	for _, req := range []models.CreateAPIKeyRequest{
+		{Scopes: []string{models.ScopeRead}},
+		{Name: "x"},
+		{Name: "x", Scopes: []string{"write"}},
+		{Name: "x", Scopes: []string{models.ScopeRead}, ExpiresAt: &past},
+	} {
+		_, err := uc.CreateAPIKey(context.Background(), req)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	uc := NewAuthUsecase(mockRepo, AuthConfig{})
+	ctx := context.Background()
+
+	plaintext, stored := issueKey(t, uc, mockRepo, models.ScopeRead)
+	mockRepo.On("GetByPrefix", ctx, stored.Prefix).Return(stored, nil).Once()
+	mockRepo.On("TouchLastUsed", ctx, stored.ID).Return(nil).Once()
+
+	key, err := uc.Authenticate(ctx, plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, stored.ID, key.ID)
+
+	// A second request is served from the cache
+	_, err = uc.Authenticate(ctx, plaintext)
+	require.NoError(t, err)
+
+	// Same prefix, different secret
+	_, err = uc.Authenticate(ctx, plaintext[:len(plaintext)-2]+"xx")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuthenticate_Rejects(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	uc := NewAuthUsecase(mockRepo, AuthConfig{})
+	ctx := context.Background()
+
+	plaintext, stored := issueKey(t, uc, mockRepo, models.ScopeRead)
+	past := time.Now().Add(-time.Minute)
+	stored.ExpiresAt = &past
+	mockRepo.On("GetByPrefix", ctx, stored.Prefix).Return(stored, nil)
+	mockRepo.On("TouchLastUsed", ctx, stored.ID).Return(nil)
+	mockRepo.On("GetByPrefix", ctx, "000000000000").Return(nil, nil)
+
+	for _, key := range []string{"", "garbage", "btk__secret", "btk_000000000000_secret", plaintext} {
+		_, err := uc.Authenticate(ctx, key)
+		assert.ErrorIs(t, err, ErrUnauthorized, key)
+	}
+}
+
+func TestAuthenticate_UsesStaleCacheWhenDatabaseIsDown(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	impl := NewAuthUsecase(mockRepo, AuthConfig{}).(*authUsecase)
+	ctx := context.Background()
+
+	plaintext, stored := issueKey(t, impl, mockRepo, models.ScopeIngest)
+	impl.cache[stored.Prefix] = cachedAPIKey{key: stored, loadedAt: time.Now().Add(-time.Hour)}
+	mockRepo.On("GetByPrefix", ctx, stored.Prefix).Return(nil, errors.New("connection refused"))
+	mockRepo.On("GetByPrefix", ctx, "ffffffffffff").Return(nil, errors.New("connection refused"))
+
+	key, err := impl.Authenticate(ctx, plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, stored.ID, key.ID)
+
+	_, err = impl.Authenticate(ctx, "btk_ffffffffffff_secret")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestAuthenticate_BootstrapKey(t *testing.T) {
+	uc := NewAuthUsecase(new(MockAPIKeyRepository), AuthConfig{BootstrapAdminKey: "let-me-in"})
+
+	key, err := uc.Authenticate(context.Background(), "let-me-in")
+	require.NoError(t, err)
+	assert.True(t, key.HasScope(models.ScopeAdmin))
+}
+
+func TestRevokeAPIKey_FlushesCache(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	uc := NewAuthUsecase(mockRepo, AuthConfig{})
+	ctx := context.Background()
+
+	plaintext, stored := issueKey(t, uc, mockRepo, models.ScopeRead)
+	mockRepo.On("GetByPrefix", ctx, stored.Prefix).Return(stored, nil).Once()
+	mockRepo.On("TouchLastUsed", ctx, stored.ID).Return(nil)
+	mockRepo.On("Revoke", ctx, stored.ID).Return(true, nil)
+
+	_, err := uc.Authenticate(ctx, plaintext)
+	require.NoError(t, err)
+
+	require.NoError(t, uc.RevokeAPIKey(ctx, stored.ID))
+
+	revoked := *stored
+	now := time.Now()
+	revoked.RevokedAt = &now
+	mockRepo.On("GetByPrefix", ctx, stored.Prefix).Return(&revoked, nil).Once()
+
+	_, err = uc.Authenticate(ctx, plaintext)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	uc := NewAuthUsecase(mockRepo, AuthConfig{})
+	ctx := context.Background()
+
+	mockRepo.On("Rotate", ctx, int64(4), mock.AnythingOfType("*models.APIKey"), time.Hour).Return(true, nil)
+	mockRepo.On("Rotate", ctx, int64(5), mock.AnythingOfType("*models.APIKey"), time.Duration(0)).Return(false, nil)
+	mockRepo.On("Rotate", ctx, int64(6), mock.AnythingOfType("*models.APIKey"), time.Hour).Return(true, ErrAPIKeyRetired)
+
+	created, err := uc.RotateAPIKey(ctx, 4, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Key, "btk_"+created.Prefix+"_"))
+
+	_, err = uc.RotateAPIKey(ctx, 5, 0)
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+
+	_, err = uc.RotateAPIKey(ctx, 6, time.Hour)
+	assert.ErrorIs(t, err, ErrAPIKeyRetired)
+
+	_, err = uc.RotateAPIKey(ctx, 4, -time.Second)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+}
@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/repo"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+// Buckets of the hourly and daily stats.
+var (
+	hourInterval = models.Interval{Count: 1, Unit: "h"}
+	dayInterval  = models.Interval{Count: 1, Unit: "d"}
+)
+
+// checkCompare rejects unknown comparison periods.
+func checkCompare(compare string) error {
+	if _, err := models.ParseCompare(compare); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAnalyticsQuery, err)
+	}
+	return nil
+}
+
+// comparedRange returns the range [from, to] is compared with, bucketed by
+// interval in loc, and the function mapping each of its buckets onto the
+// bucket it is compared with. The previous period is moved back by whole
+// buckets so that buckets line up. The previous year is a calendar year
+// back, with Feb 29 compared with Feb 28, or 52 weeks for weekly buckets so
+// that they keep starting on the same weekday.
+func comparedRange(compare string, interval models.Interval, loc *time.Location, from, to time.Time) (time.Time, time.Time, func(time.Time) time.Time) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var shift func(time.Time) time.Time
+	switch {
+	case compare == models.ComparePreviousYear && interval.Unit == "w":
+		shift = func(t time.Time) time.Time { return t.In(loc).AddDate(0, 0, -52*7) }
+	case compare == models.ComparePreviousYear:
+		shift = func(t time.Time) time.Time {
+			t = t.In(loc)
+			// AddDate would normalise Feb 29 of a year back to Mar 1,
+			// which Mar 1 is already compared with
+			if t.Month() == time.February && t.Day() == 29 {
+				t = t.AddDate(0, 0, -1)
+			}
+			return t.AddDate(-1, 0, 0)
+		}
+	default:
+		n := 1
+		for interval.AddTo(from.In(loc), n).Before(to) {
+			n++
+		}
+		shift = func(t time.Time) time.Time { return interval.AddTo(t.In(loc), -n) }
+	}
+	return shift(from), shift(to), shift
+}
+
+// compareStats sets Compare on every row of stats from the row of prev for
+// the same event and compared bucket. Buckets missing from prev had no
+// events.
+func compareStats(stats, prev []repo.EventStats, shift func(time.Time) time.Time) {
+	type key struct {
+		bucket    int64
+		eventName string
+	}
+	byKey := make(map[key]repo.EventStats, len(prev))
+	for _, s := range prev {
+		byKey[key{s.Bucket.UnixNano(), s.EventName}] = s
+	}
+
+	for i := range stats {
+		s := &stats[i]
+		bucket := shift(s.Bucket)
+		p := byKey[key{bucket.UnixNano(), s.EventName}]
+		s.Compare = models.CompareCounts(bucket, s.EventCount, s.UniqueUsers, p.EventCount, p.UniqueUsers)
+	}
+}
+
+// comparePoints is compareStats for the points of a timeseries.
+func comparePoints(points, prev []models.TimeseriesPoint, shift func(time.Time) time.Time) {
+	byBucket := make(map[int64]models.TimeseriesPoint, len(prev))
+	for _, p := range prev {
+		byBucket[p.Bucket.UnixNano()] = p
+	}
+
+	for i := range points {
+		pt := &points[i]
+		bucket := shift(pt.Bucket)
+		p := byBucket[bucket.UnixNano()]
+		pt.Compare = models.CompareCounts(bucket, pt.EventCount, pt.UniqueUsers, p.EventCount, p.UniqueUsers)
+	}
+}
@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/repo"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComparedRange(t *testing.T) {
+	jakarta, err := time.LoadLocation(models.JakartaTimezone)
+	require.NoError(t, err)
+
+	utc := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		compare  string
+		interval models.Interval
+		loc      *time.Location
+		from, to time.Time
+		bucket   time.Time
+		want     time.Time
+	}{
+		{"whole day of hours", models.ComparePreviousPeriod, hourInterval, nil,
+			utc(3, 2, 0, 0), utc(3, 3, 0, 0), utc(3, 2, 5, 0), utc(3, 1, 5, 0)},
+		{"unaligned range moves by whole buckets", models.ComparePreviousPeriod, hourInterval, nil,
+			utc(3, 2, 0, 30), utc(3, 2, 2, 0), utc(3, 2, 1, 0), utc(3, 1, 23, 0)},
+		{"months", models.ComparePreviousPeriod, models.Interval{Count: 1, Unit: "mo"}, nil,
+			utc(1, 1, 0, 0), utc(4, 1, 0, 0), utc(3, 1, 0, 0), time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{"year of local days", models.ComparePreviousYear, dayInterval, jakarta,
+			utc(2, 28, 17, 0), utc(3, 7, 17, 0), time.Date(2026, 3, 1, 0, 0, 0, 0, jakarta), time.Date(2025, 3, 1, 0, 0, 0, 0, jakarta)},
+		{"leap day compares with Feb 28", models.ComparePreviousYear, dayInterval, nil,
+			time.Date(2028, 2, 28, 0, 0, 0, 0, time.UTC), time.Date(2028, 3, 2, 0, 0, 0, 0, time.UTC),
+			time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC), time.Date(2027, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{"day after leap day compares with Mar 1", models.ComparePreviousYear, dayInterval, nil,
+			time.Date(2028, 2, 28, 0, 0, 0, 0, time.UTC), time.Date(2028, 3, 2, 0, 0, 0, 0, time.UTC),
+			time.Date(2028, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"year of weeks keeps weekday", models.ComparePreviousYear, models.Interval{Count: 1, Unit: "w"}, nil,
+			utc(3, 2, 0, 0), utc(3, 30, 0, 0), utc(3, 2, 0, 0), time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, shift := comparedRange(tt.compare, tt.interval, tt.loc, tt.from, tt.to)
+			assert.True(t, tt.want.Equal(shift(tt.bucket)), "got %s", shift(tt.bucket))
+			assert.True(t, from.Equal(shift(tt.from)))
+			assert.True(t, to.Equal(shift(tt.to)))
+			if tt.compare == models.ComparePreviousPeriod {
+				// The previous period never overlaps the current one
+				assert.False(t, to.After(tt.from))
+			}
+		})
+	}
+}
+
+func TestGetHourlyStats_Compare(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	uc := NewAnalyticsUsecase(mockRepo)
+	ctx := context.Background()
+
+	from := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	to := from.Add(2 * time.Hour)
+	mockRepo.On("GetHourlyStats", ctx, "", from, to).Return([]repo.EventStats{
+		{Bucket: from.Add(time.Hour), EventName: "app_launch", EventCount: 30, UniqueUsers: 10},
+		{Bucket: from.Add(time.Hour), EventName: "app_crash", EventCount: 2, UniqueUsers: 2},
+	}, nil)
+	mockRepo.On("GetHourlyStats", ctx, "", from.Add(-2*time.Hour), from).Return([]repo.EventStats{
+		{Bucket: from.Add(-time.Hour), EventName: "app_launch", EventCount: 20, UniqueUsers: 10},
+	}, nil)
+
+	stats, err := uc.GetHourlyStats(ctx, "", from, to, models.ComparePreviousPeriod)
+	require.NoError(t, err)
+
+	launch := stats[0].Compare
+	require.NotNil(t, launch)
+	assert.Equal(t, from.Add(-time.Hour), launch.Bucket)
+	assert.Equal(t, int64(10), launch.EventCountDelta)
+	assert.InDelta(t, 50.0, *launch.EventCountDeltaPct, 1e-9)
+	assert.InDelta(t, 0.0, *launch.UniqueUsersDeltaPct, 1e-9)
+
+	// No app_crash events in the compared hour
+	crash := stats[1].Compare
+	require.NotNil(t, crash)
+	assert.Equal(t, int64(2), crash.EventCountDelta)
+	assert.Nil(t, crash.EventCountDeltaPct)
+	mockRepo.AssertExpectations(t)
+
+	_, err = uc.GetHourlyStats(ctx, "", from, to, "last_week")
+	assert.ErrorIs(t, err, ErrInvalidAnalyticsQuery)
+}
+
+func TestGetTimeseries_Compare(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	uc := NewAnalyticsUsecase(mockRepo)
+	ctx := context.Background()
+
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+	q := models.TimeseriesQuery{Interval: dayInterval, From: from, To: to, Compare: models.ComparePreviousYear}
+	prevQ := q
+	prevQ.From, prevQ.To = from.AddDate(-1, 0, 0), to.AddDate(-1, 0, 0)
+
+	mockRepo.On("GetTimeseries", ctx, q, models.SourceDaily).
+		Return([]models.TimeseriesPoint{{Bucket: from, EventCount: 12, UniqueUsers: 4}}, nil)
+	mockRepo.On("GetTimeseries", ctx, prevQ, models.SourceDaily).
+		Return([]models.TimeseriesPoint{{Bucket: prevQ.From, EventCount: 8, UniqueUsers: 5}}, nil)
+
+	resp, err := uc.GetTimeseries(ctx, q)
+	require.NoError(t, err)
+	assert.Equal(t, models.ComparePreviousYear, resp.Compare)
+	require.NotNil(t, resp.Points[0].Compare)
+	assert.Equal(t, int64(8), resp.Points[0].Compare.EventCount)
+	assert.InDelta(t, 50.0, *resp.Points[0].Compare.EventCountDeltaPct, 1e-9)
+	assert.Equal(t, int64(-1), resp.Points[0].Compare.UniqueUsersDelta)
+	mockRepo.AssertExpectations(t)
+}
@@ -2,56 +2,364 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"time"
 
+	"github.com/herpiko/blankon-telemetry-backend/internal/ingest"
 	"github.com/herpiko/blankon-telemetry-backend/internal/repo"
+	"github.com/herpiko/blankon-telemetry-backend/internal/spool"
 	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
 )
 
 var (
 	ErrEventNotFound = errors.New("event not found")
 	ErrInvalidEvent  = errors.New("invalid event data")
+	ErrEmptyBatch    = errors.New("batch contains no events")
+	ErrBatchTooLarge = errors.New("batch exceeds maximum size")
+	ErrQueueFull     = ingest.ErrQueueFull
+	// ErrPayloadTooLarge wraps ErrInvalidEvent, so oversize events are
+	// treated like any other invalid event unless checked for explicitly.
+	ErrPayloadTooLarge = fmt.Errorf("%w: payload too large", ErrInvalidEvent)
+)
+
+const (
+	// MaxBatchSize is the maximum number of events accepted in one batch.
+	MaxBatchSize = 1000
+	// DefaultListLimit is the page size of event listings unless asked
+	// otherwise; MaxListLimit caps it.
+	DefaultListLimit = 100
+	MaxListLimit     = 1000
+	// MaxIdempotencyKeyLength is the longest idempotency key accepted.
+	MaxIdempotencyKeyLength = 255
+	// DefaultIdempotencyWindow is how long an idempotency key is honoured
+	// unless configured otherwise.
+	DefaultIdempotencyWindow = 24 * time.Hour
+	// MaxIdempotencyWindow is the retention of the job purging expired
+	// keys; a longer window would stop deduplicating once keys are purged.
+	MaxIdempotencyWindow = 7 * 24 * time.Hour
+	// MaxQuarantineRawSize is how much of a rejected item is kept in
+	// quarantine; longer items are truncated and cannot be reprocessed.
+	MaxQuarantineRawSize = 1 << 20
 )
 
 type EventUsecase interface {
-	CreateEvent(ctx context.Context, req models.CreateEventRequest) (*models.Event, error)
+	CreateEvent(ctx context.Context, req models.CreateEventRequest) (*models.Event, string, error)
+	CreateEvents(ctx context.Context, reqs []models.CreateEventRequest) (*models.BatchResponse, error)
 	GetEvent(ctx context.Context, id int64) (*models.Event, error)
-	ListEvents(ctx context.Context, filter models.EventFilter) ([]models.Event, error)
+	ListEvents(ctx context.Context, filter models.EventFilter) (*models.EventPage, error)
+	ListUserSessions(ctx context.Context, userID string, q models.SessionQuery, limit int) (*models.UserSessions, error)
+	ListUserEvents(ctx context.Context, filter models.UserEventFilter) (*models.EventPage, error)
+	IngestStats(ctx context.Context) models.IngestStats
 }
 
 type eventUsecase struct {
-	repo repo.EventRepository
+	repo              repo.EventRepository
+	queue             *ingest.Queue
+	spool             *spool.Spool
+	idempotencyWindow time.Duration
+	validator         PayloadValidator
+	quarantine        repo.QuarantineRepository
+	maxPayloadSize    int
 }
 
-func NewEventUsecase(repo repo.EventRepository) EventUsecase {
-	return &eventUsecase{repo: repo}
+// EventOption configures optional parts of the event write path.
+type EventOption func(*eventUsecase)
+
+// WithQueue makes CreateEvent and CreateEvents hand events to an
+// asynchronous ingest queue instead of writing them directly. Queued events
+// have no ID until they are flushed.
+func WithQueue(q *ingest.Queue) EventOption {
+	return func(u *eventUsecase) {
+		u.queue = q
+	}
 }
 
-func (u *eventUsecase) CreateEvent(ctx context.Context, req models.CreateEventRequest) (*models.Event, error) {
-	if req.EventName == "" {
-		return nil, ErrInvalidEvent
+// WithSpool makes synchronous writes fall back to the on-disk spool when
+// the database is unreachable. Spooled events have no ID until they are
+// replayed.
+func WithSpool(s *spool.Spool) EventOption {
+	return func(u *eventUsecase) {
+		u.spool = s
 	}
+}
 
-	if req.Timestamp.IsZero() {
-		req.Timestamp = time.Now().UTC()
+// WithIdempotencyWindow sets how long an idempotency key deduplicates
+// retries. Keys older than the window may be reused.
+func WithIdempotencyWindow(d time.Duration) EventOption {
+	return func(u *eventUsecase) {
+		if d > 0 {
+			u.idempotencyWindow = d
+		}
+	}
+}
+
+// WithSchemaValidator makes CreateEvent and CreateEvents check payloads
+// against the schema registry. Non-conforming events are rejected with a
+// *ValidationError.
+func WithSchemaValidator(v PayloadValidator) EventOption {
+	return func(u *eventUsecase) {
+		u.validator = v
+	}
+}
+
+// WithQuarantine makes rejected events be kept in the quarantine store
+// instead of being dropped.
+func WithQuarantine(r repo.QuarantineRepository) EventOption {
+	return func(u *eventUsecase) {
+		u.quarantine = r
+	}
+}
+
+// WithMaxPayloadSize rejects events whose JSON-encoded payload is larger
+// than n bytes. Zero means no limit.
+func WithMaxPayloadSize(n int) EventOption {
+	return func(u *eventUsecase) {
+		u.maxPayloadSize = n
+	}
+}
+
+func NewEventUsecase(repo repo.EventRepository, opts ...EventOption) EventUsecase {
+	u := &eventUsecase{repo: repo, idempotencyWindow: DefaultIdempotencyWindow}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+// CreateEvent stores one event and reports what happened to it with one of
+// the batch statuses: created, duplicate, queued or spooled. Queued and
+// spooled events have no ID yet.
+func (u *eventUsecase) CreateEvent(ctx context.Context, req models.CreateEventRequest) (*models.Event, string, error) {
+	event, err := u.validEvent(ctx, req)
+	if err != nil {
+		u.quarantineRejected(ctx, []*models.QuarantinedEvent{NewQuarantineEntry(req, err)})
+		return nil, "", err
+	}
+
+	// Keyed events are written synchronously so a retry can be answered
+	// with the stored event
+	if event.IdempotencyKey != "" {
+		status, err := u.createKeyed(ctx, event)
+		if err != nil {
+			log.Printf("usecase.CreateEvent: createKeyed failed: %v", err)
+			return nil, "", err
+		}
+		return event, status, nil
 	}
 
-	event := &models.Event{
-		EventName: req.EventName,
-		Timestamp: req.Timestamp,
-		Payload:   req.Payload,
+	if u.queue != nil {
+		if err := u.queue.Enqueue(event); err != nil {
+			log.Printf("usecase.CreateEvent: queue.Enqueue failed: %v", err)
+			return nil, "", err
+		}
+		return event, models.BatchStatusQueued, nil
 	}
 
 	if err := u.repo.Create(ctx, event); err != nil {
+		if u.spoolable(err) {
+			spoolErr := u.spool.Append(event)
+			if spoolErr == nil {
+				log.Printf("usecase.CreateEvent: database unavailable, event spooled: %v", err)
+				return event, models.BatchStatusSpooled, nil
+			}
+			log.Printf("usecase.CreateEvent: spool.Append failed: %v", spoolErr)
+		}
 		log.Printf("usecase.CreateEvent: repo.Create failed: %v", err)
+		return nil, "", err
+	}
+
+	return event, models.BatchStatusCreated, nil
+}
+
+// CreateEvents validates every request individually and stores the valid
+// ones in a single repository call. Invalid items are reported as rejected
+// and do not prevent the rest of the batch from being stored. Events with
+// an idempotency key are stored one by one so duplicates can be detected;
+// since those are safe to retry, an error still means the whole batch can
+// be resubmitted.
+func (u *eventUsecase) CreateEvents(ctx context.Context, reqs []models.CreateEventRequest) (*models.BatchResponse, error) {
+	if len(reqs) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if len(reqs) > MaxBatchSize {
+		return nil, ErrBatchTooLarge
+	}
+
+	resp := &models.BatchResponse{Results: make([]models.BatchItemResult, len(reqs))}
+	events := make([]*models.Event, 0, len(reqs))
+	slots := make([]int, 0, len(reqs))
+	var rejected []*models.QuarantinedEvent
+
+	for i, req := range reqs {
+		resp.Results[i].Index = i
+
+		event, err := u.validEvent(ctx, req)
+		if err != nil {
+			resp.Results[i].Status = models.BatchStatusRejected
+			resp.Results[i].Error = err.Error()
+			var verr *ValidationError
+			if errors.As(err, &verr) {
+				resp.Results[i].Details = verr.Fields
+			}
+			resp.Rejected++
+			rejected = append(rejected, NewQuarantineEntry(req, err))
+			continue
+		}
+		resp.Results[i].Event = event
+
+		if event.IdempotencyKey == "" {
+			events = append(events, event)
+			slots = append(slots, i)
+			continue
+		}
+
+		status, err := u.createKeyed(ctx, event)
+		if err != nil {
+			log.Printf("usecase.CreateEvents: createKeyed failed: %v", err)
+			return nil, err
+		}
+		resp.Results[i].Status = status
+		countResult(resp, status)
+	}
+
+	u.quarantineRejected(ctx, rejected)
+
+	if len(events) == 0 {
+		return resp, nil
+	}
+
+	status := models.BatchStatusCreated
+	if u.queue != nil {
+		if err := u.queue.Enqueue(events...); err != nil {
+			log.Printf("usecase.CreateEvents: queue.Enqueue failed: %v", err)
+			return nil, err
+		}
+		status = models.BatchStatusQueued
+	} else if err := u.repo.CreateBatch(ctx, events); err != nil {
+		if !u.spoolable(err) {
+			log.Printf("usecase.CreateEvents: repo.CreateBatch failed: %v", err)
+			return nil, err
+		}
+		if spoolErr := u.spool.Append(events...); spoolErr != nil {
+			log.Printf("usecase.CreateEvents: repo.CreateBatch failed: %v, spool.Append failed: %v", err, spoolErr)
+			return nil, err
+		}
+		log.Printf("usecase.CreateEvents: database unavailable, %d events spooled: %v", len(events), err)
+		status = models.BatchStatusSpooled
+	}
+
+	for _, slot := range slots {
+		resp.Results[slot].Status = status
+		countResult(resp, status)
+	}
+
+	return resp, nil
+}
+
+// countResult updates the batch totals for an accepted item.
+func countResult(resp *models.BatchResponse, status string) {
+	resp.Accepted++
+	switch status {
+	case models.BatchStatusQueued:
+		resp.Queued++
+	case models.BatchStatusSpooled:
+		resp.Spooled++
+	case models.BatchStatusDuplicate:
+		resp.Duplicates++
+	}
+}
+
+// createKeyed stores an event carrying an idempotency key, replacing it
+// with the original on replay. It falls back to the spool like the
+// synchronous path; the key is kept so replaying the spool deduplicates
+// too.
+func (u *eventUsecase) createKeyed(ctx context.Context, event *models.Event) (string, error) {
+	replayed, err := u.repo.CreateIdempotent(ctx, event, u.idempotencyWindow)
+	if err != nil {
+		if u.spoolable(err) {
+			if spoolErr := u.spool.Append(event); spoolErr != nil {
+				log.Printf("usecase.createKeyed: spool.Append failed: %v", spoolErr)
+				return "", err
+			}
+			return models.BatchStatusSpooled, nil
+		}
+		return "", err
+	}
+
+	if replayed {
+		return models.BatchStatusDuplicate, nil
+	}
+	return models.BatchStatusCreated, nil
+}
+
+// quarantineRejected stores rejected events in the quarantine. It is best
+// effort: the rejection is reported to the client whether or not the entry
+// could be stored.
+func (u *eventUsecase) quarantineRejected(ctx context.Context, entries []*models.QuarantinedEvent) {
+	if u.quarantine == nil || len(entries) == 0 || ctx.Value(skipQuarantineKey{}) != nil {
+		return
+	}
+	if err := u.quarantine.CreateBatch(ctx, entries); err != nil {
+		log.Printf("usecase.quarantineRejected: repo.CreateBatch failed, %d rejected events dropped: %v", len(entries), err)
+	}
+}
+
+// spoolable reports whether a failed write should be diverted to the spool.
+func (u *eventUsecase) spoolable(err error) bool {
+	return u.spool != nil && repo.IsConnectionError(err)
+}
+
+// validEvent builds the event for req and checks its payload against the
+// schema registry, if one is configured.
+func (u *eventUsecase) validEvent(ctx context.Context, req models.CreateEventRequest) (*models.Event, error) {
+	event, err := newEvent(req)
+	if err != nil {
 		return nil, err
 	}
 
+	if u.maxPayloadSize > 0 && event.Payload != nil {
+		raw, err := json.Marshal(event.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+		}
+		if len(raw) > u.maxPayloadSize {
+			return nil, fmt.Errorf("%w: %d bytes exceeds the limit of %d", ErrPayloadTooLarge, len(raw), u.maxPayloadSize)
+		}
+	}
+
+	if u.validator != nil {
+		if err := u.validator.ValidatePayload(ctx, event.EventName, req.SchemaVersion, event.Payload); err != nil {
+			return nil, err
+		}
+	}
+
 	return event, nil
 }
 
+func newEvent(req models.CreateEventRequest) (*models.Event, error) {
+	if req.EventName == "" {
+		return nil, ErrInvalidEvent
+	}
+
+	if len(req.IdempotencyKey) > MaxIdempotencyKeyLength {
+		return nil, fmt.Errorf("%w: idempotency key longer than %d characters", ErrInvalidEvent, MaxIdempotencyKeyLength)
+	}
+
+	if req.Timestamp.IsZero() {
+		req.Timestamp = time.Now().UTC()
+	}
+
+	return &models.Event{
+		EventName:      req.EventName,
+		Timestamp:      req.Timestamp,
+		Payload:        req.Payload,
+		IdempotencyKey: req.IdempotencyKey,
+	}, nil
+}
+
 func (u *eventUsecase) GetEvent(ctx context.Context, id int64) (*models.Event, error) {
 	event, err := u.repo.GetByID(ctx, id)
 	if err != nil {
@@ -66,19 +374,72 @@ func (u *eventUsecase) GetEvent(ctx context.Context, id int64) (*models.Event, e
 	return event, nil
 }
 
-func (u *eventUsecase) ListEvents(ctx context.Context, filter models.EventFilter) ([]models.Event, error) {
-	if filter.Limit <= 0 {
-		filter.Limit = 100
+// ListEvents returns a page of events. A full page carries a cursor for the
+// next one, which is usable regardless of whether the page was requested by
+// cursor or offset.
+func (u *eventUsecase) ListEvents(ctx context.Context, filter models.EventFilter) (*models.EventPage, error) {
+	filter.Limit = listLimit(filter.Limit)
+
+	events, err := u.repo.List(ctx, filter)
+	if err != nil {
+		log.Printf("usecase.ListEvents: repo.List failed: %v", err)
+		return nil, err
 	}
 
-	if filter.Limit > 1000 {
-		filter.Limit = 1000
+	page := &models.EventPage{Events: events}
+	if len(events) == filter.Limit {
+		page.NextCursor = models.CursorAfter(events[len(events)-1]).String()
 	}
+	return page, nil
+}
 
-	events, err := u.repo.List(ctx, filter)
+// ListUserEvents returns a page of one install's timeline, paginated by
+// cursor like ListEvents.
+func (u *eventUsecase) ListUserEvents(ctx context.Context, filter models.UserEventFilter) (*models.EventPage, error) {
+	if filter.UserID == "" {
+		return nil, fmt.Errorf("%w: user_id is required", ErrInvalidAnalyticsQuery)
+	}
+	if filter.Cursor != nil && filter.Cursor.Ascending == filter.Descending {
+		return nil, fmt.Errorf("%w: cursor was issued for the other order", ErrInvalidAnalyticsQuery)
+	}
+
+	filter.Limit = listLimit(filter.Limit)
+
+	events, err := u.repo.ListByUser(ctx, filter)
 	if err != nil {
-		log.Printf("usecase.ListEvents: repo.List failed: %v", err)
+		log.Printf("usecase.ListUserEvents: repo.ListByUser failed: %v", err)
 		return nil, err
 	}
-	return events, nil
+
+	page := &models.EventPage{Events: events}
+	if len(events) == filter.Limit {
+		next := models.CursorAfter(events[len(events)-1])
+		next.Ascending = !filter.Descending
+		page.NextCursor = next.String()
+	}
+	return page, nil
+}
+
+// listLimit applies the default and maximum page size to limit.
+func listLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultListLimit
+	}
+	if limit > MaxListLimit {
+		return MaxListLimit
+	}
+	return limit
+}
+
+func (u *eventUsecase) IngestStats(ctx context.Context) models.IngestStats {
+	stats := models.IngestStats{Async: u.queue != nil}
+	if u.queue != nil {
+		queueStats := u.queue.Stats()
+		stats.Queue = &queueStats
+	}
+	if u.spool != nil {
+		spoolStats := u.spool.Stats()
+		stats.Spool = &spoolStats
+	}
+	return stats
 }
@@ -6,9 +6,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/herpiko/blankon-telemetry-backend/internal/ingest"
+	"github.com/herpiko/blankon-telemetry-backend/internal/spool"
 	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockEventRepository is a mock implementation of EventRepository
@@ -25,6 +29,36 @@ func (m *MockEventRepository) Create(ctx context.Context, event *models.Event) e
 	return args.Error(0)
 }
 
+func (m *MockEventRepository) CreateBatch(ctx context.Context, events []*models.Event) error {
+	args := m.Called(ctx, events)
+	if args.Error(0) == nil {
+		for i, event := range events {
+			event.ID = int64(i + 1)
+			event.CreatedAt = time.Now()
+		}
+	}
+	return args.Error(0)
+}
+
+func (m *MockEventRepository) CopyEvents(ctx context.Context, events []*models.Event) error {
+	args := m.Called(ctx, events)
+	return args.Error(0)
+}
+
+// CreateIdempotent simulates the stored original on replay by giving the
+// event a different ID than a fresh insert would
+func (m *MockEventRepository) CreateIdempotent(ctx context.Context, event *models.Event, window time.Duration) (bool, error) {
+	args := m.Called(ctx, event, window)
+	if args.Error(1) == nil {
+		event.ID = 1
+		if args.Bool(0) {
+			event.ID = 99
+		}
+		event.CreatedAt = time.Now()
+	}
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockEventRepository) GetByID(ctx context.Context, id int64) (*models.Event, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -41,6 +75,14 @@ func (m *MockEventRepository) List(ctx context.Context, filter models.EventFilte
 	return args.Get(0).([]models.Event), args.Error(1)
 }
 
+func (m *MockEventRepository) ListByUser(ctx context.Context, filter models.UserEventFilter) ([]models.Event, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Event), args.Error(1)
+}
+
 func TestCreateEvent_Success(t *testing.T) {
 	mockRepo := new(MockEventRepository)
 	uc := NewEventUsecase(mockRepo)
@@ -54,7 +96,7 @@ func TestCreateEvent_Success(t *testing.T) {
 
 	mockRepo.On("Create", ctx, mock.AnythingOfType("*models.Event")).Return(nil)
 
-	event, err := uc.CreateEvent(ctx, req)
+	event, _, err := uc.CreateEvent(ctx, req)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, event)
@@ -73,7 +115,7 @@ func TestCreateEvent_EmptyEventName(t *testing.T) {
 		Timestamp: time.Now(),
 	}
 
-	event, err := uc.CreateEvent(ctx, req)
+	event, _, err := uc.CreateEvent(ctx, req)
 
 	assert.Error(t, err)
 	assert.Equal(t, ErrInvalidEvent, err)
@@ -92,7 +134,7 @@ func TestCreateEvent_DefaultTimestamp(t *testing.T) {
 
 	mockRepo.On("Create", ctx, mock.AnythingOfType("*models.Event")).Return(nil)
 
-	event, err := uc.CreateEvent(ctx, req)
+	event, _, err := uc.CreateEvent(ctx, req)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, event)
@@ -112,10 +154,367 @@ func TestCreateEvent_RepoError(t *testing.T) {
 
 	mockRepo.On("Create", ctx, mock.AnythingOfType("*models.Event")).Return(errors.New("db error"))
 
-	event, err := uc.CreateEvent(ctx, req)
+	event, _, err := uc.CreateEvent(ctx, req)
+
+	assert.Error(t, err)
+	assert.Nil(t, event)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateEvents_Success(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	uc := NewEventUsecase(mockRepo)
+	ctx := context.Background()
+
+	reqs := []models.CreateEventRequest{
+		{EventName: "app_launch"},
+		{EventName: "app_close"},
+	}
+
+	mockRepo.On("CreateBatch", ctx, mock.AnythingOfType("[]*models.Event")).Return(nil)
+
+	resp, err := uc.CreateEvents(ctx, reqs)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resp.Accepted)
+	assert.Equal(t, 0, resp.Rejected)
+	assert.Len(t, resp.Results, 2)
+	for i, result := range resp.Results {
+		assert.Equal(t, i, result.Index)
+		assert.Equal(t, models.BatchStatusCreated, result.Status)
+		assert.NotZero(t, result.Event.ID)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateEvents_PartialRejection(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	uc := NewEventUsecase(mockRepo)
+	ctx := context.Background()
+
+	reqs := []models.CreateEventRequest{
+		{EventName: ""},
+		{EventName: "app_launch"},
+	}
+
+	mockRepo.On("CreateBatch", ctx, mock.MatchedBy(func(events []*models.Event) bool {
+		return len(events) == 1 && events[0].EventName == "app_launch"
+	})).Return(nil)
+
+	resp, err := uc.CreateEvents(ctx, reqs)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resp.Accepted)
+	assert.Equal(t, 1, resp.Rejected)
+	assert.Equal(t, models.BatchStatusRejected, resp.Results[0].Status)
+	assert.Equal(t, ErrInvalidEvent.Error(), resp.Results[0].Error)
+	assert.Nil(t, resp.Results[0].Event)
+	assert.Equal(t, models.BatchStatusCreated, resp.Results[1].Status)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateEvents_AllRejectedSkipsRepo(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	uc := NewEventUsecase(mockRepo)
+	ctx := context.Background()
+
+	resp, err := uc.CreateEvents(ctx, []models.CreateEventRequest{{EventName: ""}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, resp.Accepted)
+	assert.Equal(t, 1, resp.Rejected)
+	mockRepo.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+}
+
+func TestCreateEvents_Empty(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	uc := NewEventUsecase(mockRepo)
+
+	resp, err := uc.CreateEvents(context.Background(), nil)
+
+	assert.Equal(t, ErrEmptyBatch, err)
+	assert.Nil(t, resp)
+}
+
+func TestCreateEvents_TooLarge(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	uc := NewEventUsecase(mockRepo)
+
+	reqs := make([]models.CreateEventRequest, MaxBatchSize+1)
+
+	resp, err := uc.CreateEvents(context.Background(), reqs)
+
+	assert.Equal(t, ErrBatchTooLarge, err)
+	assert.Nil(t, resp)
+}
+
+func TestCreateEvents_RepoError(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	uc := NewEventUsecase(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("CreateBatch", ctx, mock.AnythingOfType("[]*models.Event")).Return(errors.New("db error"))
+
+	resp, err := uc.CreateEvents(ctx, []models.CreateEventRequest{{EventName: "app_launch"}})
 
 	assert.Error(t, err)
+	assert.Nil(t, resp)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateEvent_Queued(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	queue := ingest.NewQueue(mockRepo, ingest.Config{Capacity: 10})
+	uc := NewEventUsecase(mockRepo, WithQueue(queue))
+
+	event, status, err := uc.CreateEvent(context.Background(), models.CreateEventRequest{EventName: "test_event"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.BatchStatusQueued, status)
+	assert.Equal(t, "test_event", event.EventName)
+	assert.Zero(t, event.ID)
+	assert.Equal(t, 1, queue.Depth())
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateEvent_QueueFull(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	queue := ingest.NewQueue(mockRepo, ingest.Config{Capacity: 1})
+	uc := NewEventUsecase(mockRepo, WithQueue(queue))
+	ctx := context.Background()
+
+	_, _, err := uc.CreateEvent(ctx, models.CreateEventRequest{EventName: "first"})
+	assert.NoError(t, err)
+
+	event, _, err := uc.CreateEvent(ctx, models.CreateEventRequest{EventName: "second"})
+	assert.ErrorIs(t, err, ErrQueueFull)
 	assert.Nil(t, event)
+}
+
+func TestCreateEvents_Queued(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	queue := ingest.NewQueue(mockRepo, ingest.Config{Capacity: 10})
+	uc := NewEventUsecase(mockRepo, WithQueue(queue))
+
+	resp, err := uc.CreateEvents(context.Background(), []models.CreateEventRequest{
+		{EventName: "app_launch"},
+		{EventName: ""},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resp.Accepted)
+	assert.Equal(t, 1, resp.Queued)
+	assert.Equal(t, models.BatchStatusQueued, resp.Results[0].Status)
+	assert.Equal(t, 1, queue.Depth())
+	mockRepo.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+}
+
+// downPinger reports the database as unreachable so spools never replay
+type downPinger struct{}
+
+func (downPinger) Ping(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestCreateEvent_SpoolsOnConnectionError(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	s, err := spool.Open(spool.Config{Dir: t.TempDir()}, mockRepo, downPinger{})
+	require.NoError(t, err)
+	uc := NewEventUsecase(mockRepo, WithSpool(s))
+	ctx := context.Background()
+
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*models.Event")).Return(&pgconn.PgError{Code: "08006"})
+
+	event, status, err := uc.CreateEvent(ctx, models.CreateEventRequest{EventName: "test_event"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.BatchStatusSpooled, status)
+	assert.Zero(t, event.ID)
+	assert.Equal(t, int64(1), s.Stats().Spooled)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateEvent_DoesNotSpoolQueryErrors(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	s, err := spool.Open(spool.Config{Dir: t.TempDir()}, mockRepo, downPinger{})
+	require.NoError(t, err)
+	uc := NewEventUsecase(mockRepo, WithSpool(s))
+	ctx := context.Background()
+
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*models.Event")).Return(&pgconn.PgError{Code: "22P02"})
+
+	event, _, err := uc.CreateEvent(ctx, models.CreateEventRequest{EventName: "test_event"})
+
+	assert.Error(t, err)
+	assert.Nil(t, event)
+	assert.Equal(t, int64(0), s.Stats().Spooled)
+}
+
+func TestCreateEvents_SpoolsOnConnectionError(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	s, err := spool.Open(spool.Config{Dir: t.TempDir()}, mockRepo, downPinger{})
+	require.NoError(t, err)
+	uc := NewEventUsecase(mockRepo, WithSpool(s))
+	ctx := context.Background()
+
+	mockRepo.On("CreateBatch", ctx, mock.AnythingOfType("[]*models.Event")).Return(&pgconn.ConnectError{})
+
+	resp, err := uc.CreateEvents(ctx, []models.CreateEventRequest{{EventName: "a"}, {EventName: "b"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resp.Accepted)
+	assert.Equal(t, 2, resp.Spooled)
+	assert.Equal(t, models.BatchStatusSpooled, resp.Results[0].Status)
+	assert.Equal(t, int64(2), s.Stats().Spooled)
+}
+
+func TestIngestStats(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+
+	stats := NewEventUsecase(mockRepo).IngestStats(context.Background())
+	assert.False(t, stats.Async)
+	assert.Nil(t, stats.Queue)
+
+	queue := ingest.NewQueue(mockRepo, ingest.Config{Capacity: 10})
+	stats = NewEventUsecase(mockRepo, WithQueue(queue)).IngestStats(context.Background())
+	assert.True(t, stats.Async)
+	assert.Equal(t, 10, stats.Queue.Capacity)
+}
+
+func TestCreateEvent_IdempotencyKey(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	uc := NewEventUsecase(mockRepo, WithIdempotencyWindow(time.Hour))
+	ctx := context.Background()
+
+	req := models.CreateEventRequest{EventName: "test_event", IdempotencyKey: "6f1c1a52-0d3e-4a8e-9a39-0e0f6d1b7c11"}
+
+	mockRepo.On("CreateIdempotent", ctx, mock.MatchedBy(func(e *models.Event) bool {
+		return e.IdempotencyKey == req.IdempotencyKey
+	}), time.Hour).Return(false, nil)
+
+	event, _, err := uc.CreateEvent(ctx, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), event.ID)
+	assert.Equal(t, req.IdempotencyKey, event.IdempotencyKey)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateEvent_IdempotentReplay(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	queue := ingest.NewQueue(mockRepo, ingest.Config{Capacity: 10})
+	uc := NewEventUsecase(mockRepo, WithQueue(queue))
+	ctx := context.Background()
+
+	mockRepo.On("CreateIdempotent", ctx, mock.AnythingOfType("*models.Event"), DefaultIdempotencyWindow).Return(true, nil)
+
+	event, _, err := uc.CreateEvent(ctx, models.CreateEventRequest{EventName: "test_event", IdempotencyKey: "retry-me"})
+
+	assert.NoError(t, err)
+	// The original stored event is returned and the queue is bypassed
+	assert.Equal(t, int64(99), event.ID)
+	assert.Equal(t, 0, queue.Depth())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateEvent_IdempotencyKeyTooLong(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	uc := NewEventUsecase(mockRepo)
+
+	key := make([]byte, MaxIdempotencyKeyLength+1)
+	for i := range key {
+		key[i] = 'k'
+	}
+
+	event, _, err := uc.CreateEvent(context.Background(), models.CreateEventRequest{EventName: "test_event", IdempotencyKey: string(key)})
+
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+	assert.Nil(t, event)
+}
+
+func TestCreateEvents_MixedIdempotency(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	uc := NewEventUsecase(mockRepo)
+	ctx := context.Background()
+
+	reqs := []models.CreateEventRequest{
+		{EventName: "app_launch", IdempotencyKey: "seen-before"},
+		{EventName: "app_close"},
+	}
+
+	mockRepo.On("CreateIdempotent", ctx, mock.AnythingOfType("*models.Event"), DefaultIdempotencyWindow).Return(true, nil)
+	mockRepo.On("CreateBatch", ctx, mock.MatchedBy(func(events []*models.Event) bool {
+		return len(events) == 1 && events[0].EventName == "app_close"
+	})).Return(nil)
+
+	resp, err := uc.CreateEvents(ctx, reqs)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resp.Accepted)
+	assert.Equal(t, 1, resp.Duplicates)
+	assert.Equal(t, models.BatchStatusDuplicate, resp.Results[0].Status)
+	assert.Equal(t, int64(99), resp.Results[0].Event.ID)
+	assert.Equal(t, models.BatchStatusCreated, resp.Results[1].Status)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateEvent_SchemaViolation(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	schemaRepo := new(MockSchemaRepository)
+	uc := NewEventUsecase(mockRepo, WithSchemaValidator(NewSchemaUsecase(schemaRepo)))
+
+	schemaRepo.On("GetLatest", mock.Anything, "app_launch").Return(appLaunch(1), nil)
+
+	event, _, err := uc.CreateEvent(context.Background(), models.CreateEventRequest{
+		EventName: "app_launch",
+		Payload:   map[string]interface{}{"version": 3},
+	})
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, "/version", verr.Fields[0].Field)
+	assert.Nil(t, event)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestCreateEvents_SchemaViolationRejectsItem(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	schemaRepo := new(MockSchemaRepository)
+	uc := NewEventUsecase(mockRepo, WithSchemaValidator(NewSchemaUsecase(schemaRepo)))
+	ctx := context.Background()
+
+	schemaRepo.On("GetLatest", mock.Anything, "app_launch").Return(appLaunch(1), nil)
+	mockRepo.On("CreateBatch", ctx, mock.MatchedBy(func(events []*models.Event) bool {
+		return len(events) == 1
+	})).Return(nil)
+
+	resp, err := uc.CreateEvents(ctx, []models.CreateEventRequest{
+		{EventName: "app_launch", Payload: map[string]interface{}{"version": "1.0"}},
+		{EventName: "app_launch", Payload: map[string]interface{}{}},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.Accepted)
+	assert.Equal(t, 1, resp.Rejected)
+	assert.Equal(t, models.BatchStatusRejected, resp.Results[1].Status)
+	require.Len(t, resp.Results[1].Details, 1)
+	assert.Equal(t, "required", resp.Results[1].Details[0].Keyword)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestEventWriter_RoutesKeyedEvents(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	w := NewEventWriter(mockRepo, time.Hour)
+	ctx := context.Background()
+
+	keyed := &models.Event{EventName: "keyed", IdempotencyKey: "k1"}
+	plain := &models.Event{EventName: "plain"}
+
+	mockRepo.On("CreateIdempotent", ctx, keyed, time.Hour).Return(true, nil)
+	mockRepo.On("CopyEvents", ctx, []*models.Event{plain}).Return(nil)
+
+	assert.NoError(t, w.CopyEvents(ctx, []*models.Event{keyed, plain}))
 	mockRepo.AssertExpectations(t)
 }
 
@@ -168,10 +567,10 @@ func TestListEvents_Success(t *testing.T) {
 	filter := models.EventFilter{Limit: 10}
 	mockRepo.On("List", ctx, filter).Return(expected, nil)
 
-	events, err := uc.ListEvents(ctx, filter)
+	page, err := uc.ListEvents(ctx, filter)
 
 	assert.NoError(t, err)
-	assert.Equal(t, expected, events)
+	assert.Equal(t, expected, page.Events)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -208,3 +607,58 @@ func TestListEvents_MaxLimit(t *testing.T) {
 	assert.NotNil(t, events)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestListEvents_NextCursor(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	uc := NewEventUsecase(mockRepo)
+	ctx := context.Background()
+
+	ts := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	full := []models.Event{{ID: 9, Timestamp: ts.Add(time.Minute)}, {ID: 7, Timestamp: ts}}
+	cursor := &models.EventCursor{Timestamp: ts, ID: 7}
+
+	mockRepo.On("List", ctx, models.EventFilter{Limit: 2}).Return(full, nil)
+	mockRepo.On("List", ctx, models.EventFilter{Limit: 2, Cursor: cursor}).Return([]models.Event{{ID: 3, Timestamp: ts}}, nil)
+
+	page, err := uc.ListEvents(ctx, models.EventFilter{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, cursor.String(), page.NextCursor)
+
+	next, err := models.ParseEventCursor(page.NextCursor)
+	assert.NoError(t, err)
+
+	// The last page has no cursor
+	page, err = uc.ListEvents(ctx, models.EventFilter{Limit: 2, Cursor: next})
+	assert.NoError(t, err)
+	assert.Len(t, page.Events, 1)
+	assert.Empty(t, page.NextCursor)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListUserEvents(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	uc := NewEventUsecase(mockRepo)
+	ctx := context.Background()
+
+	ts := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	full := []models.Event{{ID: 3, Timestamp: ts}, {ID: 5, Timestamp: ts.Add(time.Minute)}}
+
+	mockRepo.On("ListByUser", ctx, models.UserEventFilter{UserID: "install-1", Limit: 100}).Return([]models.Event{}, nil)
+	mockRepo.On("ListByUser", ctx, models.UserEventFilter{UserID: "install-1", EventNames: []string{"app_launch"}, Limit: 2}).Return(full, nil)
+
+	page, err := uc.ListUserEvents(ctx, models.UserEventFilter{UserID: "install-1"})
+	assert.NoError(t, err)
+	assert.Empty(t, page.NextCursor)
+
+	page, err = uc.ListUserEvents(ctx, models.UserEventFilter{UserID: "install-1", EventNames: []string{"app_launch"}, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, models.EventCursor{Timestamp: ts.Add(time.Minute), ID: 5, Ascending: true}.String(), page.NextCursor)
+
+	_, err = uc.ListUserEvents(ctx, models.UserEventFilter{})
+	assert.ErrorIs(t, err, ErrInvalidAnalyticsQuery)
+
+	desc := &models.EventCursor{Timestamp: ts, ID: 3}
+	_, err = uc.ListUserEvents(ctx, models.UserEventFilter{UserID: "install-1", Cursor: desc})
+	assert.ErrorIs(t, err, ErrInvalidAnalyticsQuery)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/repo"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+// EventWriter stores events for the background write paths, the ingest
+// queue flusher and the spool replayer. Plain events are bulk copied while
+// events with an idempotency key go through the deduplicating insert, so a
+// spooled retry of an already stored event is not written twice.
+type EventWriter struct {
+	repo   repo.EventRepository
+	window time.Duration
+}
+
+func NewEventWriter(repo repo.EventRepository, window time.Duration) *EventWriter {
+	if window <= 0 {
+		window = DefaultIdempotencyWindow
+	}
+	return &EventWriter{repo: repo, window: window}
+}
+
+func (w *EventWriter) CopyEvents(ctx context.Context, events []*models.Event) error {
+	plain := make([]*models.Event, 0, len(events))
+	for _, event := range events {
+		if event.IdempotencyKey == "" {
+			plain = append(plain, event)
+			continue
+		}
+		if _, err := w.repo.CreateIdempotent(ctx, event, w.window); err != nil {
+			log.Printf("usecase.EventWriter: repo.CreateIdempotent failed: %v", err)
+			return err
+		}
+	}
+
+	if len(plain) == 0 {
+		return nil
+	}
+	return w.repo.CopyEvents(ctx, plain)
+}
@@ -0,0 +1,239 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/repo"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+var (
+	ErrQuarantineNotFound = errors.New("quarantine entry not found")
+	ErrAlreadyReprocessed = errors.New("quarantine entry already reprocessed")
+	ErrNotReprocessable   = errors.New("quarantine entry cannot be reprocessed")
+)
+
+// skipQuarantineKey marks a context whose rejected events must not be
+// quarantined again, i.e. while reprocessing quarantined events.
+type skipQuarantineKey struct{}
+
+type QuarantineUsecase interface {
+	Quarantine(ctx context.Context, entries ...*models.QuarantinedEvent) error
+	GetQuarantined(ctx context.Context, id int64) (*models.QuarantinedEvent, error)
+	ListQuarantined(ctx context.Context, filter models.QuarantineFilter) ([]models.QuarantinedEvent, error)
+	Reprocess(ctx context.Context, id int64) (*models.Event, error)
+	ReprocessPending(ctx context.Context, filter models.QuarantineFilter) (*models.ReprocessResponse, error)
+}
+
+type quarantineUsecase struct {
+	repo    repo.QuarantineRepository
+	eventUC EventUsecase
+}
+
+// NewQuarantineUsecase resubmits quarantined events through eventUC, so they
+// go through the same validation as fresh events.
+func NewQuarantineUsecase(repo repo.QuarantineRepository, eventUC EventUsecase) QuarantineUsecase {
+	return &quarantineUsecase{repo: repo, eventUC: eventUC}
+}
+
+// NewQuarantineEntry builds the quarantine entry for a request rejected with
+// err. Requests without a timestamp get the current time, so reprocessing
+// keeps roughly when the event happened.
+func NewQuarantineEntry(req models.CreateEventRequest, err error) *models.QuarantinedEvent {
+	if req.Timestamp.IsZero() {
+		req.Timestamp = time.Now().UTC()
+	}
+
+	entry := &models.QuarantinedEvent{
+		EventName: req.EventName,
+		Reason:    quarantineReason(err),
+		Error:     err.Error(),
+	}
+
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		entry.Details = verr.Fields
+	}
+
+	raw, marshalErr := json.Marshal(req)
+	if marshalErr != nil {
+		// Decoded requests always marshal; keep the entry for inspection
+		log.Printf("usecase.NewQuarantineEntry: marshal request: %v", marshalErr)
+		entry.Truncated = true
+		return entry
+	}
+	SetQuarantineRaw(entry, raw, len(raw))
+
+	return entry
+}
+
+// SetQuarantineRaw stores raw, the first bytes of an item of size bytes, on
+// entry, truncating it to MaxQuarantineRawSize.
+func SetQuarantineRaw(entry *models.QuarantinedEvent, raw []byte, size int) {
+	if len(raw) > MaxQuarantineRawSize {
+		raw = raw[:MaxQuarantineRawSize]
+	}
+	entry.Raw = string(raw)
+	entry.RawSize = size
+	entry.Truncated = size > len(raw)
+}
+
+func quarantineReason(err error) string {
+	var verr *ValidationError
+	switch {
+	case errors.As(err, &verr):
+		return models.QuarantineReasonSchemaViolation
+	case errors.Is(err, ErrPayloadTooLarge):
+		return models.QuarantineReasonPayloadTooLarge
+	default:
+		return models.QuarantineReasonInvalidEvent
+	}
+}
+
+func (u *quarantineUsecase) Quarantine(ctx context.Context, entries ...*models.QuarantinedEvent) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := u.repo.CreateBatch(ctx, entries); err != nil {
+		log.Printf("usecase.Quarantine: repo.CreateBatch failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (u *quarantineUsecase) GetQuarantined(ctx context.Context, id int64) (*models.QuarantinedEvent, error) {
+	entry, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		log.Printf("usecase.GetQuarantined: repo.GetByID(%d) failed: %v", id, err)
+		return nil, err
+	}
+	if entry == nil {
+		return nil, ErrQuarantineNotFound
+	}
+	return entry, nil
+}
+
+func (u *quarantineUsecase) ListQuarantined(ctx context.Context, filter models.QuarantineFilter) ([]models.QuarantinedEvent, error) {
+	filter.Limit = clampQuarantineLimit(filter.Limit)
+
+	entries, err := u.repo.List(ctx, filter)
+	if err != nil {
+		log.Printf("usecase.ListQuarantined: repo.List failed: %v", err)
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Reprocess resubmits a quarantined event. The entry's ID becomes the
+// event's idempotency key unless it already has one, so an entry is never
+// stored twice even if it is reprocessed concurrently.
+func (u *quarantineUsecase) Reprocess(ctx context.Context, id int64) (*models.Event, error) {
+	entry, err := u.GetQuarantined(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return u.reprocess(ctx, entry)
+}
+
+// ReprocessPending resubmits pending entries matching filter, oldest first.
+// Entries that are rejected again stay pending and are skipped by passing
+// the returned LastID as filter.AfterID. A storage error stops the run,
+// leaving the remaining entries pending.
+func (u *quarantineUsecase) ReprocessPending(ctx context.Context, filter models.QuarantineFilter) (*models.ReprocessResponse, error) {
+	pending := false
+	filter.Reprocessed = &pending
+	filter.Offset = 0
+	filter.Limit = clampQuarantineLimit(filter.Limit)
+
+	entries, err := u.repo.List(ctx, filter)
+	if err != nil {
+		log.Printf("usecase.ReprocessPending: repo.List failed: %v", err)
+		return nil, err
+	}
+
+	resp := &models.ReprocessResponse{Results: make([]models.ReprocessResult, 0, len(entries))}
+	for i := range entries {
+		entry := &entries[i]
+		result := models.ReprocessResult{ID: entry.ID}
+		resp.LastID = entry.ID
+
+		event, err := u.reprocess(ctx, entry)
+		switch {
+		case err == nil:
+			result.Status = models.ReprocessStatusReprocessed
+			result.Event = event
+			resp.Reprocessed++
+		case errors.Is(err, ErrInvalidEvent), errors.Is(err, ErrNotReprocessable):
+			result.Status = models.ReprocessStatusRejected
+			result.Error = err.Error()
+			var verr *ValidationError
+			if errors.As(err, &verr) {
+				result.Details = verr.Fields
+			}
+			resp.Rejected++
+		case errors.Is(err, ErrAlreadyReprocessed):
+			// Reprocessed concurrently
+			continue
+		default:
+			log.Printf("usecase.ReprocessPending: reprocess entry %d failed: %v", entry.ID, err)
+			return nil, err
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	return resp, nil
+}
+
+func (u *quarantineUsecase) reprocess(ctx context.Context, entry *models.QuarantinedEvent) (*models.Event, error) {
+	if entry.ReprocessedAt != nil {
+		return nil, ErrAlreadyReprocessed
+	}
+	if entry.Truncated {
+		return nil, fmt.Errorf("%w: raw event was truncated", ErrNotReprocessable)
+	}
+
+	var req models.CreateEventRequest
+	if err := json.Unmarshal([]byte(entry.Raw), &req); err != nil {
+		return nil, fmt.Errorf("%w: raw event is not valid JSON", ErrNotReprocessable)
+	}
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = fmt.Sprintf("quarantine-%d", entry.ID)
+	}
+
+	event, _, err := u.eventUC.CreateEvent(context.WithValue(ctx, skipQuarantineKey{}, true), req)
+	if err != nil {
+		return nil, err
+	}
+
+	var eventID *int64
+	if event.ID != 0 {
+		eventID = &event.ID
+	}
+	marked, err := u.repo.MarkReprocessed(ctx, entry.ID, eventID)
+	if err != nil {
+		log.Printf("usecase.reprocess: repo.MarkReprocessed(%d) failed: %v", entry.ID, err)
+		return nil, err
+	}
+	if !marked {
+		// Another reprocess got there first; the idempotency key kept the
+		// event from being stored twice
+		return nil, ErrAlreadyReprocessed
+	}
+
+	return event, nil
+}
+
+func clampQuarantineLimit(limit int) int {
+	if limit <= 0 {
+		return 100
+	}
+	if limit > 1000 {
+		return 1000
+	}
+	return limit
+}
@@ -0,0 +1,227 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockQuarantineRepository is a mock implementation of QuarantineRepository
+type MockQuarantineRepository struct {
+	mock.Mock
+}
+
+func (m *MockQuarantineRepository) CreateBatch(ctx context.Context, entries []*models.QuarantinedEvent) error {
+	args := m.Called(ctx, entries)
+	return args.Error(0)
+}
+
+func (m *MockQuarantineRepository) GetByID(ctx context.Context, id int64) (*models.QuarantinedEvent, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.QuarantinedEvent), args.Error(1)
+}
+
+func (m *MockQuarantineRepository) List(ctx context.Context, filter models.QuarantineFilter) ([]models.QuarantinedEvent, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.QuarantinedEvent), args.Error(1)
+}
+
+func (m *MockQuarantineRepository) MarkReprocessed(ctx context.Context, id int64, eventID *int64) (bool, error) {
+	args := m.Called(ctx, id, eventID)
+	return args.Bool(0), args.Error(1)
+}
+
+func quarantined(id int64, req models.CreateEventRequest) *models.QuarantinedEvent {
+	raw, _ := json.Marshal(req)
+	return &models.QuarantinedEvent{
+		ID:        id,
+		EventName: req.EventName,
+		Reason:    models.QuarantineReasonSchemaViolation,
+		Raw:       string(raw),
+		RawSize:   len(raw),
+	}
+}
+
+func TestCreateEvent_QuarantinesSchemaViolation(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	schemaRepo := new(MockSchemaRepository)
+	quarantineRepo := new(MockQuarantineRepository)
+	uc := NewEventUsecase(mockRepo,
+		WithSchemaValidator(NewSchemaUsecase(schemaRepo)),
+		WithQuarantine(quarantineRepo))
+	ctx := context.Background()
+
+	schemaRepo.On("GetLatest", mock.Anything, "app_launch").Return(appLaunch(1), nil)
+	quarantineRepo.On("CreateBatch", ctx, mock.MatchedBy(func(entries []*models.QuarantinedEvent) bool {
+		return len(entries) == 1 &&
+			entries[0].Reason == models.QuarantineReasonSchemaViolation &&
+			entries[0].EventName == "app_launch" &&
+			len(entries[0].Details) == 1 &&
+			strings.Contains(entries[0].Raw, `"duration_ms":5`)
+	})).Return(nil)
+
+	_, _, err := uc.CreateEvent(ctx, models.CreateEventRequest{
+		EventName: "app_launch",
+		Payload:   map[string]interface{}{"duration_ms": 5},
+	})
+
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+	quarantineRepo.AssertExpectations(t)
+}
+
+func TestCreateEvent_PayloadTooLarge(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	quarantineRepo := new(MockQuarantineRepository)
+	uc := NewEventUsecase(mockRepo, WithQuarantine(quarantineRepo), WithMaxPayloadSize(16))
+
+	quarantineRepo.On("CreateBatch", mock.Anything, mock.MatchedBy(func(entries []*models.QuarantinedEvent) bool {
+		return entries[0].Reason == models.QuarantineReasonPayloadTooLarge && !entries[0].Truncated
+	})).Return(nil)
+
+	_, _, err := uc.CreateEvent(context.Background(), models.CreateEventRequest{
+		EventName: "app_launch",
+		Payload:   map[string]interface{}{"note": strings.Repeat("x", 32)},
+	})
+
+	assert.ErrorIs(t, err, ErrPayloadTooLarge)
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	quarantineRepo.AssertExpectations(t)
+}
+
+func TestCreateEvents_QuarantinesRejectedItemsTogether(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	quarantineRepo := new(MockQuarantineRepository)
+	uc := NewEventUsecase(mockRepo, WithQuarantine(quarantineRepo))
+	ctx := context.Background()
+
+	mockRepo.On("CreateBatch", ctx, mock.AnythingOfType("[]*models.Event")).Return(nil)
+	quarantineRepo.On("CreateBatch", ctx, mock.MatchedBy(func(entries []*models.QuarantinedEvent) bool {
+		return len(entries) == 2 && entries[0].Reason == models.QuarantineReasonInvalidEvent
+	})).Return(nil).Once()
+
+	resp, err := uc.CreateEvents(ctx, []models.CreateEventRequest{{}, {EventName: "ok"}, {}})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.Rejected)
+	quarantineRepo.AssertExpectations(t)
+}
+
+func TestCreateEvent_QuarantineFailureStillRejects(t *testing.T) {
+	quarantineRepo := new(MockQuarantineRepository)
+	uc := NewEventUsecase(new(MockEventRepository), WithQuarantine(quarantineRepo))
+
+	quarantineRepo.On("CreateBatch", mock.Anything, mock.Anything).Return(errors.New("connection refused"))
+
+	_, _, err := uc.CreateEvent(context.Background(), models.CreateEventRequest{})
+
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+}
+
+func TestNewQuarantineEntry_TruncatesRaw(t *testing.T) {
+	entry := NewQuarantineEntry(models.CreateEventRequest{
+		EventName: "big",
+		Payload:   map[string]interface{}{"blob": strings.Repeat("x", MaxQuarantineRawSize)},
+	}, ErrPayloadTooLarge)
+
+	assert.True(t, entry.Truncated)
+	assert.Len(t, entry.Raw, MaxQuarantineRawSize)
+	assert.Greater(t, entry.RawSize, MaxQuarantineRawSize)
+	assert.Equal(t, models.QuarantineReasonPayloadTooLarge, entry.Reason)
+}
+
+func TestReprocess_Success(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	quarantineRepo := new(MockQuarantineRepository)
+	eventUC := NewEventUsecase(mockRepo, WithQuarantine(quarantineRepo))
+	uc := NewQuarantineUsecase(quarantineRepo, eventUC)
+
+	ts := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	quarantineRepo.On("GetByID", mock.Anything, int64(7)).
+		Return(quarantined(7, models.CreateEventRequest{EventName: "app_launch", Timestamp: ts}), nil)
+	mockRepo.On("CreateIdempotent", mock.Anything, mock.MatchedBy(func(e *models.Event) bool {
+		return e.IdempotencyKey == "quarantine-7" && e.Timestamp.Equal(ts)
+	}), DefaultIdempotencyWindow).Return(false, nil)
+	quarantineRepo.On("MarkReprocessed", mock.Anything, int64(7), mock.MatchedBy(func(id *int64) bool {
+		return id != nil && *id == 1
+	})).Return(true, nil)
+
+	event, err := uc.Reprocess(context.Background(), 7)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), event.ID)
+	mockRepo.AssertExpectations(t)
+	quarantineRepo.AssertExpectations(t)
+}
+
+func TestReprocess_RejectedAgainIsNotRequarantined(t *testing.T) {
+	quarantineRepo := new(MockQuarantineRepository)
+	eventUC := NewEventUsecase(new(MockEventRepository), WithQuarantine(quarantineRepo))
+	uc := NewQuarantineUsecase(quarantineRepo, eventUC)
+
+	quarantineRepo.On("GetByID", mock.Anything, int64(3)).Return(quarantined(3, models.CreateEventRequest{}), nil)
+
+	_, err := uc.Reprocess(context.Background(), 3)
+
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+	quarantineRepo.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+	quarantineRepo.AssertNotCalled(t, "MarkReprocessed", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReprocess_NotReprocessable(t *testing.T) {
+	quarantineRepo := new(MockQuarantineRepository)
+	uc := NewQuarantineUsecase(quarantineRepo, NewEventUsecase(new(MockEventRepository)))
+
+	now := time.Now()
+	quarantineRepo.On("GetByID", mock.Anything, int64(1)).Return(&models.QuarantinedEvent{ID: 1, Raw: `{"event_name":`}, nil)
+	quarantineRepo.On("GetByID", mock.Anything, int64(2)).Return(&models.QuarantinedEvent{ID: 2, Truncated: true}, nil)
+	quarantineRepo.On("GetByID", mock.Anything, int64(3)).Return(&models.QuarantinedEvent{ID: 3, ReprocessedAt: &now}, nil)
+	quarantineRepo.On("GetByID", mock.Anything, int64(4)).Return(nil, nil)
+
+	_, err := uc.Reprocess(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrNotReprocessable)
+	_, err = uc.Reprocess(context.Background(), 2)
+	assert.ErrorIs(t, err, ErrNotReprocessable)
+	_, err = uc.Reprocess(context.Background(), 3)
+	assert.ErrorIs(t, err, ErrAlreadyReprocessed)
+	_, err = uc.Reprocess(context.Background(), 4)
+	assert.ErrorIs(t, err, ErrQuarantineNotFound)
+}
+
+func TestReprocessPending(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	quarantineRepo := new(MockQuarantineRepository)
+	uc := NewQuarantineUsecase(quarantineRepo, NewEventUsecase(mockRepo))
+
+	quarantineRepo.On("List", mock.Anything, mock.MatchedBy(func(f models.QuarantineFilter) bool {
+		return f.Reprocessed != nil && !*f.Reprocessed && f.EventName == "app_launch" && f.AfterID == 10 && f.Limit == 100
+	})).Return([]models.QuarantinedEvent{
+		*quarantined(11, models.CreateEventRequest{EventName: "app_launch"}),
+		*quarantined(12, models.CreateEventRequest{}),
+	}, nil)
+	mockRepo.On("CreateIdempotent", mock.Anything, mock.AnythingOfType("*models.Event"), DefaultIdempotencyWindow).Return(false, nil)
+	quarantineRepo.On("MarkReprocessed", mock.Anything, int64(11), mock.Anything).Return(true, nil)
+
+	resp, err := uc.ReprocessPending(context.Background(), models.QuarantineFilter{EventName: "app_launch", AfterID: 10})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.Reprocessed)
+	assert.Equal(t, 1, resp.Rejected)
+	assert.Equal(t, int64(12), resp.LastID)
+	assert.Equal(t, models.ReprocessStatusRejected, resp.Results[1].Status)
+	quarantineRepo.AssertExpectations(t)
+}
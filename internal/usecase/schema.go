@@ -0,0 +1,278 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/repo"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+var (
+	ErrSchemaNotFound = errors.New("schema not found")
+	ErrInvalidSchema  = errors.New("invalid schema")
+)
+
+// schemaCacheTTL bounds how long a compiled schema is reused before the
+// registry is consulted again, so versions registered through another
+// instance are picked up.
+const schemaCacheTTL = 30 * time.Second
+
+// ValidationError reports a payload that does not conform to its event's
+// schema. It wraps ErrInvalidEvent.
+type ValidationError struct {
+	EventName string
+	Version   int
+	Fields    []models.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	if e.Version == 0 {
+		return fmt.Sprintf("payload does not match schema for %q", e.EventName)
+	}
+	return fmt.Sprintf("payload does not match schema for %q version %d", e.EventName, e.Version)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidEvent
+}
+
+// PayloadValidator checks an event payload against the registered schema.
+// Version zero selects the latest active schema; events without a schema
+// are accepted.
+type PayloadValidator interface {
+	ValidatePayload(ctx context.Context, eventName string, version int, payload map[string]interface{}) error
+}
+
+type SchemaUsecase interface {
+	PayloadValidator
+	CreateSchema(ctx context.Context, req models.CreateSchemaRequest) (*models.EventSchema, error)
+	GetSchema(ctx context.Context, eventName string, version int) (*models.EventSchema, error)
+	ListSchemas(ctx context.Context, eventName string) ([]models.EventSchema, error)
+	SetSchemaActive(ctx context.Context, eventName string, version int, active bool) error
+}
+
+type compiledSchema struct {
+	version  int
+	schema   *jsonschema.Schema
+	loadedAt time.Time
+}
+
+type schemaUsecase struct {
+	repo repo.SchemaRepository
+
+	mu sync.RWMutex
+	// cache holds the compiled schema per "name" (latest) and "name@version";
+	// a nil schema records that no schema is registered
+	cache map[string]compiledSchema
+}
+
+func NewSchemaUsecase(repo repo.SchemaRepository) SchemaUsecase {
+	return &schemaUsecase{
+		repo:  repo,
+		cache: make(map[string]compiledSchema),
+	}
+}
+
+func (u *schemaUsecase) CreateSchema(ctx context.Context, req models.CreateSchemaRequest) (*models.EventSchema, error) {
+	if req.EventName == "" {
+		return nil, fmt.Errorf("%w: event_name is required", ErrInvalidSchema)
+	}
+	if _, err := compileSchema(req.EventName, req.Schema); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+
+	schema := &models.EventSchema{
+		EventName: req.EventName,
+		Schema:    req.Schema,
+	}
+	if err := u.repo.Create(ctx, schema); err != nil {
+		log.Printf("usecase.CreateSchema: repo.Create failed: %v", err)
+		return nil, err
+	}
+
+	u.invalidate(req.EventName)
+	return schema, nil
+}
+
+func (u *schemaUsecase) GetSchema(ctx context.Context, eventName string, version int) (*models.EventSchema, error) {
+	schema, err := u.repo.Get(ctx, eventName, version)
+	if err != nil {
+		log.Printf("usecase.GetSchema: repo.Get failed: %v", err)
+		return nil, err
+	}
+	if schema == nil {
+		return nil, ErrSchemaNotFound
+	}
+	return schema, nil
+}
+
+func (u *schemaUsecase) ListSchemas(ctx context.Context, eventName string) ([]models.EventSchema, error) {
+	schemas, err := u.repo.List(ctx, eventName)
+	if err != nil {
+		log.Printf("usecase.ListSchemas: repo.List failed: %v", err)
+		return nil, err
+	}
+	return schemas, nil
+}
+
+func (u *schemaUsecase) SetSchemaActive(ctx context.Context, eventName string, version int, active bool) error {
+	found, err := u.repo.SetActive(ctx, eventName, version, active)
+	if err != nil {
+		log.Printf("usecase.SetSchemaActive: repo.SetActive failed: %v", err)
+		return err
+	}
+	if !found {
+		return ErrSchemaNotFound
+	}
+
+	u.invalidate(eventName)
+	return nil
+}
+
+// ValidatePayload fails open: if the registry cannot be read, the payload
+// is accepted so ingestion keeps working while the database is degraded.
+func (u *schemaUsecase) ValidatePayload(ctx context.Context, eventName string, version int, payload map[string]interface{}) error {
+	compiled, err := u.lookup(ctx, eventName, version)
+	if err != nil {
+		log.Printf("usecase.ValidatePayload: schema lookup for %q failed, skipping validation: %v", eventName, err)
+		return nil
+	}
+
+	if compiled.schema == nil {
+		if version != 0 {
+			return &ValidationError{
+				EventName: eventName,
+				Version:   version,
+				Fields:    []models.FieldError{{Message: fmt.Sprintf("schema version %d is not registered", version)}},
+			}
+		}
+		return nil
+	}
+
+	// Validate the payload as it will be stored, i.e. after a JSON round
+	// trip, so numbers and nested values have their decoded types
+	var doc interface{}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("unmarshal payload: %w", err)
+	}
+
+	if err := compiled.schema.Validate(doc); err != nil {
+		var verr *jsonschema.ValidationError
+		if !errors.As(err, &verr) {
+			return fmt.Errorf("validate payload: %w", err)
+		}
+		return &ValidationError{
+			EventName: eventName,
+			Version:   compiled.version,
+			Fields:    fieldErrors(verr),
+		}
+	}
+
+	return nil
+}
+
+func (u *schemaUsecase) lookup(ctx context.Context, eventName string, version int) (compiledSchema, error) {
+	key := eventName
+	if version != 0 {
+		key = fmt.Sprintf("%s@%d", eventName, version)
+	}
+
+	u.mu.RLock()
+	cached, ok := u.cache[key]
+	u.mu.RUnlock()
+	if ok && time.Since(cached.loadedAt) < schemaCacheTTL {
+		return cached, nil
+	}
+
+	var (
+		schema *models.EventSchema
+		err    error
+	)
+	if version != 0 {
+		schema, err = u.repo.Get(ctx, eventName, version)
+	} else {
+		schema, err = u.repo.GetLatest(ctx, eventName)
+	}
+	if err != nil {
+		return compiledSchema{}, err
+	}
+
+	compiled := compiledSchema{loadedAt: time.Now()}
+	if schema != nil && (schema.Active || version != 0) {
+		compiled.version = schema.Version
+		compiled.schema, err = compileSchema(eventName, schema.Schema)
+		if err != nil {
+			return compiledSchema{}, err
+		}
+	}
+
+	u.mu.Lock()
+	u.cache[key] = compiled
+	u.mu.Unlock()
+
+	return compiled, nil
+}
+
+func (u *schemaUsecase) invalidate(eventName string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for key := range u.cache {
+		if key == eventName || strings.HasPrefix(key, eventName+"@") {
+			delete(u.cache, key)
+		}
+	}
+}
+
+func compileSchema(eventName string, document json.RawMessage) (*jsonschema.Schema, error) {
+	if len(bytes.TrimSpace(document)) == 0 {
+		return nil, errors.New("schema is required")
+	}
+
+	url := "schema://" + eventName + ".json"
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(url, bytes.NewReader(document)); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(url)
+}
+
+// fieldErrors flattens a validation error tree into its leaf failures,
+// which are the ones that name a concrete field and constraint.
+func fieldErrors(verr *jsonschema.ValidationError) []models.FieldError {
+	var fields []models.FieldError
+
+	var walk func(*jsonschema.ValidationError)
+	walk = func(ve *jsonschema.ValidationError) {
+		if len(ve.Causes) == 0 {
+			keyword := ve.KeywordLocation
+			if i := strings.LastIndexByte(keyword, '/'); i >= 0 {
+				keyword = keyword[i+1:]
+			}
+			fields = append(fields, models.FieldError{
+				Field:   ve.InstanceLocation,
+				Keyword: keyword,
+				Message: ve.Message,
+			})
+			return
+		}
+		for _, cause := range ve.Causes {
+			walk(cause)
+		}
+	}
+	walk(verr)
+
+	return fields
+}
@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSchemaRepository is a mock implementation of SchemaRepository
+type MockSchemaRepository struct {
+	mock.Mock
+}
+
+func (m *MockSchemaRepository) Create(ctx context.Context, schema *models.EventSchema) error {
+	args := m.Called(ctx, schema)
+	if args.Error(0) == nil {
+		schema.Version = 1
+		schema.Active = true
+	}
+	return args.Error(0)
+}
+
+func (m *MockSchemaRepository) Get(ctx context.Context, eventName string, version int) (*models.EventSchema, error) {
+	args := m.Called(ctx, eventName, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EventSchema), args.Error(1)
+}
+
+func (m *MockSchemaRepository) GetLatest(ctx context.Context, eventName string) (*models.EventSchema, error) {
+	args := m.Called(ctx, eventName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.EventSchema), args.Error(1)
+}
+
+func (m *MockSchemaRepository) List(ctx context.Context, eventName string) ([]models.EventSchema, error) {
+	args := m.Called(ctx, eventName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.EventSchema), args.Error(1)
+}
+
+func (m *MockSchemaRepository) SetActive(ctx context.Context, eventName string, version int, active bool) (bool, error) {
+	args := m.Called(ctx, eventName, version, active)
+	return args.Bool(0), args.Error(1)
+}
+
+const appLaunchSchema = `{
+	"type": "object",
+	"required": ["version"],
+	"properties": {
+		"version": {"type": "string"},
+		"duration_ms": {"type": "integer", "minimum": 0}
+	}
+}`
+
+func appLaunch(version int) *models.EventSchema {
+	return &models.EventSchema{
+		EventName: "app_launch",
+		Version:   version,
+		Schema:    json.RawMessage(appLaunchSchema),
+		Active:    true,
+	}
+}
+
+func TestCreateSchema_Success(t *testing.T) {
+	mockRepo := new(MockSchemaRepository)
+	uc := NewSchemaUsecase(mockRepo)
+
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.EventSchema")).Return(nil)
+
+	schema, err := uc.CreateSchema(context.Background(), models.CreateSchemaRequest{
+		EventName: "app_launch",
+		Schema:    json.RawMessage(appLaunchSchema),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, schema.Version)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateSchema_Invalid(t *testing.T) {
+	mockRepo := new(MockSchemaRepository)
+	uc := NewSchemaUsecase(mockRepo)
+
+	for _, doc := range []string{``, `{"type": 42}`, `not json`} {
+		_, err := uc.CreateSchema(context.Background(), models.CreateSchemaRequest{
+			EventName: "app_launch",
+			Schema:    json.RawMessage(doc),
+		})
+		assert.ErrorIs(t, err, ErrInvalidSchema, doc)
+	}
+
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestValidatePayload_ReportsFailingFields(t *testing.T) {
+	mockRepo := new(MockSchemaRepository)
+	uc := NewSchemaUsecase(mockRepo)
+
+	mockRepo.On("GetLatest", mock.Anything, "app_launch").Return(appLaunch(2), nil)
+
+	err := uc.ValidatePayload(context.Background(), "app_launch", 0, map[string]interface{}{"duration_ms": -5})
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.ErrorIs(t, err, ErrInvalidEvent)
+	assert.Equal(t, 2, verr.Version)
+	require.Len(t, verr.Fields, 2)
+
+	keywords := map[string]string{}
+	for _, field := range verr.Fields {
+		keywords[field.Keyword] = field.Field
+	}
+	assert.Equal(t, "", keywords["required"])
+	assert.Equal(t, "/duration_ms", keywords["minimum"])
+}
+
+func TestValidatePayload_CachesCompiledSchema(t *testing.T) {
+	mockRepo := new(MockSchemaRepository)
+	uc := NewSchemaUsecase(mockRepo)
+
+	mockRepo.On("GetLatest", mock.Anything, "app_launch").Return(appLaunch(1), nil).Once()
+
+	payload := map[string]interface{}{"version": "1.0", "duration_ms": 120}
+	assert.NoError(t, uc.ValidatePayload(context.Background(), "app_launch", 0, payload))
+	assert.NoError(t, uc.ValidatePayload(context.Background(), "app_launch", 0, payload))
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestValidatePayload_NoSchemaAccepts(t *testing.T) {
+	mockRepo := new(MockSchemaRepository)
+	uc := NewSchemaUsecase(mockRepo)
+
+	mockRepo.On("GetLatest", mock.Anything, "unknown").Return(nil, nil)
+
+	assert.NoError(t, uc.ValidatePayload(context.Background(), "unknown", 0, map[string]interface{}{"a": 1}))
+}
+
+func TestValidatePayload_UnknownVersionRejects(t *testing.T) {
+	mockRepo := new(MockSchemaRepository)
+	uc := NewSchemaUsecase(mockRepo)
+
+	mockRepo.On("Get", mock.Anything, "app_launch", 9).Return(nil, nil)
+
+	err := uc.ValidatePayload(context.Background(), "app_launch", 9, nil)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, 9, verr.Version)
+}
+
+func TestValidatePayload_FailsOpenOnRepoError(t *testing.T) {
+	mockRepo := new(MockSchemaRepository)
+	uc := NewSchemaUsecase(mockRepo)
+
+	mockRepo.On("GetLatest", mock.Anything, "app_launch").Return(nil, errors.New("connection refused"))
+
+	assert.NoError(t, uc.ValidatePayload(context.Background(), "app_launch", 0, nil))
+}
+
+func TestSetSchemaActive_InvalidatesCache(t *testing.T) {
+	mockRepo := new(MockSchemaRepository)
+	uc := NewSchemaUsecase(mockRepo)
+
+	mockRepo.On("GetLatest", mock.Anything, "app_launch").Return(appLaunch(1), nil).Once()
+	mockRepo.On("SetActive", mock.Anything, "app_launch", 1, false).Return(true, nil)
+	mockRepo.On("GetLatest", mock.Anything, "app_launch").Return(nil, nil).Once()
+
+	assert.Error(t, uc.ValidatePayload(context.Background(), "app_launch", 0, map[string]interface{}{}))
+	require.NoError(t, uc.SetSchemaActive(context.Background(), "app_launch", 1, false))
+	assert.NoError(t, uc.ValidatePayload(context.Background(), "app_launch", 0, map[string]interface{}{}))
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSetSchemaActive_NotFound(t *testing.T) {
+	mockRepo := new(MockSchemaRepository)
+	uc := NewSchemaUsecase(mockRepo)
+
+	mockRepo.On("SetActive", mock.Anything, "app_launch", 3, false).Return(false, nil)
+
+	assert.ErrorIs(t, uc.SetSchemaActive(context.Background(), "app_launch", 3, false), ErrSchemaNotFound)
+}
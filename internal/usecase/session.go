@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+const (
+	// MaxSessionGap bounds the inactivity gap splitting sessions.
+	MaxSessionGap = 24 * time.Hour
+	// MaxSessionRange bounds the range sessions are reconstructed over, as
+	// that takes a pass over all events in it.
+	MaxSessionRange = 31 * 24 * time.Hour
+	// DefaultUserSessions and MaxUserSessions bound how many sessions of an
+	// install are listed, MaxSessionEvents how many of its latest events
+	// they are built from.
+	DefaultUserSessions = 20
+	MaxUserSessions     = 100
+	MaxSessionEvents    = 5000
+)
+
+// DefaultSessionGap is the inactivity gap used unless asked otherwise.
+var DefaultSessionGap = models.Interval{Count: 30, Unit: "m"}
+
+// sessionQuery applies the defaults shared by session statistics and
+// listings: a 30 minute gap over the last 7 days.
+func sessionQuery(q models.SessionQuery) (models.SessionQuery, error) {
+	if q.Gap == (models.Interval{}) {
+		q.Gap = DefaultSessionGap
+	}
+	if q.Gap.Unit == "w" || q.Gap.Unit == "mo" || q.Gap.Approx() > MaxSessionGap {
+		return q, fmt.Errorf("%w: gap must be at most %s", ErrInvalidAnalyticsQuery, MaxSessionGap)
+	}
+	if q.To.IsZero() {
+		q.To = time.Now().UTC()
+	}
+	if q.From.IsZero() {
+		q.From = q.To.Add(-7 * 24 * time.Hour)
+	}
+	if !q.To.After(q.From) {
+		return q, fmt.Errorf("%w: to must be after from", ErrInvalidAnalyticsQuery)
+	}
+	if q.To.Sub(q.From) > MaxSessionRange {
+		return q, fmt.Errorf("%w: sessions span at most %d days", ErrInvalidAnalyticsQuery, MaxSessionRange/(24*time.Hour))
+	}
+	return q, nil
+}
+
+// GetSessionStats reports the number, length and size of the sessions
+// started on each day of the range.
+func (u *analyticsUsecase) GetSessionStats(ctx context.Context, q models.SessionQuery) (*models.SessionStatsResponse, error) {
+	q, err := sessionQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := u.repo.GetSessionStats(ctx, q)
+	if err != nil {
+		log.Printf("usecase.GetSessionStats: repo.GetSessionStats failed: %v", err)
+		return nil, err
+	}
+
+	return &models.SessionStatsResponse{
+		Gap:      q.Gap.String(),
+		From:     q.From,
+		To:       q.To,
+		Timezone: models.TimezoneName(q.Location),
+		Days:     days,
+	}, nil
+}
+
+// ListUserSessions reconstructs the latest sessions of one install from its
+// events, which are found by payload.user_id.
+func (u *eventUsecase) ListUserSessions(ctx context.Context, userID string, q models.SessionQuery, limit int) (*models.UserSessions, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("%w: user_id is required", ErrInvalidAnalyticsQuery)
+	}
+	q, err := sessionQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultUserSessions
+	}
+	if limit > MaxUserSessions {
+		limit = MaxUserSessions
+	}
+
+	events, err := u.repo.ListByUser(ctx, models.UserEventFilter{
+		UserID:     userID,
+		From:       &q.From,
+		To:         &q.To,
+		Limit:      MaxSessionEvents,
+		Descending: true,
+	})
+	if err != nil {
+		log.Printf("usecase.ListUserSessions: repo.ListByUser failed: %v", err)
+		return nil, err
+	}
+
+	sessions := splitSessions(events, q.Gap.Approx())
+	if len(sessions) > limit {
+		sessions = sessions[:limit]
+	}
+
+	return &models.UserSessions{
+		UserID:    userID,
+		Gap:       q.Gap.String(),
+		From:      q.From,
+		To:        q.To,
+		Truncated: len(events) == MaxSessionEvents,
+		Sessions:  sessions,
+	}, nil
+}
+
+// splitSessions groups events, newest first as listed by the repository,
+// into sessions, newest first.
+func splitSessions(events []models.Event, gap time.Duration) []models.Session {
+	sessions := []models.Session{}
+	for i := len(events) - 1; i >= 0; i-- {
+		event := events[i]
+		n := len(sessions)
+		if n == 0 || event.Timestamp.Sub(sessions[n-1].End) >= gap {
+			sessions = append(sessions, models.Session{Start: event.Timestamp})
+			n++
+		}
+		s := &sessions[n-1]
+		s.End = event.Timestamp
+		s.Events = append(s.Events, event)
+	}
+
+	for i, j := 0, len(sessions)-1; i < j; i, j = i+1, j-1 {
+		sessions[i], sessions[j] = sessions[j], sessions[i]
+	}
+	for i := range sessions {
+		s := &sessions[i]
+		s.EventCount = len(s.Events)
+		s.DurationSeconds = s.End.Sub(s.Start).Seconds()
+	}
+	return sessions
+}
@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitSessions(t *testing.T) {
+	start := time.Date(2026, 3, 8, 8, 0, 0, 0, time.UTC)
+	at := func(id int64, minutes int) models.Event {
+		return models.Event{ID: id, Timestamp: start.Add(time.Duration(minutes) * time.Minute)}
+	}
+
+	// Newest first, as listed by the repository
+	events := []models.Event{at(5, 200), at(4, 100), at(3, 75), at(2, 50), at(1, 0)}
+
+	sessions := splitSessions(events, 30*time.Minute)
+	require.Len(t, sessions, 3)
+
+	assert.Equal(t, 1, sessions[0].EventCount)
+	assert.Equal(t, int64(5), sessions[0].Events[0].ID)
+
+	assert.Equal(t, 3, sessions[1].EventCount)
+	assert.Equal(t, []int64{2, 3, 4}, []int64{sessions[1].Events[0].ID, sessions[1].Events[1].ID, sessions[1].Events[2].ID})
+	assert.Equal(t, float64(50*60), sessions[1].DurationSeconds)
+
+	assert.Equal(t, int64(1), sessions[2].Events[0].ID)
+	assert.Zero(t, sessions[2].DurationSeconds)
+
+	assert.Empty(t, splitSessions(nil, time.Minute))
+}
+
+func TestListUserSessions(t *testing.T) {
+	mockRepo := new(MockEventRepository)
+	uc := NewEventUsecase(mockRepo)
+	ctx := context.Background()
+
+	start := time.Date(2026, 3, 8, 8, 0, 0, 0, time.UTC)
+	mockRepo.On("ListByUser", ctx, mock.MatchedBy(func(f models.UserEventFilter) bool {
+		return f.UserID == "install-1" && f.Limit == MaxSessionEvents && f.Descending
+	})).Return([]models.Event{
+		{ID: 3, Timestamp: start.Add(3 * time.Hour)},
+		{ID: 2, Timestamp: start.Add(2 * time.Hour)},
+		{ID: 1, Timestamp: start},
+	}, nil)
+
+	resp, err := uc.ListUserSessions(ctx, "install-1", models.SessionQuery{}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "30m", resp.Gap)
+	assert.Len(t, resp.Sessions, 2)
+	assert.False(t, resp.Truncated)
+	mockRepo.AssertExpectations(t)
+
+	for _, q := range []models.SessionQuery{
+		{Gap: models.Interval{Count: 1, Unit: "w"}},
+		{Gap: models.Interval{Count: 25, Unit: "h"}},
+		{From: start.AddDate(0, -2, 0), To: start},
+	} {
+		_, err := uc.ListUserSessions(ctx, "install-1", q, 0)
+		assert.ErrorIs(t, err, ErrInvalidAnalyticsQuery)
+	}
+	_, err = uc.ListUserSessions(ctx, "", models.SessionQuery{}, 0)
+	assert.ErrorIs(t, err, ErrInvalidAnalyticsQuery)
+}
+
+func TestGetSessionStats(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	uc := NewAnalyticsUsecase(mockRepo)
+	ctx := context.Background()
+
+	to := time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)
+	mockRepo.On("GetSessionStats", ctx, models.SessionQuery{Gap: DefaultSessionGap, From: to.Add(-7 * 24 * time.Hour), To: to}).
+		Return([]models.SessionStats{{Day: to, Sessions: 3}}, nil)
+
+	resp, err := uc.GetSessionStats(ctx, models.SessionQuery{To: to})
+	require.NoError(t, err)
+	assert.Equal(t, "UTC", resp.Timezone)
+	assert.Len(t, resp.Days, 1)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,254 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/internal/repo"
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+)
+
+var (
+	ErrSignatureRequired     = errors.New("request signature required")
+	ErrInvalidSignature      = errors.New("invalid request signature")
+	ErrStaleSignature        = fmt.Errorf("%w: timestamp outside the allowed window", ErrInvalidSignature)
+	ErrInvalidSigningSecret  = errors.New("invalid signing secret request")
+	ErrSigningSecretNotFound = errors.New("signing secret not found")
+)
+
+// Signing modes. With SigningOptional unsigned requests are accepted but
+// signed ones must verify; SigningRequired rejects unsigned requests.
+const (
+	SigningOff      = "off"
+	SigningOptional = "optional"
+	SigningRequired = "required"
+)
+
+const (
+	// DefaultSignatureTolerance is how far a signature timestamp may be
+	// from the server clock unless configured otherwise.
+	DefaultSignatureTolerance = 5 * time.Minute
+	// signatureScheme prefixes the hex-encoded signature so the algorithm
+	// can be changed later without guessing.
+	signatureScheme = "sha256="
+	// signingSecretCacheTTL bounds how long a revoked secret keeps working
+	// on instances other than the one it was revoked through.
+	signingSecretCacheTTL = 30 * time.Second
+	maxReleaseLength      = 64
+)
+
+type SigningUsecase interface {
+	// Mode returns the configured signing mode.
+	Mode() string
+	Verify(ctx context.Context, sig models.RequestSignature, body []byte) error
+	CreateSigningSecret(ctx context.Context, req models.CreateSigningSecretRequest) (*models.CreatedSigningSecret, error)
+	ListSigningSecrets(ctx context.Context) ([]models.SigningSecret, error)
+	RevokeSigningSecret(ctx context.Context, id int64) error
+}
+
+type SigningConfig struct {
+	// Mode is one of SigningOff, SigningOptional and SigningRequired.
+	Mode string
+	// Tolerance is how far a signature timestamp may be from the server
+	// clock, which bounds how long a captured request can be replayed.
+	Tolerance time.Duration
+}
+
+type cachedSigningSecrets struct {
+	secrets  []models.SigningSecret
+	loadedAt time.Time
+}
+
+type signingUsecase struct {
+	repo repo.SigningSecretRepository
+	cfg  SigningConfig
+	now  func() time.Time
+
+	mu sync.RWMutex
+	// cache holds the active secrets of releases that have any
+	cache map[string]cachedSigningSecrets
+}
+
+func NewSigningUsecase(repo repo.SigningSecretRepository, cfg SigningConfig) SigningUsecase {
+	if cfg.Mode == "" {
+		cfg.Mode = SigningOff
+	}
+	if cfg.Tolerance <= 0 {
+		cfg.Tolerance = DefaultSignatureTolerance
+	}
+	return &signingUsecase{
+		repo:  repo,
+		cfg:   cfg,
+		now:   time.Now,
+		cache: make(map[string]cachedSigningSecrets),
+	}
+}
+
+// ValidSigningMode reports whether mode is a known signing mode.
+func ValidSigningMode(mode string) bool {
+	switch mode {
+	case SigningOff, SigningOptional, SigningRequired:
+		return true
+	}
+	return false
+}
+
+func (u *signingUsecase) Mode() string {
+	return u.cfg.Mode
+}
+
+// Verify checks the HMAC-SHA256 of "<timestamp>.<body>" against the active
+// secrets of the signing release.
+func (u *signingUsecase) Verify(ctx context.Context, sig models.RequestSignature, body []byte) error {
+	if u.cfg.Mode == SigningOff {
+		return nil
+	}
+	if !sig.Signed() {
+		if u.cfg.Mode == SigningRequired {
+			return ErrSignatureRequired
+		}
+		return nil
+	}
+	if sig.Release == "" || sig.Timestamp == "" || sig.Signature == "" {
+		return fmt.Errorf("%w: release, timestamp and signature are all required", ErrInvalidSignature)
+	}
+
+	unix, err := strconv.ParseInt(sig.Timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp", ErrInvalidSignature)
+	}
+	skew := u.now().Sub(time.Unix(unix, 0))
+	if skew > u.cfg.Tolerance || skew < -u.cfg.Tolerance {
+		return ErrStaleSignature
+	}
+
+	mac, err := hex.DecodeString(strings.TrimPrefix(sig.Signature, signatureScheme))
+	if err != nil || !strings.HasPrefix(sig.Signature, signatureScheme) {
+		return fmt.Errorf("%w: malformed signature", ErrInvalidSignature)
+	}
+
+	secrets, err := u.activeSecrets(ctx, sig.Release)
+	if err != nil {
+		return err
+	}
+	for _, secret := range secrets {
+		if hmac.Equal(mac, signBody(secret.Key, sig.Timestamp, body)) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+func (u *signingUsecase) activeSecrets(ctx context.Context, release string) ([]models.SigningSecret, error) {
+	u.mu.RLock()
+	cached, ok := u.cache[release]
+	u.mu.RUnlock()
+	if ok && time.Since(cached.loadedAt) < signingSecretCacheTTL {
+		return cached.secrets, nil
+	}
+
+	secrets, err := u.repo.ListActive(ctx, release)
+	if err != nil {
+		// Keep accepting known releases while the database is unreachable,
+		// so ingestion can fall back to the spool
+		if ok {
+			log.Printf("usecase.Verify: repo.ListActive failed, using cached secrets: %v", err)
+			return cached.secrets, nil
+		}
+		log.Printf("usecase.Verify: repo.ListActive failed: %v", err)
+		return nil, err
+	}
+
+	// Like unknown API keys, unknown releases are not cached so arbitrary
+	// release headers cannot grow the cache
+	if len(secrets) > 0 {
+		u.mu.Lock()
+		u.cache[release] = cachedSigningSecrets{secrets: secrets, loadedAt: time.Now()}
+		u.mu.Unlock()
+	}
+
+	return secrets, nil
+}
+
+func (u *signingUsecase) CreateSigningSecret(ctx context.Context, req models.CreateSigningSecretRequest) (*models.CreatedSigningSecret, error) {
+	release := strings.TrimSpace(req.Release)
+	if release == "" {
+		return nil, fmt.Errorf("%w: release is required", ErrInvalidSigningSecret)
+	}
+	if len(release) > maxReleaseLength {
+		return nil, fmt.Errorf("%w: release must be at most %d characters", ErrInvalidSigningSecret, maxReleaseLength)
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("generate signing secret: %w", err)
+	}
+	// Clients use the encoded string as HMAC key, so it can be embedded in
+	// configuration files and shell scripts as is
+	plaintext := base64.RawURLEncoding.EncodeToString(raw)
+
+	secret := &models.SigningSecret{Release: release, Key: []byte(plaintext)}
+	if err := u.repo.Create(ctx, secret); err != nil {
+		log.Printf("usecase.CreateSigningSecret: repo.Create failed: %v", err)
+		return nil, err
+	}
+
+	u.mu.Lock()
+	delete(u.cache, release)
+	u.mu.Unlock()
+
+	return &models.CreatedSigningSecret{SigningSecret: *secret, Secret: plaintext}, nil
+}
+
+func (u *signingUsecase) ListSigningSecrets(ctx context.Context) ([]models.SigningSecret, error) {
+	secrets, err := u.repo.List(ctx)
+	if err != nil {
+		log.Printf("usecase.ListSigningSecrets: repo.List failed: %v", err)
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// RevokeSigningSecret stops accepting signatures made with a secret, e.g.
+// after it leaked from a release image.
+func (u *signingUsecase) RevokeSigningSecret(ctx context.Context, id int64) error {
+	found, err := u.repo.Revoke(ctx, id)
+	if err != nil {
+		log.Printf("usecase.RevokeSigningSecret: repo.Revoke failed: %v", err)
+		return err
+	}
+	if !found {
+		return ErrSigningSecretNotFound
+	}
+
+	u.mu.Lock()
+	u.cache = make(map[string]cachedSigningSecrets)
+	u.mu.Unlock()
+	return nil
+}
+
+// SignRequest returns the signature header value for body, as computed by
+// clients.
+func SignRequest(key []byte, timestamp string, body []byte) string {
+	return signatureScheme + hex.EncodeToString(signBody(key, timestamp, body))
+}
+
+func signBody(key []byte, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
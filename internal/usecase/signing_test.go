@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/herpiko/blankon-telemetry-backend/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSigningSecretRepository is a mock implementation of SigningSecretRepository
+type MockSigningSecretRepository struct {
+	mock.Mock
+}
+
+func (m *MockSigningSecretRepository) Create(ctx context.Context, secret *models.SigningSecret) error {
+	args := m.Called(ctx, secret)
+	if args.Error(0) == nil {
+		secret.ID = 1
+		secret.CreatedAt = time.Now()
+	}
+	return args.Error(0)
+}
+
+func (m *MockSigningSecretRepository) ListActive(ctx context.Context, release string) ([]models.SigningSecret, error) {
+	args := m.Called(ctx, release)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SigningSecret), args.Error(1)
+}
+
+func (m *MockSigningSecretRepository) List(ctx context.Context) ([]models.SigningSecret, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SigningSecret), args.Error(1)
+}
+
+func (m *MockSigningSecretRepository) Revoke(ctx context.Context, id int64) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+// signed returns the signature headers a client of release would send for
+// body at ts.
+func signed(release string, key []byte, ts time.Time, body []byte) models.RequestSignature {
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	return models.RequestSignature{
+		Release:   release,
+		Timestamp: timestamp,
+		Signature: SignRequest(key, timestamp, body),
+	}
+}
+
+func TestVerify(t *testing.T) {
+	mockRepo := new(MockSigningSecretRepository)
+	uc := NewSigningUsecase(mockRepo, SigningConfig{Mode: SigningRequired})
+	ctx := context.Background()
+	body := []byte(`{"event_name":"app_launch"}`)
+	now := time.Now()
+
+	oldKey, newKey := []byte("old-secret"), []byte("new-secret")
+	mockRepo.On("ListActive", ctx, "blankon-12").Return([]models.SigningSecret{{ID: 1, Key: oldKey}, {ID: 2, Key: newKey}}, nil).Once()
+	mockRepo.On("ListActive", ctx, "blankon-11").Return([]models.SigningSecret{}, nil)
+
+	assert.NoError(t, uc.Verify(ctx, signed("blankon-12", oldKey, now, body), body))
+	assert.NoError(t, uc.Verify(ctx, signed("blankon-12", newKey, now, body), body))
+
+	tests := []struct {
+		name string
+		sig  models.RequestSignature
+		err  error
+	}{
+		{"unsigned", models.RequestSignature{}, ErrSignatureRequired},
+		{"wrong secret", signed("blankon-12", []byte("guess"), now, body), ErrInvalidSignature},
+		{"tampered body", signed("blankon-12", oldKey, now, []byte(`{}`)), ErrInvalidSignature},
+		{"unknown release", signed("blankon-11", oldKey, now, body), ErrInvalidSignature},
+		{"replayed", signed("blankon-12", oldKey, now.Add(-10*time.Minute), body), ErrStaleSignature},
+		{"from the future", signed("blankon-12", oldKey, now.Add(10*time.Minute), body), ErrStaleSignature},
+		{"missing timestamp", models.RequestSignature{Release: "blankon-12", Signature: "sha256=00"}, ErrInvalidSignature},
+		{"no scheme", models.RequestSignature{Release: "blankon-12", Timestamp: "1", Signature: "00"}, ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, uc.Verify(ctx, tt.sig, body), tt.err)
+		})
+	}
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestVerify_Modes(t *testing.T) {
+	ctx := context.Background()
+	body := []byte(`{}`)
+	bogus := models.RequestSignature{Release: "blankon-12", Timestamp: "0", Signature: "sha256=00"}
+
+	off := NewSigningUsecase(new(MockSigningSecretRepository), SigningConfig{})
+	assert.Equal(t, SigningOff, off.Mode())
+	assert.NoError(t, off.Verify(ctx, bogus, body))
+
+	optional := NewSigningUsecase(new(MockSigningSecretRepository), SigningConfig{Mode: SigningOptional})
+	assert.NoError(t, optional.Verify(ctx, models.RequestSignature{}, body))
+	assert.ErrorIs(t, optional.Verify(ctx, bogus, body), ErrInvalidSignature)
+}
+
+func TestVerify_UsesStaleCacheWhenDatabaseIsDown(t *testing.T) {
+	mockRepo := new(MockSigningSecretRepository)
+	impl := NewSigningUsecase(mockRepo, SigningConfig{Mode: SigningRequired}).(*signingUsecase)
+	ctx := context.Background()
+	key := []byte("secret")
+	body := []byte(`{}`)
+
+	impl.cache["blankon-12"] = cachedSigningSecrets{secrets: []models.SigningSecret{{Key: key}}, loadedAt: time.Now().Add(-time.Hour)}
+	mockRepo.On("ListActive", ctx, mock.Anything).Return(nil, errors.New("connection refused"))
+
+	assert.NoError(t, impl.Verify(ctx, signed("blankon-12", key, time.Now(), body), body))
+
+	err := impl.Verify(ctx, signed("blankon-13", key, time.Now(), body), body)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestRevokeSigningSecret_FlushesCache(t *testing.T) {
+	mockRepo := new(MockSigningSecretRepository)
+	uc := NewSigningUsecase(mockRepo, SigningConfig{Mode: SigningRequired})
+	ctx := context.Background()
+	key := []byte("leaked")
+	body := []byte(`{}`)
+
+	mockRepo.On("ListActive", ctx, "blankon-12").Return([]models.SigningSecret{{ID: 3, Key: key}}, nil).Once()
+	mockRepo.On("Revoke", ctx, int64(3)).Return(true, nil)
+	mockRepo.On("Revoke", ctx, int64(4)).Return(false, nil)
+
+	require.NoError(t, uc.Verify(ctx, signed("blankon-12", key, time.Now(), body), body))
+	require.NoError(t, uc.RevokeSigningSecret(ctx, 3))
+	assert.ErrorIs(t, uc.RevokeSigningSecret(ctx, 4), ErrSigningSecretNotFound)
+
+	mockRepo.On("ListActive", ctx, "blankon-12").Return([]models.SigningSecret{}, nil).Once()
+	assert.ErrorIs(t, uc.Verify(ctx, signed("blankon-12", key, time.Now(), body), body), ErrInvalidSignature)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateSigningSecret(t *testing.T) {
+	mockRepo := new(MockSigningSecretRepository)
+	uc := NewSigningUsecase(mockRepo, SigningConfig{Mode: SigningRequired})
+	ctx := context.Background()
+
+	var stored *models.SigningSecret
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*models.SigningSecret")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*models.SigningSecret)
+	}).Return(nil)
+
+	created, err := uc.CreateSigningSecret(ctx, models.CreateSigningSecretRequest{Release: " blankon-12 "})
+	require.NoError(t, err)
+	assert.Equal(t, "blankon-12", stored.Release)
+	assert.Equal(t, created.Secret, string(stored.Key))
+
+	mockRepo.On("ListActive", ctx, "blankon-12").Return([]models.SigningSecret{*stored}, nil)
+	body := []byte(`{}`)
+	assert.NoError(t, uc.Verify(ctx, signed("blankon-12", []byte(created.Secret), time.Now(), body), body))
+
+	_, err = uc.CreateSigningSecret(ctx, models.CreateSigningSecretRequest{})
+	assert.ErrorIs(t, err, ErrInvalidSigningSecret)
+}
@@ -0,0 +1,29 @@
+-- Client-supplied idempotency keys for deduplicating retried events
+ALTER TABLE events ADD COLUMN IF NOT EXISTS idempotency_key TEXT;
+
+-- Hypertable unique constraints must include the time column, so keys are
+-- tracked in a regular table instead. A key is only honoured while it is
+-- younger than the deduplication window configured in the application.
+CREATE TABLE IF NOT EXISTS event_idempotency_keys (
+    idempotency_key TEXT PRIMARY KEY,
+    event_id BIGINT,
+    event_timestamp TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_idempotency_keys_created_at ON event_idempotency_keys(created_at);
+
+-- Purge expired keys daily. The application refuses an IDEMPOTENCY_WINDOW
+-- longer than this retention.
+CREATE OR REPLACE PROCEDURE purge_event_idempotency_keys(job_id INT, config JSONB)
+LANGUAGE SQL AS $$
+    DELETE FROM event_idempotency_keys
+    WHERE created_at < NOW() - COALESCE((config->>'retention')::INTERVAL, INTERVAL '7 days');
+$$;
+
+SELECT add_job('purge_event_idempotency_keys', INTERVAL '1 day',
+    config => '{"retention": "7 days"}')
+WHERE NOT EXISTS (
+    SELECT 1 FROM timescaledb_information.jobs
+    WHERE proc_name = 'purge_event_idempotency_keys'
+);
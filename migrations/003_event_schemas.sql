@@ -0,0 +1,10 @@
+-- Schema registry: JSON Schema documents validating event payloads,
+-- versioned per event name
+CREATE TABLE IF NOT EXISTS event_schemas (
+    event_name VARCHAR(255) NOT NULL,
+    version INT NOT NULL,
+    schema JSONB NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_name, version)
+);
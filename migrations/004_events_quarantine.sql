@@ -0,0 +1,19 @@
+-- Events rejected at ingestion (malformed JSON, schema violations, oversize
+-- payloads), kept so they can be inspected and reprocessed after a fix
+CREATE TABLE IF NOT EXISTS events_quarantine (
+    id BIGSERIAL PRIMARY KEY,
+    event_name VARCHAR(255),
+    reason VARCHAR(32) NOT NULL,
+    error TEXT NOT NULL,
+    details JSONB,
+    raw TEXT NOT NULL,
+    raw_size INT NOT NULL,
+    truncated BOOLEAN NOT NULL DEFAULT FALSE,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reprocessed_at TIMESTAMPTZ,
+    event_id BIGINT
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_quarantine_received_at ON events_quarantine(received_at DESC);
+CREATE INDEX IF NOT EXISTS idx_events_quarantine_reason ON events_quarantine(reason, received_at DESC);
+CREATE INDEX IF NOT EXISTS idx_events_quarantine_pending ON events_quarantine(event_name, id) WHERE reprocessed_at IS NULL;
@@ -0,0 +1,11 @@
+-- Per-release HMAC secrets used by desktop clients to sign ingest requests.
+-- Unlike API keys the secret itself must be kept to verify signatures.
+CREATE TABLE IF NOT EXISTS signing_secrets (
+    id BIGSERIAL PRIMARY KEY,
+    release VARCHAR(64) NOT NULL,
+    secret BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_signing_secrets_active ON signing_secrets(release) WHERE revoked_at IS NULL;
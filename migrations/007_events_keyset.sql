@@ -0,0 +1,4 @@
+-- Keyset pagination orders events by (timestamp, id); include the id so
+-- pages can be read straight from the index
+CREATE INDEX IF NOT EXISTS idx_events_timestamp_id ON events(timestamp DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_events_name_timestamp_id ON events(event_name, timestamp DESC, id DESC);
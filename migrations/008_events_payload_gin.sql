@@ -0,0 +1,3 @@
+-- Payload equality filters use containment (payload @> ...), which
+-- jsonb_path_ops indexes more compactly than the default operator class
+CREATE INDEX IF NOT EXISTS idx_events_payload ON events USING GIN (payload jsonb_path_ops);
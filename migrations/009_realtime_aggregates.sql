@@ -0,0 +1,5 @@
+-- Real-time aggregation: queries on the continuous aggregates also compute
+-- the buckets the refresh policies have not materialized yet (the last
+-- hour, resp. day) from raw events, so recent traffic is not shown as zero
+ALTER MATERIALIZED VIEW events_hourly SET (timescaledb.materialized_only = false);
+ALTER MATERIALIZED VIEW events_daily SET (timescaledb.materialized_only = false);
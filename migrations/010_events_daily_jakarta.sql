@@ -0,0 +1,20 @@
+-- Daily aggregate bucketed on Asia/Jakarta (WIB) midnight, where most of the
+-- community is. Other zones are bucketed from events or events_hourly at
+-- query time. Bucketing with a timezone requires TimescaleDB 2.8 or later.
+CREATE MATERIALIZED VIEW IF NOT EXISTS events_daily_jakarta
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+SELECT
+    time_bucket('1 day', timestamp, 'Asia/Jakarta') AS bucket,
+    event_name,
+    COUNT(*) as event_count,
+    COUNT(DISTINCT payload->>'user_id') as unique_users
+FROM events
+GROUP BY bucket, event_name
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('events_daily_jakarta',
+    start_offset => INTERVAL '3 days',
+    end_offset => INTERVAL '1 day',
+    schedule_interval => INTERVAL '1 hour',
+    if_not_exists => TRUE
+);
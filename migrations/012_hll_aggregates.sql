@@ -0,0 +1,85 @@
+-- Replace the exact per-bucket distinct counts of the continuous aggregates
+-- with HyperLogLog sketches from TimescaleDB Toolkit. Sketches can be merged
+-- with rollup(), so unique users can be counted over any range of buckets
+-- and across event names; distinct_count() reads the estimate. The size
+-- must match models.HLLSize, which the reported error is derived from.
+--
+-- The toolkit is not part of the plain timescale/timescaledb image; fail
+-- before dropping the existing aggregates when it cannot be installed.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'timescaledb_toolkit') THEN
+        RAISE EXCEPTION 'timescaledb_toolkit is not available; use the timescale/timescaledb-ha image or install TimescaleDB Toolkit';
+    END IF;
+END
+$$;
+
+CREATE EXTENSION IF NOT EXISTS timescaledb_toolkit;
+
+DROP MATERIALIZED VIEW IF EXISTS events_hourly;
+DROP MATERIALIZED VIEW IF EXISTS events_daily;
+DROP MATERIALIZED VIEW IF EXISTS events_daily_jakarta;
+
+CREATE MATERIALIZED VIEW events_hourly
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+SELECT
+    time_bucket('1 hour', timestamp) AS bucket,
+    event_name,
+    COUNT(*) as event_count,
+    hyperloglog(8192, payload->>'user_id') as users_hll
+FROM events
+GROUP BY bucket, event_name
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('events_hourly',
+    start_offset => INTERVAL '3 hours',
+    end_offset => INTERVAL '1 hour',
+    schedule_interval => INTERVAL '30 minutes',
+    if_not_exists => TRUE
+);
+
+CREATE MATERIALIZED VIEW events_daily
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+SELECT
+    time_bucket('1 day', timestamp) AS bucket,
+    event_name,
+    COUNT(*) as event_count,
+    hyperloglog(8192, payload->>'user_id') as users_hll
+FROM events
+GROUP BY bucket, event_name
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('events_daily',
+    start_offset => INTERVAL '3 days',
+    end_offset => INTERVAL '1 day',
+    schedule_interval => INTERVAL '1 hour',
+    if_not_exists => TRUE
+);
+
+CREATE MATERIALIZED VIEW events_daily_jakarta
+WITH (timescaledb.continuous, timescaledb.materialized_only = false) AS
+SELECT
+    time_bucket('1 day', timestamp, 'Asia/Jakarta') AS bucket,
+    event_name,
+    COUNT(*) as event_count,
+    hyperloglog(8192, payload->>'user_id') as users_hll
+FROM events
+GROUP BY bucket, event_name
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy('events_daily_jakarta',
+    start_offset => INTERVAL '3 days',
+    end_offset => INTERVAL '1 day',
+    schedule_interval => INTERVAL '1 hour',
+    if_not_exists => TRUE
+);
+
+-- Materialize the ranges the analytics endpoints read by default. Real-time
+-- aggregation only covers buckets after the materialized ones, so on an
+-- existing deployment every older bucket is MISSING from the hourly, daily,
+-- timeseries and event name endpoints until `go run ./cmd/backfill` (or
+-- /app/backfill in the container) has refreshed the full history in
+-- chunks. These cannot run inside a transaction.
+CALL refresh_continuous_aggregate('events_hourly', now() - INTERVAL '7 days', NULL);
+CALL refresh_continuous_aggregate('events_daily', now() - INTERVAL '31 days', NULL);
+CALL refresh_continuous_aggregate('events_daily_jakarta', now() - INTERVAL '31 days', NULL);
@@ -0,0 +1,3 @@
+-- Per-install timelines filter on payload.user_id and page by (timestamp,
+-- id); an expression index serves both without touching the GIN index
+CREATE INDEX IF NOT EXISTS idx_events_user_id ON events((payload->>'user_id'), timestamp DESC, id DESC);
@@ -0,0 +1,4 @@
+-- Retention probes whether an install sent an event of a name before a
+-- cohort range and joins its return events by payload.user_id; index the
+-- pair so neither falls back to scanning every event of the name
+CREATE INDEX IF NOT EXISTS idx_events_name_user_id ON events(event_name, (payload->>'user_id'), timestamp);
@@ -0,0 +1,4 @@
+-- Marks keys that were rotated with a grace period. Their expires_at is cut
+-- short to the end of the grace, so they must not be rotated again: the
+-- replacement would inherit the shortened expiry.
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS rotated_at TIMESTAMPTZ;
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// ActiveUsers counts the distinct installs active on a day and in the 7 and
+// 28 days ending with it. The ratios are 0 when the denominator is.
+type ActiveUsers struct {
+	Day time.Time `json:"day"`
+	DAU int64     `json:"dau"`
+	WAU int64     `json:"wau"`
+	MAU int64     `json:"mau"`
+	// DAUOverWAU and DAUOverMAU measure stickiness: how many of the weekly
+	// or monthly active installs come back on a given day.
+	DAUOverWAU float64 `json:"dau_wau"`
+	DAUOverMAU float64 `json:"dau_mau"`
+	// Partial marks the current day.
+	Partial bool `json:"partial,omitempty"`
+}
+
+type ActiveUsersResponse struct {
+	From time.Time     `json:"from"`
+	To   time.Time     `json:"to"`
+	Days []ActiveUsers `json:"days"`
+}
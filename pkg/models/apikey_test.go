@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKey_HasScope(t *testing.T) {
+	ingest := APIKey{Scopes: []string{ScopeIngest}}
+	assert.True(t, ingest.HasScope(ScopeIngest))
+	assert.False(t, ingest.HasScope(ScopeRead))
+	assert.False(t, ingest.HasScope(ScopeAdmin))
+
+	admin := APIKey{Scopes: []string{ScopeAdmin}}
+	assert.True(t, admin.HasScope(ScopeIngest))
+	assert.True(t, admin.HasScope(ScopeRead))
+}
+
+func TestAPIKey_Active(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	assert.True(t, (&APIKey{}).Active(now))
+	assert.True(t, (&APIKey{ExpiresAt: &future}).Active(now))
+	assert.False(t, (&APIKey{ExpiresAt: &past}).Active(now))
+	assert.False(t, (&APIKey{RevokedAt: &past}).Active(now))
+}
+
+func TestAPIKey_HashNotSerialized(t *testing.T) {
+	data, err := json.Marshal(APIKey{KeyHash: []byte("secret")})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "hash")
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+// BreakdownQuery groups the events of one name by a payload field.
+type BreakdownQuery struct {
+	EventName string
+	// Path is the payload field to group by, e.g. ["version"].
+	Path     []string
+	Interval Interval
+	From     time.Time
+	To       time.Time
+	// Top is how many of the most frequent values are reported on their
+	// own; the rest are merged into one "other" row per bucket.
+	Top int
+	// Location aligns day, week and month buckets on local midnight; nil
+	// means UTC.
+	Location *time.Location
+}
+
+// BreakdownRow counts the events of one bucket carrying one value. Value is
+// nil for events without the field and on "other" rows.
+type BreakdownRow struct {
+	Bucket      time.Time `json:"bucket"`
+	Value       *string   `json:"value"`
+	Other       bool      `json:"other,omitempty"`
+	EventCount  int64     `json:"event_count"`
+	UniqueUsers int64     `json:"unique_users"`
+}
+
+type BreakdownResponse struct {
+	EventName string         `json:"event_name"`
+	By        string         `json:"by"`
+	Interval  string         `json:"interval"`
+	From      time.Time      `json:"from"`
+	To        time.Time      `json:"to"`
+	Timezone  string         `json:"timezone"`
+	Rows      []BreakdownRow `json:"rows"`
+}
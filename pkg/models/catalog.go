@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// EventNameSummary describes one event name seen by the service. Listed
+// without a range, FirstSeen and LastSeen are the start of the first and
+// last day the name was seen.
+type EventNameSummary struct {
+	EventName string    `json:"event_name"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Count     int64     `json:"count"`
+}
+
+// PayloadKey describes a payload field observed in a sample of events. Path
+// uses the dotted notation of payload filters, e.g. device.arch.
+type PayloadKey struct {
+	Path string `json:"path"`
+	// Type is the most common JSON type of the field; Types counts every
+	// type seen, as fields are not always sent consistently.
+	Type  string         `json:"type"`
+	Types map[string]int `json:"types"`
+	// Count is the number of sampled events carrying the field.
+	Count        int     `json:"count"`
+	PresenceRate float64 `json:"presence_rate"`
+	// NullRate is the share of Count where the field is null.
+	NullRate float64       `json:"null_rate"`
+	Examples []interface{} `json:"examples,omitempty"`
+}
+
+// PayloadDescription summarises the payload keys of an event name.
+type PayloadDescription struct {
+	EventName string       `json:"event_name"`
+	Sampled   int          `json:"sampled"`
+	Keys      []PayloadKey `json:"keys"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Periods a series can be compared with.
+const (
+	ComparePreviousPeriod = "previous_period"
+	ComparePreviousYear   = "previous_year"
+)
+
+var ErrInvalidCompare = errors.New("compare must be previous_period or previous_year")
+
+// ParseCompare validates a compare parameter; "" means no comparison.
+func ParseCompare(s string) (string, error) {
+	switch s {
+	case "", ComparePreviousPeriod, ComparePreviousYear:
+		return s, nil
+	}
+	return "", ErrInvalidCompare
+}
+
+// Comparison holds the counts of the bucket a row is compared with and how
+// the row changed since. Percentage deltas are nil when the compared count
+// is zero.
+type Comparison struct {
+	Bucket              time.Time `json:"bucket"`
+	EventCount          int64     `json:"event_count"`
+	UniqueUsers         int64     `json:"unique_users"`
+	EventCountDelta     int64     `json:"event_count_delta"`
+	EventCountDeltaPct  *float64  `json:"event_count_delta_pct"`
+	UniqueUsersDelta    int64     `json:"unique_users_delta"`
+	UniqueUsersDeltaPct *float64  `json:"unique_users_delta_pct"`
+}
+
+// CompareCounts compares the counts of a row with those of bucket.
+func CompareCounts(bucket time.Time, eventCount, uniqueUsers, prevEventCount, prevUniqueUsers int64) *Comparison {
+	return &Comparison{
+		Bucket:              bucket,
+		EventCount:          prevEventCount,
+		UniqueUsers:         prevUniqueUsers,
+		EventCountDelta:     eventCount - prevEventCount,
+		EventCountDeltaPct:  deltaPct(eventCount, prevEventCount),
+		UniqueUsersDelta:    uniqueUsers - prevUniqueUsers,
+		UniqueUsersDeltaPct: deltaPct(uniqueUsers, prevUniqueUsers),
+	}
+}
+
+func deltaPct(n, prev int64) *float64 {
+	if prev == 0 {
+		return nil
+	}
+	pct := float64(n-prev) / float64(prev) * 100
+	return &pct
+}
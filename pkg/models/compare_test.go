@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCompare(t *testing.T) {
+	for _, s := range []string{"", ComparePreviousPeriod, ComparePreviousYear} {
+		compare, err := ParseCompare(s)
+		assert.NoError(t, err)
+		assert.Equal(t, s, compare)
+	}
+
+	_, err := ParseCompare("last_week")
+	assert.ErrorIs(t, err, ErrInvalidCompare)
+}
+
+func TestCompareCounts(t *testing.T) {
+	bucket := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	c := CompareCounts(bucket, 150, 30, 100, 0)
+	assert.Equal(t, bucket, c.Bucket)
+	assert.Equal(t, int64(100), c.EventCount)
+	assert.Equal(t, int64(50), c.EventCountDelta)
+	require.NotNil(t, c.EventCountDeltaPct)
+	assert.InDelta(t, 50.0, *c.EventCountDeltaPct, 1e-9)
+	assert.Equal(t, int64(30), c.UniqueUsersDelta)
+	assert.Nil(t, c.UniqueUsersDeltaPct)
+
+	c = CompareCounts(bucket, 0, 0, 40, 8)
+	assert.InDelta(t, -100.0, *c.EventCountDeltaPct, 1e-9)
+	assert.InDelta(t, -100.0, *c.UniqueUsersDeltaPct, 1e-9)
+}
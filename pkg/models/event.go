@@ -1,21 +1,33 @@
 package models
 
 import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type Event struct {
-	ID        int64                  `json:"id"`
-	EventName string                 `json:"event_name"`
-	Timestamp time.Time              `json:"timestamp"`
-	Payload   map[string]interface{} `json:"payload"`
-	CreatedAt time.Time              `json:"created_at"`
+	ID             int64                  `json:"id"`
+	EventName      string                 `json:"event_name"`
+	Timestamp      time.Time              `json:"timestamp"`
+	Payload        map[string]interface{} `json:"payload"`
+	IdempotencyKey string                 `json:"idempotency_key,omitempty"`
+	CreatedAt      time.Time              `json:"created_at"`
 }
 
 type CreateEventRequest struct {
 	EventName string                 `json:"event_name"`
 	Timestamp time.Time              `json:"timestamp"`
 	Payload   map[string]interface{} `json:"payload"`
+	// IdempotencyKey is an optional client-generated identifier, usually a
+	// UUID. Retries carrying the same key return the originally stored
+	// event instead of creating a duplicate.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	// SchemaVersion pins payload validation to a registered schema
+	// version; zero validates against the latest active version.
+	SchemaVersion int `json:"schema_version,omitempty"`
 }
 
 type EventFilter struct {
@@ -24,4 +36,129 @@ type EventFilter struct {
 	To        *time.Time
 	Limit     int
 	Offset    int
+	// Cursor, if set, lists events after this position instead of using
+	// Offset.
+	Cursor *EventCursor
+	// Payload conditions must all match.
+	Payload []PayloadFilter
+}
+
+// UserEventFilter selects the events of one install, found by
+// payload.user_id. Events are listed oldest first unless Descending is set.
+type UserEventFilter struct {
+	UserID string
+	// EventNames restricts the listing to these events; empty lists all.
+	EventNames []string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	// Cursor, if set, lists events past this position in the listing order.
+	Cursor     *EventCursor
+	Descending bool
+}
+
+// EventCursor is a position in a list of events ordered by timestamp and
+// ID, descending unless Ascending is set. The order is part of the token, so
+// a cursor cannot be replayed against a listing running the other way.
+type EventCursor struct {
+	Timestamp time.Time
+	ID        int64
+	Ascending bool
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorAfter returns the cursor pointing past event.
+func CursorAfter(event Event) *EventCursor {
+	return &EventCursor{Timestamp: event.Timestamp, ID: event.ID}
+}
+
+// String encodes the cursor as an opaque token.
+func (c EventCursor) String() string {
+	raw := strconv.FormatInt(c.Timestamp.UnixMicro(), 10) + "," + strconv.FormatInt(c.ID, 10)
+	if c.Ascending {
+		raw += ",asc"
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseEventCursor decodes a token returned by EventCursor.String.
+func ParseEventCursor(token string) (*EventCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	tsStr, idStr, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	ts, err := strconv.ParseInt(tsStr, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	idStr, order, ascending := strings.Cut(idStr, ",")
+	if ascending && order != "asc" {
+		return nil, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &EventCursor{Timestamp: time.UnixMicro(ts).UTC(), ID: id, Ascending: ascending}, nil
+}
+
+// EventPage is one page of listed events. NextCursor is empty on the last
+// page.
+type EventPage struct {
+	Events     []Event
+	NextCursor string
+}
+
+// Outcomes reported for each item of a batch ingest request.
+const (
+	BatchStatusCreated = "created"
+	// BatchStatusQueued marks events accepted by the asynchronous ingest
+	// queue; they are written shortly after the response is sent.
+	BatchStatusQueued = "queued"
+	// BatchStatusSpooled marks events written to the local spool because
+	// the database was unreachable; they are replayed once it is back.
+	BatchStatusSpooled  = "spooled"
+	BatchStatusRejected = "rejected"
+	// BatchStatusDuplicate marks events whose idempotency key was already
+	// used; the result carries the originally stored event.
+	BatchStatusDuplicate = "duplicate"
+	// BatchStatusFailed marks valid events that could not be stored and
+	// are safe to retry.
+	BatchStatusFailed = "failed"
+)
+
+// BatchItemResult reports what happened to a single event of a batch,
+// identified by its position in the submitted array.
+type BatchItemResult struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	// Event echoes the stored event in batch responses. Streamed responses
+	// only carry its ID, which is 0 for queued and spooled events.
+	Event   *Event       `json:"event,omitempty"`
+	ID      int64        `json:"id,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Details []FieldError `json:"details,omitempty"`
+}
+
+type BatchResponse struct {
+	// Accepted counts every event that needs no retry: created, queued,
+	// spooled and duplicate ones.
+	Accepted int `json:"accepted"`
+	Queued   int `json:"queued,omitempty"`
+	Spooled  int `json:"spooled,omitempty"`
+	// Duplicates counts events that were not stored again because their
+	// idempotency key had already been used.
+	Duplicates int               `json:"duplicates,omitempty"`
+	Rejected   int               `json:"rejected"`
+	Failed     int               `json:"failed,omitempty"`
+	Results    []BatchItemResult `json:"results"`
+	// Truncated is set when a streamed body could not be read to the end or
+	// held more events than one request may carry; events after the last
+	// result were not processed.
+	Truncated bool `json:"truncated,omitempty"`
 }
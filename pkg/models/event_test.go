@@ -90,3 +90,25 @@ func TestEventFilter_WithValues(t *testing.T) {
 	assert.Equal(t, 50, filter.Limit)
 	assert.Equal(t, 10, filter.Offset)
 }
+
+func TestEventCursor_RoundTrip(t *testing.T) {
+	cursor := EventCursor{Timestamp: time.Date(2026, 3, 1, 12, 30, 0, 123456000, time.UTC), ID: 42}
+
+	parsed, err := ParseEventCursor(cursor.String())
+
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, *parsed)
+
+	cursor.Ascending = true
+	parsed, err = ParseEventCursor(cursor.String())
+
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, *parsed)
+}
+
+func TestParseEventCursor_Invalid(t *testing.T) {
+	for _, token := range []string{"", "!!!", "bm9jb21tYQ", "YSxi", "MSwyLGRlc2M"} {
+		_, err := ParseEventCursor(token)
+		assert.ErrorIs(t, err, ErrInvalidCursor, token)
+	}
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// FunnelQuery follows installs through an ordered list of events. An
+// install enters the funnel with its first Steps[0] event between From and
+// To and reaches each later step with the first matching event after the
+// previous step, as long as it is within Window of entering.
+type FunnelQuery struct {
+	Steps  []string
+	Window Interval
+	From   time.Time
+	To     time.Time
+	// By optionally splits the funnel by a payload field of the entering
+	// event, e.g. ["edition"].
+	By []string
+	// Top is how many of the most frequent By values get a funnel of their
+	// own; the rest are merged into one "other" group.
+	Top int
+}
+
+// FunnelStep counts the installs that reached one step. ConversionRate is
+// relative to the previous step and OverallRate to the first;
+// MedianSecondsToConvert is the median time from the previous step and
+// nil for the first step or when nobody reached it.
+type FunnelStep struct {
+	Event                  string   `json:"event"`
+	Users                  int64    `json:"users"`
+	ConversionRate         float64  `json:"conversion_rate"`
+	OverallRate            float64  `json:"overall_rate"`
+	MedianSecondsToConvert *float64 `json:"median_seconds_to_convert"`
+}
+
+// FunnelGroup is the funnel of the installs sharing one By value. Without
+// a breakdown there is a single group with a nil Value.
+type FunnelGroup struct {
+	Value *string      `json:"value"`
+	Other bool         `json:"other,omitempty"`
+	Steps []FunnelStep `json:"steps"`
+}
+
+type FunnelResponse struct {
+	Steps  []string      `json:"steps"`
+	Window string        `json:"window"`
+	From   time.Time     `json:"from"`
+	To     time.Time     `json:"to"`
+	By     string        `json:"by,omitempty"`
+	Groups []FunnelGroup `json:"groups"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// QueueStats describes the state of the asynchronous ingest queue.
+type QueueStats struct {
+	Depth         int        `json:"depth"`
+	Capacity      int        `json:"capacity"`
+	BatchSize     int        `json:"batch_size"`
+	FlushInterval string     `json:"flush_interval"`
+	Flushed       int64      `json:"flushed"`
+	Dropped       int64      `json:"dropped"`
+	LastFlushAt   *time.Time `json:"last_flush_at,omitempty"`
+}
+
+// IngestStats reports the write path state. Queue is nil when events are
+// written synchronously and Spool is nil when spooling is disabled.
+type IngestStats struct {
+	Async bool        `json:"async"`
+	Queue *QueueStats `json:"queue,omitempty"`
+	Spool *SpoolStats `json:"spool,omitempty"`
+}
+
+// SpoolStats describes the on-disk spool used while the database is
+// unreachable.
+type SpoolStats struct {
+	Dir          string     `json:"dir"`
+	Segments     int        `json:"segments"`
+	PendingBytes int64      `json:"pending_bytes"`
+	Spooled      int64      `json:"spooled"`
+	Replayed     int64      `json:"replayed"`
+	Discarded    int64      `json:"discarded"`
+	LastReplayAt *time.Time `json:"last_replay_at,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalidInterval = errors.New("invalid interval")
+
+	intervalPattern = regexp.MustCompile(`^([1-9][0-9]{0,3})(m|h|d|w|mo)$`)
+)
+
+// Interval is a bucket width such as 15m, 6h, 1d, 1w or 1mo. Months are
+// calendar months, so an Interval is not a fixed duration.
+type Interval struct {
+	Count int
+	Unit  string
+}
+
+// ParseInterval parses <count><unit> with unit m (minutes), h, d, w or mo.
+func ParseInterval(s string) (Interval, error) {
+	m := intervalPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Interval{}, ErrInvalidInterval
+	}
+	count, _ := strconv.Atoi(m[1])
+	return Interval{Count: count, Unit: m[2]}, nil
+}
+
+func (i Interval) String() string {
+	return strconv.Itoa(i.Count) + i.Unit
+}
+
+// SQL returns the interval in PostgreSQL syntax, e.g. "15 minutes".
+func (i Interval) SQL() string {
+	unit := map[string]string{"m": "minutes", "h": "hours", "d": "days", "w": "weeks", "mo": "months"}[i.Unit]
+	return strconv.Itoa(i.Count) + " " + unit
+}
+
+// Approx returns the interval's length, taking a month as 30 days. It is
+// meant for estimating bucket counts.
+func (i Interval) Approx() time.Duration {
+	unit := map[string]time.Duration{
+		"m":  time.Minute,
+		"h":  time.Hour,
+		"d":  24 * time.Hour,
+		"w":  7 * 24 * time.Hour,
+		"mo": 30 * 24 * time.Hour,
+	}[i.Unit]
+	return time.Duration(i.Count) * unit
+}
+
+// AddTo returns t moved by n intervals. Days, weeks and months follow the
+// calendar of t's location.
+func (i Interval) AddTo(t time.Time, n int) time.Time {
+	switch i.Unit {
+	case "mo":
+		return t.AddDate(0, n*i.Count, 0)
+	case "d":
+		return t.AddDate(0, 0, n*i.Count)
+	case "w":
+		return t.AddDate(0, 0, 7*n*i.Count)
+	}
+	return t.Add(time.Duration(n) * i.Approx())
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		in     string
+		sql    string
+		approx time.Duration
+	}{
+		{"15m", "15 minutes", 15 * time.Minute},
+		{"6h", "6 hours", 6 * time.Hour},
+		{"1d", "1 days", 24 * time.Hour},
+		{"1w", "1 weeks", 7 * 24 * time.Hour},
+		{"1mo", "1 months", 30 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		interval, err := ParseInterval(tt.in)
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, tt.in, interval.String())
+		assert.Equal(t, tt.sql, interval.SQL())
+		assert.Equal(t, tt.approx, interval.Approx())
+	}
+
+	for _, in := range []string{"", "0d", "1y", "d", "1.5h", "-1h", "100000m"} {
+		_, err := ParseInterval(in)
+		assert.ErrorIs(t, err, ErrInvalidInterval, in)
+	}
+}
+
+func TestInterval_AddTo(t *testing.T) {
+	jan31 := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), Interval{Count: 1, Unit: "mo"}.AddTo(jan31, 1))
+	assert.Equal(t, time.Date(2026, 2, 14, 0, 0, 0, 0, time.UTC), Interval{Count: 1, Unit: "w"}.AddTo(jan31, 2))
+	assert.Equal(t, time.Date(2026, 1, 30, 0, 0, 0, 0, time.UTC), Interval{Count: 1, Unit: "d"}.AddTo(jan31, -1))
+	assert.Equal(t, jan31.Add(90*time.Minute), Interval{Count: 45, Unit: "m"}.AddTo(jan31, 2))
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidMetricAgg = errors.New("invalid metric aggregate")
+
+	percentilePattern = regexp.MustCompile(`^p([1-9][0-9]?)$`)
+)
+
+// Metric aggregates besides percentiles, which are written p1 to p99.
+const (
+	MetricCount = "count"
+	MetricAvg   = "avg"
+	MetricMin   = "min"
+	MetricMax   = "max"
+	MetricSum   = "sum"
+)
+
+// ParseMetricAggs parses a comma separated list such as "p50,p90,avg".
+func ParseMetricAggs(s string) ([]string, error) {
+	var aggs []string
+	for _, agg := range strings.Split(s, ",") {
+		agg = strings.TrimSpace(agg)
+		switch agg {
+		case MetricCount, MetricAvg, MetricMin, MetricMax, MetricSum:
+		default:
+			if _, ok := PercentileFraction(agg); !ok {
+				return nil, ErrInvalidMetricAgg
+			}
+		}
+		aggs = append(aggs, agg)
+	}
+	return aggs, nil
+}
+
+// PercentileFraction returns 0.9 for "p90"; ok is false for anything that
+// is not a percentile.
+func PercentileFraction(agg string) (fraction float64, ok bool) {
+	m := percentilePattern.FindStringSubmatch(agg)
+	if m == nil {
+		return 0, false
+	}
+	n, _ := strconv.Atoi(m[1])
+	return float64(n) / 100, true
+}
+
+// MetricQuery aggregates a numeric payload field of one event name per
+// bucket. Events where the field is missing or not a number are skipped.
+type MetricQuery struct {
+	EventName string
+	Path      []string
+	Aggs      []string
+	Interval  Interval
+	From      time.Time
+	To        time.Time
+	Location  *time.Location
+}
+
+// MetricPoint holds the aggregates of one bucket, keyed by their name.
+// Count is the number of numeric values in the bucket.
+type MetricPoint struct {
+	Bucket time.Time           `json:"bucket"`
+	Count  int64               `json:"count"`
+	Values map[string]*float64 `json:"values"`
+}
+
+type MetricResponse struct {
+	EventName string        `json:"event_name"`
+	Field     string        `json:"field"`
+	Aggs      []string      `json:"aggs"`
+	Interval  string        `json:"interval"`
+	From      time.Time     `json:"from"`
+	To        time.Time     `json:"to"`
+	Timezone  string        `json:"timezone"`
+	Points    []MetricPoint `json:"points"`
+}
+
+// HistogramQuery distributes the values of a numeric payload field over
+// Bins equal-width bins between Min and Max, which default to the smallest
+// and largest value in the range.
+type HistogramQuery struct {
+	EventName string
+	Path      []string
+	From      time.Time
+	To        time.Time
+	Bins      int
+	Min       *float64
+	Max       *float64
+}
+
+// HistogramBin counts the values in [Lower, Upper); the last bin includes
+// Upper.
+type HistogramBin struct {
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+	Count int64   `json:"count"`
+}
+
+// HistogramResponse reports values below Min and above Max as Underflow
+// and Overflow.
+type HistogramResponse struct {
+	EventName string         `json:"event_name"`
+	Field     string         `json:"field"`
+	From      time.Time      `json:"from"`
+	To        time.Time      `json:"to"`
+	Min       float64        `json:"min"`
+	Max       float64        `json:"max"`
+	Count     int64          `json:"count"`
+	Underflow int64          `json:"underflow"`
+	Overflow  int64          `json:"overflow"`
+	Bins      []HistogramBin `json:"bins"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetricAggs(t *testing.T) {
+	aggs, err := ParseMetricAggs("p50, p99,avg,max")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p50", "p99", "avg", "max"}, aggs)
+
+	fraction, ok := PercentileFraction("p90")
+	assert.True(t, ok)
+	assert.Equal(t, 0.9, fraction)
+
+	for _, in := range []string{"", "p0", "p100", "p9.5", "median", "avg,"} {
+		_, err := ParseMetricAggs(in)
+		assert.ErrorIs(t, err, ErrInvalidMetricAgg, in)
+	}
+}
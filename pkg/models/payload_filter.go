@@ -0,0 +1,103 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Payload filter operators.
+const (
+	PayloadOpEq     = "eq"
+	PayloadOpIn     = "in"
+	PayloadOpGt     = "gt"
+	PayloadOpGte    = "gte"
+	PayloadOpLt     = "lt"
+	PayloadOpLte    = "lte"
+	PayloadOpExists = "exists"
+)
+
+const (
+	// MaxPayloadFilters is the most payload conditions one listing may use.
+	MaxPayloadFilters = 10
+	// MaxPayloadFilterValues is the most values one in condition may list.
+	MaxPayloadFilterValues = 50
+	maxPayloadPathDepth    = 8
+)
+
+var (
+	ErrInvalidPayloadFilter = errors.New("invalid payload filter")
+
+	payloadKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+)
+
+// PayloadFilter is a condition on a payload field. Path addresses nested
+// objects, e.g. ["device", "arch"] for payload.device.arch.
+type PayloadFilter struct {
+	Path []string
+	Op   string
+	// Values holds the operand of eq and the operands of in. Each value
+	// matches the equal string, and also the equal number or boolean if it
+	// parses as one.
+	Values []string
+	// Number is the bound of gt, gte, lt and lte, which only match numbers.
+	Number float64
+	// Exists selects whether exists matches present or missing fields.
+	Exists bool
+}
+
+// ParsePayloadPath splits a dotted payload field such as device.arch into
+// its keys.
+func ParsePayloadPath(field string) ([]string, error) {
+	path := strings.Split(field, ".")
+	if len(path) > maxPayloadPathDepth {
+		return nil, fmt.Errorf("%w: %q is nested too deeply", ErrInvalidPayloadFilter, field)
+	}
+	for _, key := range path {
+		if !payloadKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("%w: invalid field %q", ErrInvalidPayloadFilter, field)
+		}
+	}
+	return path, nil
+}
+
+// ParsePayloadFilter builds a filter from a dotted field path, an operator
+// and its textual operand. An empty op means eq; in takes comma-separated
+// values.
+func ParsePayloadFilter(field, op, value string) (PayloadFilter, error) {
+	path, err := ParsePayloadPath(field)
+	if err != nil {
+		return PayloadFilter{}, err
+	}
+
+	filter := PayloadFilter{Path: path, Op: op}
+	switch op {
+	case "", PayloadOpEq:
+		filter.Op = PayloadOpEq
+		filter.Values = []string{value}
+	case PayloadOpIn:
+		filter.Values = strings.Split(value, ",")
+		if len(filter.Values) > MaxPayloadFilterValues {
+			return PayloadFilter{}, fmt.Errorf("%w: %s lists more than %d values", ErrInvalidPayloadFilter, field, MaxPayloadFilterValues)
+		}
+	case PayloadOpGt, PayloadOpGte, PayloadOpLt, PayloadOpLte:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return PayloadFilter{}, fmt.Errorf("%w: %s[%s] needs a number", ErrInvalidPayloadFilter, field, op)
+		}
+		filter.Number = n
+	case PayloadOpExists:
+		exists, err := strconv.ParseBool(value)
+		if err != nil {
+			return PayloadFilter{}, fmt.Errorf("%w: %s[exists] needs true or false", ErrInvalidPayloadFilter, field)
+		}
+		filter.Exists = exists
+	default:
+		return PayloadFilter{}, fmt.Errorf("%w: unknown operator %q", ErrInvalidPayloadFilter, op)
+	}
+
+	return filter, nil
+}
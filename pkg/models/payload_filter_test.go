@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePayloadFilter(t *testing.T) {
+	filter, err := ParsePayloadFilter("device.arch", "", "amd64")
+	assert.NoError(t, err)
+	assert.Equal(t, PayloadFilter{Path: []string{"device", "arch"}, Op: PayloadOpEq, Values: []string{"amd64"}}, filter)
+
+	filter, err = ParsePayloadFilter("os", PayloadOpIn, "linux,windows")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"linux", "windows"}, filter.Values)
+
+	filter, err = ParsePayloadFilter("version", PayloadOpGte, "23.0")
+	assert.NoError(t, err)
+	assert.Equal(t, 23.0, filter.Number)
+
+	filter, err = ParsePayloadFilter("crash", PayloadOpExists, "false")
+	assert.NoError(t, err)
+	assert.False(t, filter.Exists)
+}
+
+func TestParsePayloadFilter_Invalid(t *testing.T) {
+	tests := []struct {
+		field, op, value string
+	}{
+		{"", "", "x"},
+		{"a..b", "", "x"},
+		{"a'); DROP TABLE events; --", "", "x"},
+		{"a.b.c.d.e.f.g.h.i", "", "x"},
+		{"version", PayloadOpGt, "new"},
+		{"version", PayloadOpLt, "NaN"},
+		{"crash", PayloadOpExists, "maybe"},
+		{"os", "like", "lin%"},
+	}
+
+	for _, tt := range tests {
+		_, err := ParsePayloadFilter(tt.field, tt.op, tt.value)
+		assert.ErrorIs(t, err, ErrInvalidPayloadFilter, tt.field)
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+)
+
+// Reasons an event was quarantined instead of stored.
+const (
+	QuarantineReasonInvalidJSON     = "invalid_json"
+	QuarantineReasonInvalidEvent    = "invalid_event"
+	QuarantineReasonSchemaViolation = "schema_violation"
+	QuarantineReasonPayloadTooLarge = "payload_too_large"
+)
+
+// QuarantinedEvent is a rejected ingest item together with why it was
+// rejected. Raw holds the item as received, cut off after a size limit when
+// Truncated is set; truncated items cannot be reprocessed.
+type QuarantinedEvent struct {
+	ID            int64        `json:"id"`
+	EventName     string       `json:"event_name,omitempty"`
+	Reason        string       `json:"reason"`
+	Error         string       `json:"error"`
+	Details       []FieldError `json:"details,omitempty"`
+	Raw           string       `json:"raw"`
+	RawSize       int          `json:"raw_size"`
+	Truncated     bool         `json:"truncated,omitempty"`
+	ReceivedAt    time.Time    `json:"received_at"`
+	ReprocessedAt *time.Time   `json:"reprocessed_at,omitempty"`
+	EventID       *int64       `json:"event_id,omitempty"`
+}
+
+type QuarantineFilter struct {
+	Reason    string
+	EventName string
+	// Reprocessed restricts the listing to reprocessed (true) or pending
+	// (false) entries when set.
+	Reprocessed *bool
+	// AfterID restricts the listing to entries with a larger ID.
+	AfterID int64
+	Limit   int
+	Offset  int
+}
+
+// Outcomes reported for each entry of a reprocess request.
+const (
+	ReprocessStatusReprocessed = "reprocessed"
+	ReprocessStatusRejected    = "rejected"
+)
+
+type ReprocessResult struct {
+	ID      int64        `json:"id"`
+	Status  string       `json:"status"`
+	Event   *Event       `json:"event,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Details []FieldError `json:"details,omitempty"`
+}
+
+type ReprocessResponse struct {
+	Reprocessed int               `json:"reprocessed"`
+	Rejected    int               `json:"rejected"`
+	Results     []ReprocessResult `json:"results"`
+	// LastID is the last entry examined; pass it as after_id to continue
+	// past entries that were rejected again.
+	LastID int64 `json:"last_id,omitempty"`
+}
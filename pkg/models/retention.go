@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// RetentionQuery follows cohorts of installs, grouped by when they first
+// sent one of StartEvents, and counts how many send ReturnEvent in the
+// cohort's own period and each of the Periods periods that follow.
+type RetentionQuery struct {
+	StartEvents []string
+	ReturnEvent string
+	// Granularity is 1d, 1w or 1mo; it is both the cohort and the period
+	// width.
+	Granularity Interval
+	Periods     int
+	// From and To bound the first start event of the cohorts' installs.
+	From time.Time
+	To   time.Time
+}
+
+// RetentionCohort is one row of the cohort matrix. Retained[n] and Rates[n]
+// are the installs sending the return event in period n, period 0 being
+// the cohort's own; they are nil for periods that have not started yet.
+type RetentionCohort struct {
+	Cohort   time.Time  `json:"cohort"`
+	Size     int64      `json:"size"`
+	Retained []*int64   `json:"retained"`
+	Rates    []*float64 `json:"rates"`
+}
+
+type RetentionResponse struct {
+	StartEvents []string          `json:"start_events"`
+	ReturnEvent string            `json:"return_event"`
+	Granularity string            `json:"granularity"`
+	Periods     int               `json:"periods"`
+	From        time.Time         `json:"from"`
+	To          time.Time         `json:"to"`
+	Cohorts     []RetentionCohort `json:"cohorts"`
+}
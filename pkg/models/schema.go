@@ -0,0 +1,30 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventSchema is a JSON Schema document that payloads of an event name must
+// conform to. Versions are assigned sequentially per event name; inactive
+// versions are kept for reference but no longer used for validation.
+type EventSchema struct {
+	EventName string          `json:"event_name"`
+	Version   int             `json:"version"`
+	Schema    json.RawMessage `json:"schema"`
+	Active    bool            `json:"active"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type CreateSchemaRequest struct {
+	EventName string          `json:"event_name"`
+	Schema    json.RawMessage `json:"schema"`
+}
+
+// FieldError describes one way a payload fails its schema. Field is a JSON
+// pointer into the payload, empty for the payload itself.
+type FieldError struct {
+	Field   string `json:"field"`
+	Keyword string `json:"keyword,omitempty"`
+	Message string `json:"message"`
+}
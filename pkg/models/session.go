@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+)
+
+// SessionQuery splits the events of every install into sessions wherever
+// two consecutive events are Gap or more apart.
+type SessionQuery struct {
+	Gap      Interval
+	From     time.Time
+	To       time.Time
+	Location *time.Location
+}
+
+// SessionStats summarises the sessions started on one day.
+type SessionStats struct {
+	Day                time.Time `json:"day"`
+	Sessions           int64     `json:"sessions"`
+	Users              int64     `json:"users"`
+	AvgDurationSeconds float64   `json:"avg_duration_seconds"`
+	AvgEvents          float64   `json:"avg_events"`
+}
+
+type SessionStatsResponse struct {
+	Gap      string         `json:"gap"`
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	Timezone string         `json:"timezone"`
+	Days     []SessionStats `json:"days"`
+}
+
+// Session is a run of one install's events with no gap between two of them
+// reaching the inactivity gap. Events are in chronological order.
+type Session struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	EventCount      int       `json:"event_count"`
+	Events          []Event   `json:"events"`
+}
+
+// UserSessions lists the latest sessions of one install, newest first.
+// Truncated is set when the install sent more events in the range than are
+// looked at, so the oldest session may be incomplete.
+type UserSessions struct {
+	UserID    string    `json:"user_id"`
+	Gap       string    `json:"gap"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Truncated bool      `json:"truncated"`
+	Sessions  []Session `json:"sessions"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// SigningSecret is an HMAC secret shared with the clients of one release.
+// The secret is only returned once, when it is created.
+type SigningSecret struct {
+	ID        int64      `json:"id"`
+	Release   string     `json:"release"`
+	Key       []byte     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type CreateSigningSecretRequest struct {
+	Release string `json:"release"`
+}
+
+// CreatedSigningSecret is a new signing secret together with its value.
+type CreatedSigningSecret struct {
+	SigningSecret
+	Secret string `json:"secret"`
+}
+
+// RequestSignature holds the signature headers of an ingest request.
+type RequestSignature struct {
+	Release   string
+	Timestamp string
+	Signature string
+}
+
+// Signed reports whether the request carries any signature header.
+func (s RequestSignature) Signed() bool {
+	return s.Release != "" || s.Timestamp != "" || s.Signature != ""
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+)
+
+// Sources a timeseries can be computed from.
+const (
+	SourceEvents = "events"
+	SourceHourly = "events_hourly"
+	SourceDaily  = "events_daily"
+	// SourceDailyJakarta buckets days on Asia/Jakarta midnight.
+	SourceDailyJakarta = "events_daily_jakarta"
+)
+
+type TimeseriesQuery struct {
+	// EventName restricts the series to one event; empty counts all.
+	EventName string
+	Interval  Interval
+	From      time.Time
+	To        time.Time
+	// Location aligns day, week and month buckets on local midnight; nil
+	// means UTC.
+	Location *time.Location
+	// Compare, if set, compares every point with the aligned bucket of the
+	// previous period or year.
+	Compare string
+}
+
+// TimeseriesPoint is one bucket of a timeseries. Buckets without events are
+// reported with zero counts. UniqueUsers is estimated from HyperLogLog
+// sketches unless the series is computed from raw events.
+type TimeseriesPoint struct {
+	Bucket      time.Time `json:"bucket"`
+	EventCount  int64     `json:"event_count"`
+	UniqueUsers int64     `json:"unique_users"`
+	// Partial marks the bucket that has not ended yet.
+	Partial bool `json:"partial,omitempty"`
+	// Compare is set when the series is compared with an earlier period.
+	Compare *Comparison `json:"compare,omitempty"`
+}
+
+type TimeseriesResponse struct {
+	EventName string    `json:"event_name,omitempty"`
+	Interval  string    `json:"interval"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Timezone  string    `json:"timezone"`
+	Source    string    `json:"source"`
+	Compare   string    `json:"compare,omitempty"`
+	// UniqueUsersError is the relative standard error of the unique users
+	// of every point, 0 when they are exact.
+	UniqueUsersError float64           `json:"unique_users_error"`
+	Points           []TimeseriesPoint `json:"points"`
+}
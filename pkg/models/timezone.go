@@ -0,0 +1,53 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// JakartaTimezone (WIB) has continuous aggregates of its own, as most of
+// the community reports in it.
+const JakartaTimezone = "Asia/Jakarta"
+
+var ErrInvalidTimezone = errors.New("invalid timezone")
+
+// ParseTimezone loads an IANA timezone such as Asia/Makassar. The empty
+// string returns nil, which stands for UTC.
+func ParseTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return nil, nil
+	}
+	// "Local" would depend on the server's configuration
+	if name == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
+}
+
+// TimezoneName returns the IANA name of loc, treating nil as UTC.
+func TimezoneName(loc *time.Location) string {
+	if loc == nil {
+		return "UTC"
+	}
+	return loc.String()
+}
+
+// WholeHourOffsets reports whether loc is a whole number of hours away from
+// UTC both in winter and summer, so its hours line up with UTC hours.
+func WholeHourOffsets(loc *time.Location) bool {
+	if loc == nil {
+		return true
+	}
+	year := time.Now().Year()
+	for _, month := range []time.Month{time.January, time.July} {
+		_, offset := time.Date(year, month, 1, 0, 0, 0, 0, loc).Zone()
+		if offset%3600 != 0 {
+			return false
+		}
+	}
+	return true
+}
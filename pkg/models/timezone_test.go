@@ -0,0 +1,38 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimezone(t *testing.T) {
+	loc, err := ParseTimezone("")
+	require.NoError(t, err)
+	assert.Nil(t, loc)
+	assert.Equal(t, "UTC", TimezoneName(loc))
+
+	loc, err = ParseTimezone("Asia/Makassar")
+	require.NoError(t, err)
+	assert.Equal(t, "Asia/Makassar", TimezoneName(loc))
+
+	for _, name := range []string{"Local", "WIB", "Asia/Nowhere"} {
+		_, err := ParseTimezone(name)
+		assert.ErrorIs(t, err, ErrInvalidTimezone, name)
+	}
+}
+
+func TestWholeHourOffsets(t *testing.T) {
+	for name, want := range map[string]bool{
+		"UTC":              true,
+		"Asia/Jayapura":    true,
+		"Europe/Berlin":    true,
+		"Asia/Kolkata":     false,
+		"Australia/Darwin": false,
+	} {
+		loc, err := ParseTimezone(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, WholeHourOffsets(loc), name)
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// HLLSize is the number of registers of the HyperLogLog sketches in the
+// continuous aggregates, see migrations/012_hll_aggregates.sql.
+const HLLSize = 8192
+
+// HLLStdError is the relative standard error of unique counts estimated
+// from the sketches, about 1.15%.
+var HLLStdError = 1.04 / math.Sqrt(HLLSize)
+
+// UniqueUsersResponse is the approximate number of distinct installs that
+// sent events between From and To.
+type UniqueUsersResponse struct {
+	EventName   string    `json:"event_name,omitempty"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	UniqueUsers int64     `json:"unique_users"`
+	// StdError is the relative standard error of UniqueUsers.
+	StdError float64 `json:"std_error"`
+}